@@ -7,6 +7,19 @@ const (
 	CLEAR_LINE   = "\x1b[K"  // Clear line from cursor to end
 	CURSOR_HOME  = "\x1b[H"  // Move cursor to top-left (1,1)
 
+	// Alternate screen buffer, which keeps the shell's screen contents to be shown again on exit
+	ALTERNATE_SCREEN_ON  = "\x1b[?1049h"
+	ALTERNATE_SCREEN_OFF = "\x1b[?1049l"
+
+	// Clipboard, set by the terminal to the base64 encoded text between start and end
+	CLIPBOARD_SET_START = "\x1b]52;c;"
+	CLIPBOARD_SET_END   = "\a"
+
+	// Bell
+	TERMINAL_BELL     = "\a"
+	REVERSE_VIDEO_ON  = "\x1b[?5h" // Show the whole screen with inverted colors
+	REVERSE_VIDEO_OFF = "\x1b[?5l"
+
 	// Cursor visibility
 	CURSOR_HIDE = "\x1b[?25l" // Hide cursor
 	CURSOR_SHOW = "\x1b[?25h" // Show cursor
@@ -18,10 +31,15 @@ const (
 	// Format strings for dynamic positioning
 	CURSOR_POSITION_FORMAT = "\x1b[%d;%dH" // Format for moving cursor to specific row;col
 	CURSOR_RESPONSE_FORMAT = "\x1b[%d;%dR" // Format for parsing cursor position response
+	CURSOR_COLUMN_FORMAT   = "\x1b[%dG"    // Format for moving cursor to a column in the current row
 
 	// Text formatting
 	COLORS_RESET  = "\x1b[m"
 	COLORS_INVERT = "\x1b[7m"
+
+	// Background colors
+	BACKGROUND_HIGHLIGHT = "\x1b[100m" // Bright black background, for the line of the current search match
+	BACKGROUND_RESET     = "\x1b[49m"
 )
 
 // ANSI Graphics Mode Constants
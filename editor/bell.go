@@ -0,0 +1,51 @@
+package editor
+
+import "time"
+
+// Kinds of feedback given by Bell
+const (
+	BELL_NONE    = iota
+	BELL_VISUAL  // flash the screen
+	BELL_AUDIBLE // ring the terminal bell
+)
+
+// BELL_FLASH_DURATION is how long the screen is shown inverted by a visual bell,
+// unless a key is pressed before
+const BELL_FLASH_DURATION = 80 * time.Millisecond
+
+// Bell signals that a command could not be performed. The screen flashes or the
+// terminal bell rings with the next screen refresh, as configured.
+func (e *Editor) Bell() {
+	e.bellPending = true
+}
+
+// ringBell gives the feedback of a pending Bell, after the frame was written. A
+// flash is ended by waitForKey, so that it doesn't hold up the editor.
+func (e *Editor) ringBell() {
+	if !e.bellPending {
+		return
+	}
+	e.bellPending = false
+
+	out := e.writer()
+	switch e.config.Bell {
+	case BELL_AUDIBLE:
+		out.WriteString(TERMINAL_BELL)
+		out.Flush()
+	case BELL_VISUAL:
+		out.WriteString(REVERSE_VIDEO_ON)
+		out.Flush()
+		e.bellFlashing = true
+	}
+}
+
+// endFlash shows the screen normally again after a visual bell
+func (e *Editor) endFlash() {
+	if !e.bellFlashing {
+		return
+	}
+	e.bellFlashing = false
+	out := e.writer()
+	out.WriteString(REVERSE_VIDEO_OFF)
+	out.Flush()
+}
@@ -0,0 +1,57 @@
+package editor
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBell(t *testing.T) {
+	tests := []struct {
+		bell int
+		want string
+	}{
+		{BELL_NONE, ""},
+		{BELL_AUDIBLE, TERMINAL_BELL},
+		{BELL_VISUAL, REVERSE_VIDEO_ON},
+	}
+	for _, tt := range tests {
+		e := newTestEditor(10, 80, "only line")
+		e.config.Bell = tt.bell
+		var out bytes.Buffer
+		e.output = newOutput(&out)
+		e.RefreshScreen()
+
+		// Moving up from the first line is not possible
+		e.MoveCursor(ARROW_UP)
+		out.Reset()
+		e.RefreshScreen()
+		if tt.want != "" && !strings.Contains(out.String(), tt.want) {
+			t.Errorf("Bell %d: expected %q in the output", tt.bell, tt.want)
+		}
+		if tt.bell == BELL_VISUAL && (strings.Contains(out.String(), REVERSE_VIDEO_OFF) || !e.bellFlashing) {
+			t.Errorf("Expected the visual bell to flash until the next key")
+		}
+		if tt.bell == BELL_VISUAL {
+			out.Reset()
+			e.input = newInput(strings.NewReader("x"))
+			if c, err := e.waitForKey(); c != 'x' || err != nil {
+				t.Errorf("Expected the key pressed during the flash, got %q, %v", c, err)
+			}
+			if out.String() != REVERSE_VIDEO_OFF || e.bellFlashing {
+				t.Errorf("Expected the key to end the flash, got %q", out.String())
+			}
+		}
+		if tt.bell == BELL_NONE && strings.ContainsAny(out.String(), "\a") {
+			t.Errorf("Expected no bell when switched off")
+		}
+
+		// A possible move doesn't ring
+		e.MoveCursor(ARROW_RIGHT)
+		out.Reset()
+		e.RefreshScreen()
+		if strings.Contains(out.String(), TERMINAL_BELL) || strings.Contains(out.String(), REVERSE_VIDEO_ON) {
+			t.Errorf("Bell %d: expected no bell after a move", tt.bell)
+		}
+	}
+}
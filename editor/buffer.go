@@ -0,0 +1,278 @@
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// buffer holds the state of an open file while another buffer is shown
+type buffer struct {
+	rows               []editorRow
+	totalRows          int
+	cx, cy             int
+	rowOffset          int
+	colOffset          int
+	dirty              int
+	filename           string
+	syntax             *editorSyntax
+	detectedSyntax     *editorSyntax
+	indent             indentStyle
+	tabSize            int
+	lineEnding         string
+	trimTrailingSpace  bool
+	insertFinalNewline bool
+	finalNewline       bool
+	bom                bool
+	encoding           string
+	highlightOff       bool
+	hexControl         bool
+	stdoutContent      []byte
+	disk               diskState
+	undo               undoHistory
+	lastSave           time.Time
+}
+
+// closedFile remembers a file that was closed or replaced by another one, to reopen it
+type closedFile struct {
+	filename string
+	cx, cy   int
+}
+
+// storeBuffer saves the shown file into its buffer slot
+func (e *Editor) storeBuffer() {
+	if len(e.buffers) == 0 {
+		e.buffers = []buffer{{}}
+		e.currentBuffer = 0
+	}
+	e.buffers[e.currentBuffer] = buffer{
+		rows:               e.row,
+		totalRows:          e.totalRows,
+		cx:                 e.cx,
+		cy:                 e.cy,
+		rowOffset:          e.rowOffset,
+		colOffset:          e.colOffset,
+		dirty:              e.dirty,
+		filename:           e.filename,
+		syntax:             e.syntax,
+		detectedSyntax:     e.detectedSyntax,
+		indent:             e.indent,
+		tabSize:            e.tabSize,
+		lineEnding:         e.lineEnding,
+		trimTrailingSpace:  e.trimTrailingSpace,
+		insertFinalNewline: e.insertFinalNewline,
+		finalNewline:       e.finalNewline,
+		bom:                e.bom,
+		encoding:           e.encoding,
+		highlightOff:       e.highlightOff,
+		hexControl:         e.hexControl,
+		stdoutContent:      e.stdoutContent,
+		disk:               e.disk,
+		undo:               e.undo,
+		lastSave:           e.lastSave,
+	}
+}
+
+// loadBuffer shows the file of the given buffer slot
+func (e *Editor) loadBuffer(index int) {
+	b := e.buffers[index]
+	e.currentBuffer = index
+	e.row = b.rows
+	e.totalRows = b.totalRows
+	e.cx, e.cy = b.cx, b.cy
+	e.rowOffset = b.rowOffset
+	e.colOffset = b.colOffset
+	e.dirty = b.dirty
+	e.filename = b.filename
+	e.syntax = b.syntax
+	e.detectedSyntax = b.detectedSyntax
+	e.indent = b.indent
+	e.tabSize = b.tabSize
+	e.lineEnding = b.lineEnding
+	e.trimTrailingSpace = b.trimTrailingSpace
+	e.insertFinalNewline = b.insertFinalNewline
+	e.finalNewline = b.finalNewline
+	e.bom = b.bom
+	e.encoding = b.encoding
+	e.highlightOff = b.highlightOff
+	e.hexControl = b.hexControl
+	e.stdoutContent = b.stdoutContent
+	e.disk = b.disk
+	e.undo = b.undo
+	e.lastSave = b.lastSave
+
+	// Per-view state does not carry over to another file
+	e.clearSelection()
+	e.clearCursors()
+	e.resetGoalColumn()
+	e.indexedRows = 0
+	e.contentVersion++
+	e.invalidateFrame() // The lines drawn for the other file can't be reused
+}
+
+// bufferCount returns the number of open buffers
+func (e *Editor) bufferCount() int {
+	return max(len(e.buffers), 1)
+}
+
+// OpenBuffer opens a file in a new buffer and shows it. A file that doesn't exist yet
+// is shown empty and created when it is saved. If the file can't be opened, the new
+// buffer is discarded and the previous one is shown again.
+func (e *Editor) OpenBuffer(filename string) error {
+	e.storeBuffer()
+	previous := e.currentBuffer
+	e.buffers = append(e.buffers, buffer{})
+	e.currentBuffer = len(e.buffers) - 1
+	e.filename = "" // The new buffer doesn't replace the previous file
+
+	err := e.Open(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		err = e.Load(strings.NewReader(""), filename)
+	}
+	if err != nil {
+		e.buffers = e.buffers[:len(e.buffers)-1]
+		e.loadBuffer(previous)
+		return err
+	}
+	e.clearSelection()
+	e.clearCursors()
+	e.dirty = 0
+	e.stdoutContent = nil
+	return nil
+}
+
+// findBuffer returns the index of the buffer the file is open in, or -1 if it isn't open
+func (e *Editor) findBuffer(filename string) int {
+	filename = filepath.Clean(filename)
+	if filepath.Clean(e.filename) == filename {
+		return e.currentBuffer
+	}
+	for i, b := range e.buffers {
+		if i != e.currentBuffer && filepath.Clean(b.filename) == filename {
+			return i
+		}
+	}
+	return -1
+}
+
+// SwitchBuffer shows the buffer with the given 0-based index
+func (e *Editor) SwitchBuffer(index int) {
+	if index < 0 || index >= e.bufferCount() || index == e.currentBuffer {
+		return
+	}
+	e.storeBuffer()
+	e.loadBuffer(index)
+}
+
+// NextBuffer shows the next buffer, or the previous one for a negative direction, wrapping around
+func (e *Editor) NextBuffer(direction int) {
+	if e.bufferCount() < 2 {
+		e.SetStatusMessage("No other buffers")
+		return
+	}
+	e.SwitchBuffer((e.currentBuffer + direction + e.bufferCount()) % e.bufferCount())
+	e.SetStatusMessage("Buffer %s", e.bufferLabel())
+}
+
+// CloseBuffer closes the shown file, asking first if it has unsaved changes. The next
+// buffer is shown afterwards, or an empty scratch buffer if it was the last one.
+func (e *Editor) CloseBuffer() {
+	name := e.displayName()
+	if e.dirty > 0 {
+		key := e.PromptKey("Discard unsaved changes to %s? (y/n)", name)
+		if key != 'y' && key != 'Y' {
+			e.SetStatusMessage("Close cancelled")
+			return
+		}
+	}
+
+	e.rememberClosed()
+	if e.bufferCount() < 2 {
+		e.Load(strings.NewReader(""), "")
+		e.disk = diskState{}
+		e.stdoutContent = nil
+		e.buffers = nil
+		e.currentBuffer = 0
+		e.clearSelection()
+		e.clearCursors()
+		e.resetGoalColumn()
+	} else {
+		closed := e.currentBuffer
+		e.buffers = slices.Delete(e.buffers, closed, closed+1)
+		e.loadBuffer(min(closed, len(e.buffers)-1))
+		e.bufferClosed(closed)
+	}
+	e.SetStatusMessage("Closed %s", name)
+}
+
+// rememberClosed remembers the shown file and the cursor position in it before it is
+// closed or replaced, so that ReopenClosed can bring it back
+func (e *Editor) rememberClosed() {
+	if e.filename == "" || e.filename == STDIO_FILENAME {
+		return
+	}
+	e.lastClosed = closedFile{filename: e.filename, cx: e.cx, cy: e.cy}
+}
+
+// ReopenClosed opens the file that was closed or replaced last in a new buffer, with
+// the cursor where it was
+func (e *Editor) ReopenClosed() {
+	closed := e.lastClosed
+	if closed.filename == "" {
+		e.SetStatusMessage("No closed file to reopen")
+		return
+	}
+	if index := e.findBuffer(closed.filename); index >= 0 {
+		e.SwitchBuffer(index)
+		return
+	}
+	if _, err := os.Stat(closed.filename); err != nil {
+		e.ShowError("'%s' no longer exists", closed.filename)
+		return
+	}
+	if err := e.OpenBuffer(closed.filename); err != nil {
+		e.ShowError("%v", err)
+		return
+	}
+
+	// The file may have changed since, so the cursor is kept inside it
+	e.cy = min(closed.cy, max(e.totalRows-1, 0))
+	e.cx = 0
+	if e.cy < e.totalRows {
+		e.cx = min(closed.cx, len(e.row[e.cy].chars))
+	}
+	e.EnsureCursorVisible()
+	e.lastClosed = closedFile{}
+	e.SetStatusMessage("Reopened %s", closed.filename)
+}
+
+// displayName returns the filename of the shown buffer, or "[No Name]" if it has none yet
+func (e *Editor) displayName() string {
+	if e.filename == "" {
+		return "[No Name]"
+	}
+	return e.filename
+}
+
+// bufferLabel describes the current buffer like "2/3", or "" if there is only one
+func (e *Editor) bufferLabel() string {
+	if e.bufferCount() < 2 {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", e.currentBuffer+1, e.bufferCount())
+}
+
+// otherBuffersDirty reports whether any buffer besides the shown one has unsaved changes
+func (e *Editor) otherBuffersDirty() bool {
+	for i, b := range e.buffers {
+		if i != e.currentBuffer && b.dirty > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package editor
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenBufferAndSwitch(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.txt")
+	second := filepath.Join(dir, "second.go")
+	os.WriteFile(first, []byte("one\ntwo\n"), 0644)
+	os.WriteFile(second, []byte("package main\n"), 0644)
+
+	e := newTestEditor(10, 80)
+	e.config = DefaultConfig()
+	if err := e.Open(first); err != nil {
+		t.Fatal(err)
+	}
+	e.cy = 1
+	e.InsertChar('x')
+
+	if err := e.OpenBuffer(second); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.OpenBuffer(dir); err == nil {
+		t.Fatalf("Expected an error for a directory")
+	}
+	if e.filename != second || e.bufferCount() != 2 || e.syntax == nil || e.dirty != 0 {
+		t.Fatalf("Expected second file shown in 2 buffers, got %q in %d", e.filename, e.bufferCount())
+	}
+	if !e.otherBuffersDirty() {
+		t.Errorf("Expected the first buffer to be dirty")
+	}
+
+	e.NextBuffer(1)
+	if e.filename != first || e.cy != 1 || string(e.row[1].chars) != "xtwo" || e.syntax != nil {
+		t.Errorf("Expected first buffer restored, got %q at row %d", e.filename, e.cy)
+	}
+}
+
+func TestOpenBufferNewFile(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.txt")
+	missing := filepath.Join(dir, "new.go")
+	os.WriteFile(first, []byte("one\n"), 0644)
+
+	e := newTestEditor(10, 80)
+	e.config = DefaultConfig()
+	if err := e.Open(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.OpenBuffer(missing); err != nil {
+		t.Fatalf("Expected a missing file to be opened empty, got %v", err)
+	}
+	if e.filename != missing || e.bufferCount() != 2 || e.totalRows != 0 || e.syntax == nil {
+		t.Fatalf("Expected an empty Go buffer for %q, got %q with %d rows in %d buffers", missing, e.filename, e.totalRows, e.bufferCount())
+	}
+
+	e.NextBuffer(1)
+	if e.filename != first || string(e.row[0].chars) != "one" {
+		t.Errorf("Expected the first file to be kept, got %q", e.filename)
+	}
+}
+
+func TestCloseBuffer(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.txt")
+	second := filepath.Join(dir, "second.go")
+	os.WriteFile(first, []byte("one\n"), 0644)
+	os.WriteFile(second, []byte("package main\n"), 0644)
+
+	e := newTestEditor(10, 80)
+	e.config = DefaultConfig()
+	e.output = newOutput(io.Discard)
+	e.Open(first)
+	e.OpenBuffer(second)
+	e.InsertChar('x')
+
+	// Declining the confirmation keeps the modified buffer open
+	e.input = newInput(strings.NewReader("n"))
+	e.CloseBuffer()
+	if e.filename != second || e.bufferCount() != 2 {
+		t.Fatalf("Expected the buffer to stay open, got %q in %d buffers", e.filename, e.bufferCount())
+	}
+
+	e.input = newInput(strings.NewReader("y"))
+	e.CloseBuffer()
+	if e.filename != first || e.bufferCount() != 1 || string(e.row[0].chars) != "one" {
+		t.Fatalf("Expected the first buffer to be shown, got %q in %d buffers", e.filename, e.bufferCount())
+	}
+
+	e.CloseBuffer()
+	if e.filename != "" || e.syntax != nil || e.totalRows != 0 || e.dirty != 0 || e.bufferCount() != 1 {
+		t.Errorf("Expected an empty scratch buffer, got %q with %d rows", e.filename, e.totalRows)
+	}
+}
+
+func TestToggleHeaderSource(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "list.c")
+	header := filepath.Join(dir, "list.h")
+	os.WriteFile(source, []byte("#include \"list.h\"\n"), 0644)
+	os.WriteFile(header, []byte("struct list;\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "alone.cpp"), []byte(""), 0644)
+
+	e := newTestEditor(10, 80)
+	e.config = DefaultConfig()
+	e.Open(source)
+	e.InsertChar('x')
+
+	// The modified source stays open in its buffer
+	e.ToggleHeaderSource()
+	if e.filename != header || e.bufferCount() != 2 {
+		t.Fatalf("Expected the header to be opened in a new buffer, got %q in %d", e.filename, e.bufferCount())
+	}
+	e.ToggleHeaderSource()
+	if e.filename != source || e.bufferCount() != 2 || e.dirty == 0 {
+		t.Errorf("Expected to switch back to the modified source, got %q in %d", e.filename, e.bufferCount())
+	}
+
+	e.OpenBuffer(filepath.Join(dir, "alone.cpp"))
+	e.ToggleHeaderSource()
+	if e.messagePriority != MESSAGE_ERROR || e.bufferCount() != 3 {
+		t.Errorf("Expected an error for a file without a header, got %q", e.statusMessage)
+	}
+}
+
+func TestReopenClosed(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.txt")
+	second := filepath.Join(dir, "second.txt")
+	os.WriteFile(first, []byte("one\ntwo\nthree\n"), 0644)
+	os.WriteFile(second, []byte("other\n"), 0644)
+
+	e := newTestEditor(10, 80)
+	e.config = DefaultConfig()
+	e.ReopenClosed()
+	if e.statusMessage != "No closed file to reopen" {
+		t.Errorf("Expected nothing to reopen, got %q", e.statusMessage)
+	}
+
+	// Replacing a file remembers it with the cursor position
+	e.Open(first)
+	e.cx, e.cy = 3, 2
+	e.Open(second)
+	e.ReopenClosed()
+	if e.filename != first || e.bufferCount() != 2 || e.cx != 3 || e.cy != 2 {
+		t.Fatalf("Expected %q reopened at 3,2 in a new buffer, got %q at %d,%d in %d", first, e.filename, e.cx, e.cy, e.bufferCount())
+	}
+
+	e.CloseBuffer()
+	os.Remove(first)
+	e.ReopenClosed()
+	if e.messagePriority != MESSAGE_ERROR || e.filename != second {
+		t.Errorf("Expected an error for a removed file, got %q", e.statusMessage)
+	}
+}
@@ -0,0 +1,163 @@
+package editor
+
+import (
+	"encoding/base64"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+)
+
+// clipboardTool is a command line tool that accesses the system clipboard
+type clipboardTool struct {
+	env   string   // environment variable that must be set for the tool to work, if any
+	copy  []string // command that reads the text to copy from its standard input
+	paste []string // command that writes the clipboard text to its standard output
+}
+
+// clipboardTools are tried in order, the first one that is installed is used
+var clipboardTools = []clipboardTool{
+	{env: "WAYLAND_DISPLAY", copy: []string{"wl-copy"}, paste: []string{"wl-paste", "--no-newline"}},
+	{env: "DISPLAY", copy: []string{"xclip", "-selection", "clipboard"}, paste: []string{"xclip", "-selection", "clipboard", "-o"}},
+	{env: "DISPLAY", copy: []string{"xsel", "--clipboard", "--input"}, paste: []string{"xsel", "--clipboard", "--output"}},
+	{copy: []string{"pbcopy"}, paste: []string{"pbpaste"}},
+	{copy: []string{"clip.exe"}, paste: []string{"powershell.exe", "-NoProfile", "-Command", "Get-Clipboard"}},
+}
+
+// findClipboardTool returns the first clipboard tool that can be used, or nil
+func findClipboardTool() *clipboardTool {
+	for i, tool := range clipboardTools {
+		if tool.env != "" && os.Getenv(tool.env) == "" {
+			continue
+		}
+		if _, err := exec.LookPath(tool.copy[0]); err == nil {
+			return &clipboardTools[i]
+		}
+	}
+	return nil
+}
+
+// selectModeKeys are the keys that move the cursor and extend the selection in SELECT_MODE
+var selectModeKeys = map[int]bool{
+	ARROW_LEFT:     true,
+	ARROW_RIGHT:    true,
+	ARROW_UP:       true,
+	ARROW_DOWN:     true,
+	HOME_KEY:       true,
+	END_KEY:        true,
+	SMART_HOME_KEY: true,
+	PAGE_UP:        true,
+	PAGE_DOWN:      true,
+	CTRL_HOME_KEY:  true,
+	CTRL_END_KEY:   true,
+}
+
+// ToggleSelectMode anchors a selection at the cursor that the keys moving the cursor
+// extend without Shift, until another key is pressed. Invoked again, it stops selecting.
+func (e *Editor) ToggleSelectMode() {
+	if e.mode == SELECT_MODE {
+		e.mode = EDIT_MODE
+		e.clearSelection()
+		return
+	}
+	if !e.selection.active {
+		e.selection = selection{active: true, anchorX: e.cx, anchorY: e.cy}
+	}
+	e.mode = SELECT_MODE
+	e.SetStatusMessage("Selecting: move the cursor, then Ctrl-C to copy or Ctrl-X to cut")
+}
+
+// Copy puts the selected text into the clipboard, which is shared by all buffers
+func (e *Editor) Copy() bool {
+	r, ok := e.selectionRange()
+	if !ok {
+		e.SetStatusMessage("Nothing selected to copy")
+		return false
+	}
+	e.CopyToClipboard(e.rangeText(r))
+	e.SetStatusMessage("Copied %d characters", utf8.RuneCountInString(e.clipboard))
+	return true
+}
+
+// Cut puts the selected text into the clipboard and deletes it
+func (e *Editor) Cut() {
+	if e.Copy() {
+		e.DeleteSelection()
+	}
+}
+
+// Paste inserts the text of the clipboard at the cursor, in place of the selected
+// text. The cursor ends up after the inserted text.
+func (e *Editor) Paste() {
+	text := e.PasteFromClipboard()
+	if text == "" {
+		e.SetStatusMessage("The clipboard is empty")
+		return
+	}
+	e.DeleteSelection()
+	e.insertText(text)
+}
+
+// CopyToClipboard makes text the text that is pasted. With config.SystemClipboard
+// it is copied to the system clipboard as well.
+func (e *Editor) CopyToClipboard(text string) {
+	e.clipboard = text
+	if !e.config.SystemClipboard {
+		return
+	}
+	e.clipboardPending = text
+	if tool := findClipboardTool(); tool != nil {
+		cmd := exec.Command(tool.copy[0], tool.copy[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err != nil {
+			e.logDiagnostic("copying with %s: %v", tool.copy[0], err)
+		}
+	}
+}
+
+// PasteFromClipboard returns the text to paste: with config.SystemClipboard the text of
+// the system clipboard if a clipboard tool can read it, otherwise the text copied last
+func (e *Editor) PasteFromClipboard() string {
+	if !e.config.SystemClipboard {
+		return e.clipboard
+	}
+	tool := findClipboardTool()
+	if tool == nil {
+		return e.clipboard
+	}
+	out, err := exec.Command(tool.paste[0], tool.paste[1:]...).Output()
+	if err != nil {
+		e.logDiagnostic("pasting with %s: %v", tool.paste[0], err)
+		return e.clipboard
+	}
+	return strings.ReplaceAll(string(out), "\r\n", "\n")
+}
+
+// sendClipboard sends the text of a pending copy to the terminal's clipboard,
+// after the frame was written
+func (e *Editor) sendClipboard() {
+	if e.clipboardPending == "" {
+		return
+	}
+	out := e.writer()
+	out.WriteString(CLIPBOARD_SET_START + base64.StdEncoding.EncodeToString([]byte(e.clipboardPending)) + CLIPBOARD_SET_END)
+	out.Flush()
+	e.clipboardPending = ""
+}
+
+// insertText inserts text at the cursor as it is, without indenting its lines,
+// and moves the cursor after it
+func (e *Editor) insertText(text string) {
+	if e.cy == e.totalRows {
+		e.InsertRow(e.totalRows, []byte(""), 0)
+	}
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			e.splitRow(nil)
+			e.cy++
+			e.cx = 0
+		}
+		e.row[e.cy].replaceBytes(e, e.cx, e.cx, []byte(line))
+		e.cx += len(line)
+	}
+}
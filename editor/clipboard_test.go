@@ -0,0 +1,76 @@
+package editor
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"testing"
+)
+
+func TestSelectModeCopyPaste(t *testing.T) {
+	e := newTestEditor(10, 80, "first line", "second line")
+	e.output = newOutput(io.Discard)
+	e.config = DefaultConfig()
+	e.config.SystemClipboard = false
+	e.cx = 6
+
+	// Ctrl-B, then the arrow keys select without Shift until the text is copied
+	pressKeys(e, "\x02", "\x1b[B", "\x03")
+	if e.clipboard != "line\nsecond" || e.mode != EDIT_MODE || e.selection.active {
+		t.Fatalf("Expected %q copied and selecting to end, got %q", "line\nsecond", e.clipboard)
+	}
+
+	pressKeys(e, "\x1b[F", "\x15")
+	if got := e.Lines(); !slices.Equal(got, []string{"first line", "second lineline", "second"}) {
+		t.Errorf("Expected the text pasted at the end of the line, got %q", got)
+	}
+	if e.cy != 2 || e.cx != 6 {
+		t.Errorf("Expected the cursor after the pasted text, got (%d,%d)", e.cy, e.cx)
+	}
+}
+
+func TestCutPasteReplacesSelection(t *testing.T) {
+	e := newTestEditor(10, 80, "one two three")
+	e.output = newOutput(io.Discard)
+	e.config = DefaultConfig()
+	e.config.SystemClipboard = false
+
+	// Cut "one ", then paste it over the selected "three"
+	pressKeys(e, "\x1b[1;2C", "\x1b[1;2C", "\x1b[1;2C", "\x1b[1;2C", "\x18")
+	e.selection = selection{active: true, anchorX: 4, anchorY: 0}
+	e.cx = 9
+	pressKeys(e, "\x15")
+	if got := e.Lines(); !slices.Equal(got, []string{"two one "}) {
+		t.Errorf("Expected the selection to be replaced, got %q", got)
+	}
+
+	// Other keys than moves end selecting without changing the clipboard
+	pressKeys(e, "\x02", "\x1b[D", "x")
+	if e.mode != EDIT_MODE || e.clipboard != "one " {
+		t.Errorf("Expected typing to end selecting, got mode %d", e.mode)
+	}
+}
+
+func TestCopyToTerminalClipboard(t *testing.T) {
+	t.Setenv("PATH", "") // No clipboard tools
+	e := newTestEditor(10, 80, "text")
+	var out bytes.Buffer
+	e.output = newOutput(&out)
+	e.config = DefaultConfig()
+
+	e.CopyToClipboard("héllo")
+	e.RefreshScreen()
+	if want := CLIPBOARD_SET_START + "aMOpbGxv" + CLIPBOARD_SET_END; !bytes.HasSuffix(out.Bytes(), []byte(want)) {
+		t.Errorf("Expected the text to be sent to the terminal after the frame, got %q", out.String())
+	}
+
+	// Without a tool to read the system clipboard, the text copied last is pasted
+	out.Reset()
+	e.RefreshScreen()
+	if bytes.Contains(out.Bytes(), []byte(CLIPBOARD_SET_START)) {
+		t.Errorf("Expected the text to be sent only once")
+	}
+	if got := e.PasteFromClipboard(); got != "héllo" {
+		t.Errorf("Expected the copied text to be pasted, got %q", got)
+	}
+}
@@ -0,0 +1,109 @@
+package editor
+
+import "strings"
+
+// blockCommentRange returns the text that a block comment is toggled around: the
+// selection, or the current line without its indentation. Whitespace at either
+// end is left out. It returns ok false if there is no text.
+func (e *Editor) blockCommentRange() (r textRange, ok bool) {
+	startY, startX, endY, endX, selected := e.selectionBounds()
+	if !selected || (startY == endY && startX == endX) {
+		startY, startX, endY, endX = e.cy, 0, e.cy, len(e.row[e.cy].chars)
+	}
+	if endY >= e.totalRows {
+		endY, endX = e.totalRows-1, len(e.row[e.totalRows-1].chars)
+	}
+	if endX == 0 && endY > startY {
+		// A selection of whole lines ends at the start of the next line
+		endY--
+		endX = len(e.row[endY].chars)
+	}
+	startX = min(startX, len(e.row[startY].chars))
+	endX = min(endX, len(e.row[endY].chars))
+
+	isBlank := func(c byte) bool { return c == ' ' || c == '\t' }
+	for startX < len(e.row[startY].chars) && isBlank(e.row[startY].chars[startX]) {
+		startX++
+	}
+	for endX > 0 && isBlank(e.row[endY].chars[endX-1]) && (endY > startY || endX > startX) {
+		endX--
+	}
+	if startY == endY && startX >= endX {
+		return textRange{}, false
+	}
+	return textRange{startY, startX, endY, endX}, true
+}
+
+// rangeText returns the text of r with the rows joined by "\n"
+func (e *Editor) rangeText(r textRange) string {
+	if r.startY == r.endY {
+		return string(e.row[r.startY].chars[r.startX:r.endX])
+	}
+	lines := []string{string(e.row[r.startY].chars[r.startX:])}
+	for y := r.startY + 1; y < r.endY; y++ {
+		lines = append(lines, string(e.row[y].chars))
+	}
+	lines = append(lines, string(e.row[r.endY].chars[:r.endX]))
+	return strings.Join(lines, "\n")
+}
+
+// ToggleBlockComment wraps the selection, or the current line, in the block comment
+// markers of the filetype, or unwraps it if it already starts and ends with them.
+// Block comments don't nest, so text that contains the end marker isn't wrapped.
+// The wrapped or unwrapped text is selected afterwards.
+func (e *Editor) ToggleBlockComment() {
+	if e.syntax == nil || e.syntax.multilineCommentStart == "" || e.syntax.multilineCommentEnd == "" {
+		e.SetStatusMessage("No block comments for this filetype")
+		return
+	}
+	if e.cy >= e.totalRows {
+		return
+	}
+	r, ok := e.blockCommentRange()
+	if !ok {
+		e.SetStatusMessage("Nothing to comment")
+		return
+	}
+	open, close := e.syntax.multilineCommentStart, e.syntax.multilineCommentEnd
+	text := e.rangeText(r)
+
+	// Text like "/* a */ b /* c */" is two comments, not one to unwrap
+	wrapped := strings.HasPrefix(text, open) && strings.HasSuffix(text, close) &&
+		len(text) >= len(open)+len(close) && !strings.Contains(text[len(open):len(text)-len(close)], close)
+
+	// The end of the range is changed first, so the start stays where it is
+	if wrapped {
+		endRow := &e.row[r.endY]
+		from := r.endX - len(close)
+		if from > 0 && endRow.chars[from-1] == ' ' && (r.endY > r.startY || from-1 >= r.startX+len(open)) {
+			from--
+		}
+		endRow.replaceBytes(e, from, r.endX, nil)
+		r.endX = from
+
+		startRow := &e.row[r.startY]
+		to := r.startX + len(open)
+		if to < len(startRow.chars) && startRow.chars[to] == ' ' && (r.endY > r.startY || to < r.endX) {
+			to++
+		}
+		startRow.replaceBytes(e, r.startX, to, nil)
+		if r.endY == r.startY {
+			r.endX -= to - r.startX
+		}
+		e.SetStatusMessage("Block comment removed")
+	} else {
+		if strings.Contains(text, close) {
+			e.ShowWarning("can't nest block comments: the text contains %s", close)
+			return
+		}
+		e.row[r.endY].replaceBytes(e, r.endX, r.endX, []byte(" "+close))
+		r.endX += len(close) + 1
+		e.row[r.startY].replaceBytes(e, r.startX, r.startX, []byte(open+" "))
+		if r.endY == r.startY {
+			r.endX += len(open) + 1
+		}
+	}
+
+	e.selection = selection{active: true, anchorX: r.startX, anchorY: r.startY}
+	e.cx, e.cy = r.endX, r.endY
+}
@@ -0,0 +1,61 @@
+package editor
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestToggleBlockCommentLine(t *testing.T) {
+	e := newTestEditor(10, 80)
+	e.Load(strings.NewReader("\tx := 1\ny := 2"), "main.go")
+
+	e.ToggleBlockComment()
+	if got := e.Lines(); !slices.Equal(got, []string{"\t/* x := 1 */", "y := 2"}) {
+		t.Fatalf("Expected the line to be wrapped after its indentation, got %q", got)
+	}
+	if e.row[0].hl[len(e.row[0].hl)-1] != HL_MLCOMMENT || e.row[1].hl[0] == HL_MLCOMMENT {
+		t.Errorf("Expected only the wrapped line to be highlighted as a comment")
+	}
+
+	e.clearSelection()
+	e.ToggleBlockComment()
+	if got := e.Lines(); !slices.Equal(got, []string{"\tx := 1", "y := 2"}) {
+		t.Errorf("Expected the line to be unwrapped, got %q", got)
+	}
+}
+
+func TestToggleBlockCommentSelection(t *testing.T) {
+	e := newTestEditor(10, 80)
+	e.Load(strings.NewReader("a := 1\nb := 2\nc := 3"), "main.go")
+
+	// Selecting whole lines ends at the start of the line after them
+	e.selection = selection{active: true, anchorX: 0, anchorY: 0}
+	e.cy, e.cx = 2, 0
+	e.ToggleBlockComment()
+	if got := e.Lines(); !slices.Equal(got, []string{"/* a := 1", "b := 2 */", "c := 3"}) {
+		t.Fatalf("Expected the selected lines to be wrapped, got %q", got)
+	}
+	if e.row[1].hl[0] != HL_MLCOMMENT || e.row[2].hl[0] == HL_MLCOMMENT {
+		t.Errorf("Expected the comment to end on the second line")
+	}
+
+	// The wrapped text stays selected, so toggling again unwraps it
+	e.ToggleBlockComment()
+	if got := e.Lines(); !slices.Equal(got, []string{"a := 1", "b := 2", "c := 3"}) {
+		t.Errorf("Expected the selected lines to be unwrapped, got %q", got)
+	}
+}
+
+func TestToggleBlockCommentDoesNotNest(t *testing.T) {
+	e := newTestEditor(10, 80)
+	e.Load(strings.NewReader("/* a */ b /* c */"), "main.go")
+
+	e.ToggleBlockComment()
+	if got := e.Lines(); !slices.Equal(got, []string{"/* a */ b /* c */"}) {
+		t.Errorf("Expected the line to be left alone, got %q", got)
+	}
+	if !strings.Contains(e.statusMessage, "can't nest") {
+		t.Errorf("Expected a warning, got %q", e.statusMessage)
+	}
+}
@@ -0,0 +1,192 @@
+package editor
+
+import "time"
+
+// Config holds the user-tunable settings of the editor
+type Config struct {
+	// EscapeTimeout is how long to wait after an ESC byte for the rest of an
+	// escape sequence before treating it as a lone Escape keypress. Zero or less
+	// means ESCAPE_TIMEOUT.
+	EscapeTimeout time.Duration
+
+	// AlternateScreen draws on the terminal's alternate screen buffer, so that the
+	// shell's screen contents are shown again after quitting instead of the editor's
+	AlternateScreen bool
+
+	// ShowScrollbar reserves the rightmost column for a scroll position indicator
+	ShowScrollbar bool
+
+	// LineNumbers shows a gutter with line numbers left of the text: LINE_NUMBERS_ABSOLUTE
+	// numbers the lines, LINE_NUMBERS_RELATIVE counts them from the cursor's line and
+	// LINE_NUMBERS_HYBRID does both, numbering the cursor's line. LINE_NUMBERS_OFF hides it.
+	LineNumbers int
+
+	// TabStop is the display width of a tab, unless a buffer's .editorconfig sets another
+	TabStop int
+
+	// QuitTimes is how many more times Ctrl-Q has to be pressed to quit with unsaved changes
+	QuitTimes int
+
+	// ExpandTab indents with spaces instead of a tab character
+	ExpandTab bool
+
+	// IndentWidth is the number of spaces per indentation level when ExpandTab is set
+	IndentWidth int
+
+	// SoftTabBackspace makes Backspace in the leading spaces of a line indented with
+	// spaces delete back to the previous indentation level
+	SoftTabBackspace bool
+
+	// DetectIndent infers the indentation style of opened files instead of using
+	// ExpandTab and IndentWidth
+	DetectIndent bool
+
+	// AutoIndent starts a new line with the indentation of the line it was split from
+	AutoIndent bool
+
+	// EditorConfig applies settings from .editorconfig files to opened files
+	EditorConfig bool
+
+	// InsertFinalNewline makes saved files always end with a line ending.
+	// Otherwise files keep whether they ended with one when opened.
+	InsertFinalNewline bool
+
+	// TrailingNewlineRow shows the line ending at the end of a file as an empty last
+	// row, so the cursor can sit below the last line of content
+	TrailingNewlineRow bool
+
+	// HexControlChars shows control characters as \xNN hex escapes instead of ^X caret notation
+	HexControlChars bool
+
+	// WarnMixedIndent warns when an opened file indents with both tabs and spaces
+	WarnMixedIndent bool
+
+	// ContinueComments repeats the filetype's line comment marker on a new line
+	// split off from a comment
+	ContinueComments bool
+
+	// ContinuePrefixes lists the markers, per filetype, that are repeated on a new line
+	// split off from a line starting with them. "" is the key for files without a filetype.
+	ContinuePrefixes map[string][]string
+
+	// DedentBrackets lists, per filetype, the closing brackets that are dedented to
+	// line up with the line of the matching opening bracket when typed on a line that
+	// is blank up to the cursor. It only applies with AutoIndent.
+	DedentBrackets map[string]string
+
+	// Snippets maps, per filetype, trigger words to the text that replaces them when
+	// Tab is pressed right after one. "" is the key for files without a filetype.
+	// An expansion may span several lines, and "$0" in it marks where the cursor goes.
+	Snippets map[string]map[string]string
+
+	// HighlightWord underlines the other occurrences of the word under the cursor
+	HighlightWord bool
+
+	// AutoSave saves a file with unsaved changes once no key was pressed for that long.
+	// Files without a name and files changed on disk are left alone. Zero disables it.
+	AutoSave time.Duration
+
+	// DiskCheckInterval is how often the open file is checked for changes on disk
+	// while waiting for input. Zero disables the check.
+	DiskCheckInterval time.Duration
+
+	// WrapCursor moves Left at the start of a line to the end of the previous line,
+	// and Right at the end of a line to the start of the next one
+	WrapCursor bool
+
+	// MessageTimeout is how long status messages are shown. Zero means MESSAGE_TIMEOUT.
+	MessageTimeout time.Duration
+
+	// ErrorTimeout is how long warnings and errors are shown. Zero means MESSAGE_TIMEOUT.
+	ErrorTimeout time.Duration
+
+	// StickyErrors keeps warnings and errors shown until the next keypress, ignoring ErrorTimeout
+	StickyErrors bool
+
+	// RecentFilesPath is the file that remembers recently opened files for the start
+	// screen. Empty disables remembering them.
+	RecentFilesPath string
+
+	// Bell is the feedback for commands that can't be performed: BELL_VISUAL flashes
+	// the screen, BELL_AUDIBLE rings the terminal bell and BELL_NONE, the default, gives none
+	Bell int
+
+	// Keymap binds keys like "ctrl-a", "alt-x" or "home" to named commands, taking the
+	// place of what the key does by default. Emacs-style navigation, for example, is
+	// {"ctrl-a": "smart-home", "ctrl-e": "line-end", "alt-f": "explorer"}.
+	// The commands are listed in commandKeys.
+	Keymap map[string]string
+
+	// DebugKeys shows the raw bytes of key sequences that aren't recognized in the
+	// message bar. They are written to the diagnostics log either way.
+	DebugKeys bool
+
+	// SystemClipboard shares copied and cut text with the system clipboard: through the
+	// terminal with an OSC 52 sequence, and with a clipboard tool like xclip or pbcopy
+	// for terminals that don't support it. Pasting reads from the tool if there is one.
+	SystemClipboard bool
+
+	// Colors replaces the colors of highlighting classes, by their names in
+	// highlightClasses, with ANSI color codes like ANSI_COLOR_RED
+	Colors map[string]int
+
+	// QuickOpenIgnore lists file and directory names skipped when listing files for quick open
+	QuickOpenIgnore []string
+}
+
+// DefaultConfig returns the settings used when nothing else is configured
+func DefaultConfig() Config {
+	return Config{
+		EscapeTimeout:      ESCAPE_TIMEOUT,
+		AlternateScreen:    true,
+		ShowScrollbar:      false,
+		TabStop:            TAB_STOP,
+		QuitTimes:          QUIT_TIMES,
+		LineNumbers:        LINE_NUMBERS_OFF,
+		ExpandTab:          false,
+		IndentWidth:        TAB_STOP,
+		SoftTabBackspace:   true,
+		DetectIndent:       true,
+		AutoIndent:         true,
+		EditorConfig:       true,
+		WarnMixedIndent:    true,
+		InsertFinalNewline: false,
+		TrailingNewlineRow: false,
+		ContinueComments:   true,
+		ContinuePrefixes: map[string][]string{
+			"":         {"- ", "* ", "+ ", "> "},
+			"markdown": {"- ", "* ", "+ ", "> "},
+		},
+		DedentBrackets: map[string]string{
+			"c":  "})]",
+			"go": "})]",
+		},
+		Snippets: map[string]map[string]string{
+			"go": {
+				"iferr": "if err != nil {\n\treturn $0\n}",
+				"fori":  "for i := 0; i < $0; i++ {\n}",
+			},
+		},
+		Keymap:            map[string]string{"ctrl-a": "smart-home"},
+		HighlightWord:     false,
+		DiskCheckInterval: 2 * time.Second,
+		WrapCursor:        true,
+		MessageTimeout:    MESSAGE_TIMEOUT,
+		ErrorTimeout:      10 * time.Second,
+		StickyErrors:      false,
+		Bell:              BELL_NONE,
+		SystemClipboard:   true,
+		QuickOpenIgnore:   []string{".git", ".hg", ".svn", "node_modules", "vendor"},
+	}
+}
+
+// SetConfig replaces the editor configuration
+func (e *Editor) SetConfig(config Config) {
+	e.config = config
+	e.quitTimes = config.QuitTimes
+	keymap, err := parseKeymap(config.Keymap)
+	if err != nil {
+		e.ShowWarning("%v", err)
+	}
+	e.keymap = keymap
+}
@@ -0,0 +1,285 @@
+package editor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// configSetting parses the value of a setting in the config file into the config
+type configSetting func(c *Config, value string) error
+
+// boolSetting is a setting of "true" or "false"
+func boolSetting(field func(c *Config) *bool) configSetting {
+	return func(c *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("expected true or false, got %q", value)
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+// intSetting is a setting of a whole number no less than least
+func intSetting(field func(c *Config) *int, least int) configSetting {
+	return func(c *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < least {
+			return fmt.Errorf("expected a number of at least %d, got %q", least, value)
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+// durationSetting is a setting of a duration like "30s" or "500ms", or "0" for none
+func durationSetting(field func(c *Config) *time.Duration) configSetting {
+	return func(c *Config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return fmt.Errorf("expected a duration like 30s, got %q", value)
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+// choiceSetting is a setting of one of the names of choices
+func choiceSetting(field func(c *Config) *int, choices map[string]int) configSetting {
+	return func(c *Config, value string) error {
+		choice, ok := choices[value]
+		if !ok {
+			names := slices.Sorted(maps.Keys(choices))
+			return fmt.Errorf("expected one of %s, got %q", strings.Join(names, ", "), value)
+		}
+		*field(c) = choice
+		return nil
+	}
+}
+
+// configSettings are the settings outside of sections in the config file, by name
+var configSettings = map[string]configSetting{
+	"tab-stop":             intSetting(func(c *Config) *int { return &c.TabStop }, 1),
+	"quit-times":           intSetting(func(c *Config) *int { return &c.QuitTimes }, 0),
+	"indent-width":         intSetting(func(c *Config) *int { return &c.IndentWidth }, 1),
+	"expand-tab":           boolSetting(func(c *Config) *bool { return &c.ExpandTab }),
+	"auto-indent":          boolSetting(func(c *Config) *bool { return &c.AutoIndent }),
+	"detect-indent":        boolSetting(func(c *Config) *bool { return &c.DetectIndent }),
+	"editorconfig":         boolSetting(func(c *Config) *bool { return &c.EditorConfig }),
+	"insert-final-newline": boolSetting(func(c *Config) *bool { return &c.InsertFinalNewline }),
+	"show-scrollbar":       boolSetting(func(c *Config) *bool { return &c.ShowScrollbar }),
+	"alternate-screen":     boolSetting(func(c *Config) *bool { return &c.AlternateScreen }),
+	"highlight-word":       boolSetting(func(c *Config) *bool { return &c.HighlightWord }),
+	"wrap-cursor":          boolSetting(func(c *Config) *bool { return &c.WrapCursor }),
+	"system-clipboard":     boolSetting(func(c *Config) *bool { return &c.SystemClipboard }),
+	"sticky-errors":        boolSetting(func(c *Config) *bool { return &c.StickyErrors }),
+	"auto-save":            durationSetting(func(c *Config) *time.Duration { return &c.AutoSave }),
+	"disk-check-interval":  durationSetting(func(c *Config) *time.Duration { return &c.DiskCheckInterval }),
+	"line-numbers": choiceSetting(func(c *Config) *int { return &c.LineNumbers }, map[string]int{
+		"off": LINE_NUMBERS_OFF, "absolute": LINE_NUMBERS_ABSOLUTE,
+		"relative": LINE_NUMBERS_RELATIVE, "hybrid": LINE_NUMBERS_HYBRID,
+	}),
+	"bell": choiceSetting(func(c *Config) *int { return &c.Bell }, map[string]int{
+		"none": BELL_NONE, "visual": BELL_VISUAL, "audible": BELL_AUDIBLE,
+	}),
+}
+
+// colorNames are the colors that can be given by name in the [colors] section
+var colorNames = map[string]int{
+	"red":     ANSI_COLOR_RED,
+	"green":   ANSI_COLOR_GREEN,
+	"yellow":  ANSI_COLOR_YELLOW,
+	"blue":    ANSI_COLOR_BLUE,
+	"magenta": ANSI_COLOR_MAGENTA,
+	"cyan":    ANSI_COLOR_CYAN,
+	"white":   ANSI_COLOR_WHITE,
+	"default": ANSI_COLOR_DEFAULT,
+}
+
+// DefaultConfigPath returns where the config file is looked for:
+// $XDG_CONFIG_HOME/kigo/config, or ~/.config/kigo/config. It is "" if there is no
+// home directory.
+func DefaultConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "kigo", "config")
+}
+
+// LoadConfigFile applies the settings in the file at path to config. A missing file
+// leaves config as it is. Invalid lines are skipped and reported in the error.
+func LoadConfigFile(path string, config *Config) error {
+	if path == "" {
+		return nil
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+	return parseConfigFile(file, path, config)
+}
+
+// parseConfigFile reads settings in a small subset of TOML, which also reads as plain
+// key=value lines: "name = value" lines with '#' comments, where values may be quoted
+// with escapes like \n. A '#' only starts a comment after whitespace, so that names
+// like "alt-#" need no quotes. The sections [keymap], [colors], [dedent-brackets] and
+// [snippets.<filetype>] hold the entries of the map settings of the same names, with
+// [snippets] for files without a filetype. Entries of unknown sections are skipped.
+func parseConfigFile(r io.Reader, name string, config *Config) error {
+	var errs []string
+	section, skip := "", false
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fail := func(format string, args ...any) {
+			errs = append(errs, fmt.Sprintf("%s:%d: %s", name, n, fmt.Sprintf(format, args...)))
+		}
+
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			skip = section != "keymap" && section != "colors" && section != "dedent-brackets" &&
+				section != "snippets" && !strings.HasPrefix(section, "snippets.")
+			if skip {
+				fail("unknown section [%s]", section)
+			}
+			continue
+		}
+		if skip {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			fail("expected name = value")
+			continue
+		}
+		key, err := unquoteConfigValue(strings.TrimSpace(key))
+		if err != nil {
+			fail("%v", err)
+			continue
+		}
+		value, err = unquoteConfigValue(strings.TrimSpace(value))
+		if err != nil {
+			fail("%v", err)
+			continue
+		}
+
+		if err := applyConfigEntry(config, section, key, value); err != nil {
+			fail("%s: %v", key, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// unquoteConfigValue returns a quoted name or value without its quotes and with its
+// escapes replaced, and any other value without a trailing comment
+func unquoteConfigValue(value string) (string, error) {
+	if strings.HasPrefix(value, `"`) {
+		quoted, err := strconv.QuotedPrefix(value)
+		if rest := value[len(quoted):]; err != nil || (strings.TrimSpace(rest) != "" && commentStart(rest) != 0) {
+			return "", fmt.Errorf("invalid quoted string %s", value)
+		}
+		return strconv.Unquote(quoted)
+	}
+	if i := commentStart(value); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(value), nil
+}
+
+// commentStart returns where the comment in value starts, after any whitespace before
+// its '#', or -1 if there is none
+func commentStart(value string) int {
+	for i := range len(value) {
+		if value[i] == '#' && (i == 0 || value[i-1] == ' ' || value[i-1] == '\t') {
+			return len(strings.TrimRight(value[:i], " \t"))
+		}
+	}
+	return -1
+}
+
+// applyConfigEntry sets what the entry with key and value in the section sets
+func applyConfigEntry(config *Config, section, key, value string) error {
+	switch {
+	case section == "":
+		setting, ok := configSettings[key]
+		if !ok {
+			return fmt.Errorf("unknown setting")
+		}
+		return setting(config, value)
+
+	case section == "keymap":
+		if _, err := parseKeyName(key); err != nil {
+			return err
+		}
+		if _, ok := commandKeys[value]; !ok {
+			return fmt.Errorf("unknown command %q", value)
+		}
+		if config.Keymap == nil {
+			config.Keymap = map[string]string{}
+		}
+		// The same key may be spelled differently in the defaults
+		config.Keymap[normalizeKeyName(key)] = value
+
+	case section == "colors":
+		if !slices.Contains(slices.Collect(maps.Values(highlightClassNames)), key) {
+			return fmt.Errorf("unknown highlighting class")
+		}
+		color, ok := colorNames[value]
+		if n, err := strconv.Atoi(value); err == nil {
+			color, ok = n, true
+		}
+		if !ok {
+			return fmt.Errorf("unknown color %q", value)
+		}
+		if config.Colors == nil {
+			config.Colors = map[string]int{}
+		}
+		config.Colors[key] = color
+
+	case section == "dedent-brackets":
+		if config.DedentBrackets == nil {
+			config.DedentBrackets = map[string]string{}
+		}
+		config.DedentBrackets[key] = value
+
+	default:
+		filetype := strings.TrimPrefix(strings.TrimPrefix(section, "snippets"), ".")
+		if config.Snippets == nil {
+			config.Snippets = map[string]map[string]string{}
+		}
+		if config.Snippets[filetype] == nil {
+			config.Snippets[filetype] = map[string]string{}
+		}
+		config.Snippets[filetype][key] = value
+	}
+	return nil
+}
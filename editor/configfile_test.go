@@ -0,0 +1,143 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseConfigFile(t *testing.T) {
+	file := `# kigo settings
+tab-stop = 8
+quit-times = 1
+line-numbers = "relative"  # a comment
+auto-save = 30s
+show-scrollbar = true
+
+[keymap]
+ctrl-a = "line-start"
+"alt-*" = find
+
+[colors]
+keyword1 = blue
+string = 35
+
+[snippets.go]
+main = "func main() {\n\t$0\n}"
+`
+	config := DefaultConfig()
+	if err := parseConfigFile(strings.NewReader(file), "config", &config); err != nil {
+		t.Fatalf("Expected the file to be read, got %v", err)
+	}
+
+	if config.TabStop != 8 || config.QuitTimes != 1 || config.LineNumbers != LINE_NUMBERS_RELATIVE ||
+		config.AutoSave != 30*time.Second || !config.ShowScrollbar {
+		t.Errorf("Unexpected settings %+v", config)
+	}
+	if config.Keymap["ctrl-a"] != "line-start" || config.Keymap["alt-*"] != "find" {
+		t.Errorf("Unexpected keymap %v", config.Keymap)
+	}
+	if config.Colors["keyword1"] != ANSI_COLOR_BLUE || config.Colors["string"] != ANSI_COLOR_MAGENTA {
+		t.Errorf("Unexpected colors %v", config.Colors)
+	}
+	if got := config.Snippets["go"]["main"]; got != "func main() {\n\t$0\n}" {
+		t.Errorf("Unexpected snippet %q", got)
+	}
+	if config.Snippets["go"]["iferr"] == "" {
+		t.Errorf("Expected the default snippets to be kept")
+	}
+}
+
+func TestParseConfigFileErrors(t *testing.T) {
+	file := "tab-stop = 0\nline-numbers = sideways\ncolor = red\n[fonts]\nsize = 12\n[keymap]\nctrl-a = fly\n"
+	config := DefaultConfig()
+	err := parseConfigFile(strings.NewReader(file), "config", &config)
+	if err == nil {
+		t.Fatal("Expected the invalid lines to be reported")
+	}
+	for _, want := range []string{"config:1: tab-stop", "config:2: line-numbers", "config:3: color: unknown setting",
+		"config:4: unknown section [fonts]", `config:7: ctrl-a: unknown command "fly"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %q", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "size") || config.TabStop != TAB_STOP {
+		t.Errorf("Expected invalid lines to change nothing, got %v", err)
+	}
+}
+
+func TestParseConfigFileEdgeCases(t *testing.T) {
+	file := "quit-times = 0\n[keymap]\nalt-# = find  # a comment\nCtrl-A = line-start\nAlt-D = save\nalt-d = quit\n"
+	config := DefaultConfig()
+	if err := parseConfigFile(strings.NewReader(file), "config", &config); err != nil {
+		t.Fatalf("Expected the file to be read, got %v", err)
+	}
+
+	if config.QuitTimes != 0 {
+		t.Errorf("Expected quit-times 0, got %d", config.QuitTimes)
+	}
+	expected := map[string]string{"ctrl-a": "line-start", "alt-#": "find", "alt-D": "save", "alt-d": "quit"}
+	if len(config.Keymap) != len(expected) {
+		t.Errorf("Expected keymap %v, got %v", expected, config.Keymap)
+	}
+	for name, command := range expected {
+		if config.Keymap[name] != command {
+			t.Errorf("Expected %s bound to %q, got %q", name, command, config.Keymap[name])
+		}
+	}
+}
+
+func TestConfigSettingsApply(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "config"), []byte("tab-stop = 2\nquit-times = 5\n[colors]\nstring = blue\n"), 0644)
+	config := DefaultConfig()
+	if err := LoadConfigFile(filepath.Join(dir, "config"), &config); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadConfigFile(filepath.Join(dir, "missing"), &config); err != nil {
+		t.Errorf("Expected a missing file to be skipped, got %v", err)
+	}
+
+	e := newTestEditor(10, 80)
+	e.SetConfig(config)
+	e.resetBufferSettings()
+	e.InsertRow(0, []byte("\tx"), 2)
+	if got := string(e.row[0].render); got != "  x" || e.quitTimes != 5 {
+		t.Errorf("Expected tabs 2 columns wide, got %q", got)
+	}
+	if color, _ := e.theme()(HL_STRING); color != ANSI_COLOR_BLUE {
+		t.Errorf("Expected the configured string color, got %d", color)
+	}
+	if color, _ := e.theme()(HL_NUMBER); color != ANSI_COLOR_RED {
+		t.Errorf("Expected the default number color, got %d", color)
+	}
+}
+
+func TestAutoSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	os.WriteFile(path, []byte("one\n"), 0644)
+
+	e := newTestEditor(10, 80)
+	e.config = DefaultConfig()
+	e.Open(path)
+	if e.autoSave() {
+		t.Errorf("Expected an unchanged file not to be saved")
+	}
+	e.InsertChar('x')
+	if !e.autoSave() || e.dirty != 0 {
+		t.Fatalf("Expected the change to be saved")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "xone\n" {
+		t.Errorf("Unexpected file contents %q", data)
+	}
+
+	// A file changed by another program isn't overwritten without asking
+	time.Sleep(10 * time.Millisecond)
+	os.WriteFile(path, []byte("other\n"), 0644)
+	e.InsertChar('y')
+	if e.autoSave() {
+		t.Errorf("Expected a file changed on disk not to be saved")
+	}
+}
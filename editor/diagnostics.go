@@ -0,0 +1,73 @@
+package editor
+
+import (
+	"fmt"
+	"time"
+)
+
+// DIAGNOSTICS_MAX_ENTRIES is the number of entries the diagnostics log keeps, older ones are dropped
+const DIAGNOSTICS_MAX_ENTRIES = 500
+
+// logDiagnostic adds a timestamped entry to the diagnostics log
+func (e *Editor) logDiagnostic(format string, args ...any) {
+	entry := time.Now().Format("15:04:05.000") + " " + fmt.Sprintf(format, args...)
+	e.diagnostics = append(e.diagnostics, entry)
+	if len(e.diagnostics) > DIAGNOSTICS_MAX_ENTRIES {
+		e.diagnostics = e.diagnostics[len(e.diagnostics)-DIAGNOSTICS_MAX_ENTRIES:]
+	}
+}
+
+// logKey records a decoded key and the raw bytes it was read from. Sequences that
+// are not recognized are decoded as a bare ESC and are marked as such, and with
+// config.DebugKeys they are shown so that a mapping for them can be reported.
+func (e *Editor) logKey(key int, raw []byte) {
+	if key == '\x1b' && len(raw) > 1 {
+		e.logDiagnostic("unrecognized sequence %q", raw)
+		if e.config.DebugKeys {
+			e.SetStatusMessage("Unrecognized key sequence %q", raw)
+		}
+		return
+	}
+	e.logDiagnostic("key %d from %q", key, raw)
+}
+
+// DiagnosticsScreen shows the diagnostics log read-only, scrolling like the help screen
+type DiagnosticsScreen struct {
+	HelpScreen
+}
+
+// NewDiagnosticsScreen creates a screen with the current entries of the diagnostics log
+func NewDiagnosticsScreen(editor *Editor) *DiagnosticsScreen {
+	entries := editor.diagnostics
+	if len(entries) == 0 {
+		entries = []string{"The diagnostics log is empty"}
+	}
+	content := make([]editorRow, len(entries))
+	for i, entry := range entries {
+		content[i] = editorRow{idx: i, chars: []byte(entry)}
+		content[i].Update(editor)
+	}
+	return &DiagnosticsScreen{HelpScreen{content: content}}
+}
+
+// GetTitle returns the diagnostics screen title
+func (d *DiagnosticsScreen) GetTitle() string {
+	return "Diagnostics"
+}
+
+// GetStatusMessage returns the status message for the diagnostics screen
+func (d *DiagnosticsScreen) GetStatusMessage() string {
+	return "Diagnostics - Use Arrow Keys to scroll, 'q' or Escape to exit"
+}
+
+// Initialize shows the most recent entries, which are at the end
+func (d *DiagnosticsScreen) Initialize(e *Editor) {
+	d.HandleKey(END_KEY, e)
+}
+
+// ShowDiagnostics displays the diagnostics log
+func (e *Editor) ShowDiagnostics() {
+	screen := NewDiagnosticsScreen(e)
+	modalManager := NewModalManager(e, screen)
+	modalManager.Show(DIAGNOSTICS_MODE)
+}
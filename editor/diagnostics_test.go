@@ -0,0 +1,78 @@
+package editor
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiagnosticsLogsKeys(t *testing.T) {
+	e := newTestEditor(10, 80)
+	e.config = DefaultConfig()
+	e.input = newInput(strings.NewReader("\x1b[A" + "\x1b[99X"))
+
+	if key, _ := e.readKey(); key != ARROW_UP {
+		t.Fatalf("Expected ARROW_UP, got %d", key)
+	}
+	if key, _ := e.readKey(); key != '\x1b' {
+		t.Fatalf("Expected an unknown sequence to be read as ESC, got %d", key)
+	}
+
+	if len(e.diagnostics) != 2 {
+		t.Fatalf("Expected 2 log entries, got %q", e.diagnostics)
+	}
+	if want := fmt.Sprintf("key %d from \"\\x1b[A\"", ARROW_UP); !strings.HasSuffix(e.diagnostics[0], want) {
+		t.Errorf("Expected %q to end with %q", e.diagnostics[0], want)
+	}
+	if want := `unrecognized sequence "\x1b[99X"`; !strings.HasSuffix(e.diagnostics[1], want) {
+		t.Errorf("Expected %q to end with %q", e.diagnostics[1], want)
+	}
+}
+
+func TestDiagnosticsLogsErrorsAndResizes(t *testing.T) {
+	e := newTestEditor(10, 80)
+	e.ShowError("disk full")
+	e.setScreenSize(30, 100)
+
+	if len(e.diagnostics) != 2 {
+		t.Fatalf("Expected 2 log entries, got %q", e.diagnostics)
+	}
+	if !strings.HasSuffix(e.diagnostics[0], "Error: disk full") {
+		t.Errorf("Expected the error to be logged, got %q", e.diagnostics[0])
+	}
+	if !strings.HasSuffix(e.diagnostics[1], "window size 100x30") {
+		t.Errorf("Expected the window size to be logged, got %q", e.diagnostics[1])
+	}
+}
+
+func TestDiagnosticsLogIsCapped(t *testing.T) {
+	e := newTestEditor(10, 80)
+	for i := range DIAGNOSTICS_MAX_ENTRIES + 10 {
+		e.logDiagnostic("entry %d", i)
+	}
+
+	if len(e.diagnostics) != DIAGNOSTICS_MAX_ENTRIES {
+		t.Fatalf("Expected %d entries, got %d", DIAGNOSTICS_MAX_ENTRIES, len(e.diagnostics))
+	}
+	if !strings.HasSuffix(e.diagnostics[0], "entry 10") {
+		t.Errorf("Expected the oldest entries to be dropped, got %q first", e.diagnostics[0])
+	}
+}
+
+func TestUnrecognizedSequenceIsConsumed(t *testing.T) {
+	e := newTestEditor(10, 80, "")
+	e.output = newOutput(io.Discard)
+	e.config = DefaultConfig()
+	e.config.DebugKeys = true
+
+	// The whole sequence, including its intermediate byte, is skipped instead of typed
+	pressKeys(e, "\x1b[?1 q", "x")
+	if got := e.Lines(); !slices.Equal(got, []string{"x"}) {
+		t.Errorf("Expected only the typed key to be inserted, got %q", got)
+	}
+	if want := `Unrecognized key sequence "\x1b[?1 q"`; e.statusMessage != want {
+		t.Errorf("Expected the status message %q, got %q", want, e.statusMessage)
+	}
+}
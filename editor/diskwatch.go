@@ -0,0 +1,146 @@
+package editor
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// diskState is what was last seen of the open file on disk
+type diskState struct {
+	info    os.FileInfo // nil if the file did not exist
+	changed string      // "" while the file is unchanged, otherwise how it changed
+}
+
+// rememberDiskState records the file on disk as the version being edited
+func (e *Editor) rememberDiskState() {
+	e.disk = diskState{}
+	if e.filename == "" || e.filename == STDIO_FILENAME {
+		return
+	}
+	if info, err := os.Stat(e.filename); err == nil {
+		e.disk.info = info
+	}
+}
+
+// checkDisk compares the file on disk with the version being edited and updates
+// the changed marker. It returns true if the marker changed.
+func (e *Editor) checkDisk() bool {
+	if e.filename == "" || e.filename == STDIO_FILENAME {
+		return false
+	}
+
+	changed := ""
+	info, err := os.Stat(e.filename)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if e.disk.info != nil {
+			changed = "deleted on disk"
+		}
+	case err != nil:
+		return false
+	case e.disk.info == nil:
+		changed = "created on disk"
+	case !os.SameFile(info, e.disk.info):
+		changed = "replaced on disk"
+	case !info.ModTime().Equal(e.disk.info.ModTime()) || info.Size() != e.disk.info.Size():
+		changed = "changed on disk"
+	}
+
+	if changed == e.disk.changed {
+		return false
+	}
+	e.disk.changed = changed
+	return true
+}
+
+// waitForKey waits for the first byte of the next key, ending the flash of a visual
+// bell after BELL_FLASH_DURATION or at the key. While waiting, the open file is checked
+// for changes on disk every config.DiskCheckInterval, unless that is zero, and saved
+// once it waited config.AutoSave with unsaved changes. It isn't saved while a modal
+// screen shows its own rows or a prompt, like a search, is in progress.
+func (e *Editor) waitForKey() (byte, error) {
+	if e.bellFlashing {
+		c, err := e.input.readByte(BELL_FLASH_DURATION)
+		e.endFlash()
+		if err != errReadTimeout {
+			return c, err
+		}
+	}
+
+	interval := e.config.DiskCheckInterval
+	if e.config.AutoSave > 0 && (interval <= 0 || e.config.AutoSave < interval) {
+		interval = e.config.AutoSave
+	}
+
+	var waited time.Duration
+	for {
+		c, err := e.input.readByte(interval)
+		if err != errReadTimeout {
+			return c, err
+		}
+		waited += interval
+		refresh := e.config.DiskCheckInterval > 0 && e.checkDisk()
+		if e.config.AutoSave > 0 && waited >= e.config.AutoSave && !e.isModal() && !e.prompting &&
+			e.autoSave() {
+			refresh = true
+		}
+		if refresh {
+			e.RefreshScreen()
+		}
+	}
+}
+
+// autoSave saves the file if it has unsaved changes, unless it has no name yet or
+// changed on disk, where saving needs the user's confirmation. It reports whether the
+// file was saved.
+func (e *Editor) autoSave() bool {
+	if e.dirty == 0 || e.filename == "" || e.filename == STDIO_FILENAME {
+		return false
+	}
+	e.checkDisk()
+	if e.disk.changed != "" || !e.writeFile() {
+		return false
+	}
+	if e.unencodableChars() == 0 {
+		e.SetStatusMessage("Saved %s automatically", e.filename) // Warnings are kept
+	}
+	return true
+}
+
+// Reload reads the file from disk again, discarding unsaved changes after confirmation
+func (e *Editor) Reload() {
+	if e.filename == "" || e.filename == STDIO_FILENAME {
+		e.SetStatusMessage("No file to reload")
+		return
+	}
+	if e.dirty > 0 {
+		key := e.PromptKey("Discard unsaved changes and reload %s? (y/n)", e.filename)
+		if key != 'y' && key != 'Y' {
+			e.SetStatusMessage("Reload cancelled")
+			return
+		}
+	}
+
+	cy, cx := e.cy, e.cx
+	if err := e.Open(e.filename); err != nil {
+		e.ShowError("%v", err)
+		return
+	}
+	e.cy = min(cy, max(e.totalRows-1, 0))
+	if e.cy < e.totalRows {
+		e.cx = min(cx, len(e.row[e.cy].chars))
+	}
+	e.SetStatusMessage("Reloaded %s", e.filename)
+}
+
+// confirmOverwrite asks before saving over a file that changed on disk since it was opened
+func (e *Editor) confirmOverwrite() bool {
+	e.checkDisk()
+	if e.disk.changed == "" {
+		return true
+	}
+	key := e.PromptKey("File was %s since it was opened. Overwrite? (y/n)", e.disk.changed)
+	return key == 'y' || key == 'Y'
+}
@@ -0,0 +1,113 @@
+package editor
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckDisk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	os.WriteFile(path, []byte("one\n"), 0644)
+
+	e := newTestEditor(10, 80)
+	e.config = DefaultConfig()
+	if err := e.Open(path); err != nil {
+		t.Fatal(err)
+	}
+	if e.checkDisk() || e.disk.changed != "" {
+		t.Fatalf("Expected no change right after opening, got %q", e.disk.changed)
+	}
+
+	os.WriteFile(path, []byte("one\ntwo\n"), 0644)
+	if !e.checkDisk() || e.disk.changed != "changed on disk" {
+		t.Errorf("Expected a change to be detected, got %q", e.disk.changed)
+	}
+	if e.checkDisk() {
+		t.Errorf("Expected the marker to be reported only once")
+	}
+
+	os.Remove(path)
+	if !e.checkDisk() || e.disk.changed != "deleted on disk" {
+		t.Errorf("Expected the deletion to be detected, got %q", e.disk.changed)
+	}
+
+}
+
+func TestSaveIndicator(t *testing.T) {
+	e := newTestEditor(10, 80, "text")
+	now := time.Date(2024, 5, 1, 14, 30, 0, 0, time.Local)
+	if got := e.saveIndicator(now); got != "" {
+		t.Errorf("Expected no indicator before saving, got %q", got)
+	}
+
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{12 * time.Second, "saved 12s ago"},
+		{5 * time.Minute, "saved 5m ago"},
+		{2 * time.Hour, "saved 12:30"},
+	}
+	for _, tt := range tests {
+		e.lastSave = now.Add(-tt.ago)
+		if got := e.saveIndicator(now); got != tt.want {
+			t.Errorf("Saved %v ago: expected %q, got %q", tt.ago, tt.want, got)
+		}
+	}
+
+	e.disk.changed = "modified"
+	if got := e.saveIndicator(now); got != "" {
+		t.Errorf("Expected no indicator after the file changed on disk, got %q", got)
+	}
+}
+
+func TestSaveSetsLastSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	os.WriteFile(path, []byte("text\n"), 0644)
+	e := newTestEditor(10, 120)
+	e.config = DefaultConfig()
+	e.output = newOutput(io.Discard)
+	e.Open(path)
+	if !e.lastSave.IsZero() {
+		t.Fatalf("Expected no save time after opening")
+	}
+
+	e.Save()
+	var abuf appendBuffer
+	e.DrawStatusBar(&abuf)
+	if e.lastSave.IsZero() || !strings.Contains(string(abuf.b), "saved 0s ago") {
+		t.Errorf("Expected the save time in the status bar, got %q", abuf.b)
+	}
+}
+
+func TestAutoSaveSkipsModalScreens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	os.WriteFile(path, []byte("one\n"), 0644)
+
+	e := newTestEditor(10, 80)
+	e.config = DefaultConfig()
+	e.config.AutoSave = 5 * time.Millisecond
+	e.output = newOutput(io.Discard)
+	e.Open(path)
+	e.InsertChar('x')
+
+	r, w := io.Pipe()
+	e.input = newInput(r)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("\x1b"))
+		w.Close()
+	}()
+	e.Help()
+
+	if data, _ := os.ReadFile(path); string(data) != "one\n" {
+		t.Errorf("Expected the file not to be saved while help was shown, got %q", data)
+	}
+	if e.dirty == 0 || string(e.row[0].chars) != "xone" {
+		t.Errorf("Expected the unsaved change to be kept, got %q", e.row[0].chars)
+	}
+}
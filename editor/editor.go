@@ -5,11 +5,16 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"math"
 	"os"
+	"path/filepath"
 	"runtime"
 	"slices"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"golang.org/x/term"
 )
@@ -20,8 +25,16 @@ import (
 const (
 	KIGO_VERSION           = "1.0.0"
 	TAB_STOP               = 4
-	CONTROL_SEQUENCE_WIDTH = 2
+	CONTROL_SEQUENCE_WIDTH = 2 // width of a control character in caret notation, like ^A
+	HEX_CONTROL_WIDTH      = 4 // width of a control character as hex escape, like \x01
 	QUIT_TIMES             = 3
+	SCROLLBAR_THUMB        = "█"
+	SCROLLBAR_TRACK        = "│"
+	LONG_LINE_LENGTH       = 10000 // lines longer than this are rendered on demand
+	STDIO_FILENAME         = "-"   // edits standard input and saves to standard output
+	MESSAGE_TIMEOUT        = 5 * time.Second
+	ESCAPE_TIMEOUT         = 50 * time.Millisecond
+	UTF8_BOM               = "\ufeff" // byte order mark some editors start UTF-8 files with
 )
 
 // getLineEnding returns the appropriate line ending for the current OS
@@ -32,6 +45,34 @@ func getLineEnding() string {
 	return "\n"
 }
 
+// tabStop returns the display width of a tab in the current buffer
+func (e *Editor) tabStop() int {
+	switch {
+	case e.tabSize > 0:
+		return e.tabSize
+	case e.config.TabStop > 0:
+		return e.config.TabStop
+	}
+	return TAB_STOP
+}
+
+// escapeTimeout returns how long to wait for the rest of an escape sequence. A timeout
+// of zero or less would wait for the next key after a lone ESC, so it isn't used.
+func (e *Editor) escapeTimeout() time.Duration {
+	if e.config.EscapeTimeout > 0 {
+		return e.config.EscapeTimeout
+	}
+	return ESCAPE_TIMEOUT
+}
+
+// lineEndingFor returns the line ending written after each row of the current buffer
+func (e *Editor) lineEndingFor() string {
+	if e.lineEnding == "" {
+		return getLineEnding()
+	}
+	return e.lineEnding
+}
+
 // Key aliase
 const (
 	BACKSPACE  = 127 // ASCII backspace
@@ -44,8 +85,20 @@ const (
 	END_KEY
 	PAGE_UP
 	PAGE_DOWN
+	CTRL_DELETE_KEY
+	SHIFT_ARROW_LEFT
+	SHIFT_ARROW_RIGHT
+	SHIFT_ARROW_UP
+	SHIFT_ARROW_DOWN
+	SHIFT_TAB
+	CTRL_HOME_KEY
+	CTRL_END_KEY
+	SMART_HOME_KEY // not sent by terminals, runs SmartHome when bound in the keymap
 )
 
+// ALT_MODIFIER is set on keys pressed together with Alt
+const ALT_MODIFIER = 1 << 16
+
 // Syntax highlighting types
 const (
 	HL_NORMAL = iota
@@ -57,12 +110,19 @@ const (
 	HL_NUMBER
 	HL_MATCH
 	HL_CONTROL
+	HL_SELECTION
+	HL_WORD
+	HL_CURSOR
 )
 
 // Syntax highlighting flags
 const (
-	HL_HIGHLIGHT_NUMBERS = 1 << 0
-	HL_HIGHLIGHT_STRINGS = 1 << 1
+	HL_HIGHLIGHT_NUMBERS  = 1 << 0
+	HL_HIGHLIGHT_STRINGS  = 1 << 1
+	HL_HIGHLIGHT_VERSIONS = 1 << 2 // module versions like v1.2.3
+	HL_HIGHLIGHT_MAKE     = 1 << 3 // make rule targets and $(variable) references
+	HL_HIGHLIGHT_HEADERS  = 1 << 4 // Markdown headers like "## Usage"
+	HL_HIGHLIGHT_KEYS     = 1 << 5 // YAML mapping keys like "name:"
 )
 
 // Editor modes
@@ -72,6 +132,17 @@ const (
 	SEARCH_MODE
 	SAVE_MODE
 	HELP_MODE
+	QUICK_OPEN_MODE
+	DIAGNOSTICS_MODE
+	SELECT_MODE // editing, with the keys that move the cursor extending the selection
+)
+
+// Message severities. A shown message is not replaced by one of lower severity,
+// and warnings and errors are colored in the message bar.
+const (
+	MESSAGE_INFO = iota
+	MESSAGE_WARNING
+	MESSAGE_ERROR
 )
 
 // Check if the byte is a control character
@@ -84,11 +155,21 @@ func isDigit(c byte) bool {
 	return c >= '0' && c <= '9'
 }
 
+// Check if the byte can be part of an identifier, so that a keyword can't end before it
+func isIdentifierChar(c byte) bool {
+	return isDigit(c) || c == '_' || (c|0x20 >= 'a' && c|0x20 <= 'z') || c >= utf8.RuneSelf
+}
+
 // Convert a character to its control key equivalent
 func withControlKey(c int) int {
 	return c & 0x1f // 0x1f is 31 in decimal, which is the control character range
 }
 
+// Convert a character to its Alt key equivalent
+func withAltKey(c int) int {
+	return c | ALT_MODIFIER
+}
+
 /*** data ***/
 
 type editorSyntax struct {
@@ -99,38 +180,133 @@ type editorSyntax struct {
 	multilineCommentStart  string
 	multilineCommentEnd    string
 	flags                  int
+	indentTabs             bool   // files of this type must indent with tabs, whatever the configuration
+	stringQuotes           string // characters that start and end strings, "" for " and '
+	commentAfterSpace      bool   // the single-line comment only starts a line or follows whitespace, like # in shells
+}
+
+// quotes returns the characters that start and end strings
+func (s *editorSyntax) quotes() string {
+	if s.stringQuotes == "" {
+		return `"'`
+	}
+	return s.stringQuotes
+}
+
+// commentStartsAt reports whether a single-line comment starts at column i of line
+func (s *editorSyntax) commentStartsAt(line []byte, i int) bool {
+	if s.singlelineCommentStart == "" || !bytes.HasPrefix(line[i:], []byte(s.singlelineCommentStart)) {
+		return false
+	}
+	return !s.commentAfterSpace || i == 0 || line[i-1] == ' ' || line[i-1] == '\t'
 }
 
 type editorRow struct {
 	idx           int
 	chars         []byte
-	render        []byte
+	render        []byte // nil for long lines until a search needs it
+	renderWidth   int
 	hl            []int
 	hlOpenComment bool
+	dirty         bool // changed since it was last drawn, so the drawn line can't be reused
 }
 
 // Terminal handles terminal-specific operations
 type Terminal struct {
-	originalState *term.State
+	originalState   *term.State
+	in              *os.File // where keys are read from, nil for stdin
+	out             *os.File // where the screen is drawn, nil for stdout
+	alternateScreen bool     // whether the editor switched to the alternate screen buffer
+}
+
+// inputFile returns the terminal keys are read from
+func (t *Terminal) inputFile() *os.File {
+	if t != nil && t.in != nil {
+		return t.in
+	}
+	return os.Stdin
+}
+
+// outputFile returns the terminal the screen is drawn on
+func (t *Terminal) outputFile() *os.File {
+	if t != nil && t.out != nil {
+		return t.out
+	}
+	return os.Stdout
+}
+
+// StdinIsTerminal reports whether standard input is a terminal rather than a pipe or file
+func StdinIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
 }
 
 // Editor represents the text editor state
 type Editor struct {
-	cx, cy            int
-	rx                int
-	rowOffset         int
-	colOffset         int
-	screenRows        int
-	screenCols        int
-	totalRows         int
-	row               []editorRow
-	dirty             int // captures if and how much edits are made
-	filename          string
-	statusMessage     string
-	statusMessageTime time.Time
-	syntax            *editorSyntax
-	mode              int // e.g., "insert", "normal", "visual"
-	terminal          *Terminal
+	cx, cy             int
+	rx                 int
+	rowOffset          int
+	colOffset          int
+	screenRows         int
+	screenCols         int
+	screenTop          int // screen row of the shown window's top, when the screen is split
+	screenLeft         int // screen column of the shown window's left edge
+	totalRows          int
+	row                []editorRow
+	dirty              int // captures if and how much edits are made
+	filename           string
+	statusMessage      string
+	statusMessageTime  time.Time
+	messagePriority    int // severity of statusMessage, like MESSAGE_ERROR
+	syntax             *editorSyntax
+	detectedSyntax     *editorSyntax // syntax for the filename, also while highlighting is off
+	mode               int           // e.g., "insert", "normal", "visual"
+	terminal           *Terminal
+	input              *input
+	config             Config
+	goalRx             int  // render column that vertical movement returns to
+	hasGoalRx          bool // whether goalRx is set by a preceding vertical move
+	selection          selection
+	highlightedWord    []byte // word whose occurrences are highlighted
+	searchQuery        []byte // last searched text, continued by repeated searches
+	searchWholeWord    bool   // whether searchQuery only matches whole words
+	searchRegex        bool   // whether searchQuery is a regular expression
+	searchDirection    int    // 1 if the last search went forward, -1 if backward
+	find               findState
+	quitTimes          int  // Ctrl-Q presses left before quitting with unsaved changes
+	prompting          bool // whether a prompt in the status bar is waiting for input
+	repeatCount        int  // times to repeat the next command, 0 if not given
+	indent             indentStyle
+	tabSize            int      // display width of a tab, 0 means config.TabStop
+	lineEnding         string   // written after each row, "" means the OS default
+	trimTrailingSpace  bool     // strip trailing whitespace from rows when saving
+	insertFinalNewline bool     // always end the file with a line ending when saving
+	finalNewline       bool     // whether the opened file ended with a line ending
+	bom                bool     // whether the opened file started with UTF8_BOM, written back when saving
+	encoding           string   // encoding the file is read and written in
+	highlightOff       bool     // whether syntax highlighting is switched off for the file
+	hexControl         bool     // whether control characters are shown as \xNN instead of ^X
+	cursors            []cursor // additional cursors for simultaneous editing
+	undo               undoHistory
+	modal              *ModalManager // modal screen being shown, laid out again when the screen size changes
+	start              startScreen
+	bellPending        bool // whether the next screen refresh gives the feedback of Bell
+	bellFlashing       bool // whether the screen is shown inverted by a visual bell
+	frame              screenFrame
+	output             *output
+	indexedRows        int    // rows before this position have an up to date idx
+	contentVersion     int    // changes whenever the text or its highlighting changes
+	stdoutContent      []byte // saved content written to stdout on exit when editing "-"
+	disk               diskState
+	keymap             map[int]int // keys rebound in the configuration to the keys of other commands
+	lastSave           time.Time
+	diagnostics        []string // recent entries of the diagnostics log, oldest first
+	clipboard          string   // text copied or cut, shared by all buffers
+	clipboardPending   string   // copied text sent to the terminal's clipboard with the next screen refresh
+	lastClosed         closedFile
+	buffers            []buffer // open files, the current one's slot is stale while shown
+	currentBuffer      int
+	split              windowLayout
+	syntaxes           []editorSyntax // loaded from syntax files, see LoadSyntaxDir
 }
 
 /*** filetypes ***/
@@ -151,7 +327,7 @@ var HLDB_ENTRIES = []editorSyntax{
 	},
 	{
 		filetype:  "go",
-		filematch: []string{".go", ".mod", ".sum"},
+		filematch: []string{".go"},
 		keywords: [][]string{
 			{"break", "case", "chan", "const", "continue", "default", "defer", "else",
 				"fallthrough", "for", "go", "goto", "if", "import", "map", "package",
@@ -163,140 +339,397 @@ var HLDB_ENTRIES = []editorSyntax{
 		multilineCommentEnd:    "*/",
 		flags:                  HL_HIGHLIGHT_NUMBERS | HL_HIGHLIGHT_STRINGS,
 	},
+	{
+		filetype:  "go.mod",
+		filematch: []string{"go.mod", ".mod"},
+		keywords: [][]string{
+			{"module", "go", "toolchain", "godebug", "require", "replace", "exclude", "retract", "tool"},
+			{"=>"},
+		},
+		singlelineCommentStart: "//",
+		flags:                  HL_HIGHLIGHT_STRINGS | HL_HIGHLIGHT_VERSIONS,
+	},
+	{
+		filetype:  "go.sum",
+		filematch: []string{"go.sum", ".sum"},
+		flags:     HL_HIGHLIGHT_VERSIONS,
+	},
+	{
+		filetype:  "dockerfile",
+		filematch: []string{"Dockerfile", "Containerfile", "Dockerfile.*", "*.dockerfile"},
+		keywords: [][]string{
+			{"FROM", "RUN", "CMD", "LABEL", "EXPOSE", "ENV", "ADD", "COPY", "ENTRYPOINT", "VOLUME",
+				"USER", "WORKDIR", "ARG", "ONBUILD", "STOPSIGNAL", "HEALTHCHECK", "SHELL", "MAINTAINER"},
+			{"AS", "as"},
+		},
+		singlelineCommentStart: "#",
+		flags:                  HL_HIGHLIGHT_NUMBERS | HL_HIGHLIGHT_STRINGS,
+	},
+	{
+		filetype:  "python",
+		filematch: []string{".py", ".pyw", ".pyi"},
+		keywords: [][]string{
+			{"and", "as", "assert", "async", "await", "break", "class", "continue", "def", "del",
+				"elif", "else", "except", "finally", "for", "from", "global", "if", "import", "in",
+				"is", "lambda", "nonlocal", "not", "or", "pass", "raise", "return", "try", "while",
+				"with", "yield", "match", "case"},
+			{"True", "False", "None", "self", "int", "float", "str", "bytes", "bool", "list",
+				"dict", "set", "tuple", "object"},
+		},
+		singlelineCommentStart: "#",
+		multilineCommentStart:  `"""`, // Docstrings
+		multilineCommentEnd:    `"""`,
+		flags:                  HL_HIGHLIGHT_NUMBERS | HL_HIGHLIGHT_STRINGS,
+	},
+	{
+		filetype:  "javascript",
+		filematch: []string{".js", ".mjs", ".cjs", ".jsx"},
+		keywords: [][]string{
+			{"async", "await", "break", "case", "catch", "class", "const", "continue", "debugger",
+				"default", "delete", "do", "else", "export", "extends", "finally", "for", "function",
+				"if", "import", "in", "instanceof", "let", "new", "of", "return", "static", "super",
+				"switch", "this", "throw", "try", "typeof", "var", "void", "while", "with", "yield"},
+			{"true", "false", "null", "undefined", "NaN", "Infinity"},
+		},
+		singlelineCommentStart: "//",
+		multilineCommentStart:  "/*",
+		multilineCommentEnd:    "*/",
+		flags:                  HL_HIGHLIGHT_NUMBERS | HL_HIGHLIGHT_STRINGS,
+		stringQuotes:           "\"'`", // Template literals too
+	},
+	{
+		filetype:  "rust",
+		filematch: []string{".rs"},
+		keywords: [][]string{
+			{"as", "async", "await", "break", "const", "continue", "crate", "dyn", "else", "enum",
+				"extern", "fn", "for", "if", "impl", "in", "let", "loop", "match", "mod", "move",
+				"mut", "pub", "ref", "return", "self", "Self", "static", "struct", "super", "trait",
+				"type", "unsafe", "use", "where", "while"},
+			{"i8", "i16", "i32", "i64", "i128", "isize", "u8", "u16", "u32", "u64", "u128", "usize",
+				"f32", "f64", "bool", "char", "str", "String", "Vec", "Option", "Result", "Box",
+				"true", "false", "Some", "None", "Ok", "Err"},
+		},
+		singlelineCommentStart: "//",
+		multilineCommentStart:  "/*",
+		multilineCommentEnd:    "*/",
+		flags:                  HL_HIGHLIGHT_NUMBERS | HL_HIGHLIGHT_STRINGS,
+		stringQuotes:           `"`, // A ' starts a lifetime like 'a more often than a char
+	},
+	{
+		filetype:  "markdown",
+		filematch: []string{".md", ".markdown"},
+		// Numbers in prose are plain text, and quotes are apostrophes more often than
+		// not, so only `code` is highlighted as strings
+		multilineCommentStart: "<!--",
+		multilineCommentEnd:   "-->",
+		flags:                 HL_HIGHLIGHT_STRINGS | HL_HIGHLIGHT_HEADERS,
+		stringQuotes:          "`",
+	},
+	{
+		filetype:  "json",
+		filematch: []string{".json"},
+		keywords: [][]string{
+			nil,
+			{"true", "false", "null"},
+		},
+		flags:        HL_HIGHLIGHT_NUMBERS | HL_HIGHLIGHT_STRINGS,
+		stringQuotes: `"`,
+	},
+	{
+		filetype:  "yaml",
+		filematch: []string{".yaml", ".yml"},
+		keywords: [][]string{
+			nil,
+			{"true", "false", "null", "yes", "no", "on", "off", "~"},
+		},
+		singlelineCommentStart: "#",
+		commentAfterSpace:      true,
+		flags:                  HL_HIGHLIGHT_NUMBERS | HL_HIGHLIGHT_STRINGS | HL_HIGHLIGHT_KEYS,
+	},
+	{
+		filetype:  "sh",
+		filematch: []string{".sh", ".bash", ".zsh", ".bashrc", ".zshrc", ".profile"},
+		keywords: [][]string{
+			{"if", "then", "else", "elif", "fi", "for", "while", "until", "do", "done", "case",
+				"esac", "in", "function", "select", "return", "break", "continue", "exit", "local",
+				"export", "readonly", "declare", "unset", "shift", "source", "alias", "set"},
+			{"echo", "printf", "read", "cd", "test", "true", "false", "eval", "exec", "trap"},
+		},
+		singlelineCommentStart: "#",
+		commentAfterSpace:      true,
+		flags:                  HL_HIGHLIGHT_NUMBERS | HL_HIGHLIGHT_STRINGS,
+		stringQuotes:           "\"'`", // Command substitution too
+	},
+	{
+		filetype:  "makefile",
+		filematch: []string{"Makefile", "makefile", "GNUmakefile", "*.mk"},
+		keywords: [][]string{
+			{"ifeq", "ifneq", "ifdef", "ifndef", "else", "endif", "include", "define", "endef",
+				"export", "unexport", "override", "vpath"},
+			{"$@", "$<", "$^", "$?", "$*"},
+		},
+		singlelineCommentStart: "#",
+		flags:                  HL_HIGHLIGHT_STRINGS | HL_HIGHLIGHT_MAKE,
+		indentTabs:             true, // recipe lines must start with a tab
+	},
 }
 
 /*** terminal ***/
 
 // Die restores terminal, prints an error message and exits the program
 func (e *Editor) Die(format string, args ...any) {
+	e.clearScreen()
 	e.RestoreTerminal()
-	os.Stdout.Write([]byte(CLEAR_SCREEN))
-	os.Stdout.Write([]byte(CURSOR_HOME))
 	fmt.Fprintf(os.Stderr, "Error: "+format+"\n", args...)
 	os.Exit(1)
 }
 
-// ShowError displays an error message in the status bar instead of terminating
+// ShowError displays an error message in the status bar instead of terminating.
+// Until the next keypress it is not replaced by ordinary status messages.
 func (e *Editor) ShowError(format string, args ...any) {
-	e.SetStatusMessage("Warn: "+format, args...)
+	message := "Error: " + fmt.Sprintf(format, args...)
+	e.logDiagnostic("%s", message)
+	e.setMessage(MESSAGE_ERROR, message)
+}
+
+// ShowWarning displays a warning about something that may need attention, like
+// ShowError but styled less alarmingly
+func (e *Editor) ShowWarning(format string, args ...any) {
+	message := "Warn: " + fmt.Sprintf(format, args...)
+	e.logDiagnostic("%s", message)
+	e.setMessage(MESSAGE_WARNING, message)
 }
 
 // Enable raw mode for terminal input.
 // This allows us to read every input key and positions the cursor freely
 func (e *Editor) EnableRawMode() error {
-	// Check if stdin is a terminal
-	if !term.IsTerminal(int(os.Stdin.Fd())) {
-		return errors.New("not running in a terminal")
+	// If stdin or stdout is not a terminal, the editor is probably part of a pipeline,
+	// so talk to the controlling terminal instead
+	stdoutIsTerminal := term.IsTerminal(int(os.Stdout.Fd()))
+	if !StdinIsTerminal() || !stdoutIsTerminal {
+		tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+		if err != nil {
+			return errors.New("not running in a terminal")
+		}
+		if !term.IsTerminal(int(tty.Fd())) {
+			tty.Close()
+			return errors.New("not running in a terminal")
+		}
+		if !StdinIsTerminal() {
+			e.terminal.in = tty
+		}
+		if !stdoutIsTerminal {
+			e.terminal.out = tty
+		}
 	}
 
 	var err error
-	e.terminal.originalState, err = term.MakeRaw(int(os.Stdin.Fd()))
+	e.terminal.originalState, err = term.MakeRaw(int(e.terminal.inputFile().Fd()))
 	if err != nil {
 		return errors.New("enabling terminal raw mode: " + err.Error())
 	}
+	if e.config.AlternateScreen {
+		out := e.writer()
+		out.WriteString(ALTERNATE_SCREEN_ON)
+		out.Flush()
+		e.terminal.alternateScreen = true
+	}
 	return nil
 }
 
-// Restore the original terminal state, disabling raw mode.
+// Restore the original terminal state, disabling raw mode and switching back
+// from the alternate screen so that the shell's screen contents reappear.
 func (e *Editor) RestoreTerminal() {
-	if e.terminal != nil && e.terminal.originalState != nil {
-		term.Restore(int(os.Stdin.Fd()), e.terminal.originalState)
+	if e.terminal == nil {
+		return
+	}
+	if e.terminal.alternateScreen {
+		out := e.writer()
+		out.WriteString(ALTERNATE_SCREEN_OFF)
+		out.Flush()
+		e.terminal.alternateScreen = false
+	}
+	if e.terminal.originalState != nil {
+		term.Restore(int(e.terminal.inputFile().Fd()), e.terminal.originalState)
 		e.terminal.originalState = nil // Prevent multiple restoration attempts
 	}
 }
 
-func readKey() (int, error) {
-	buf := make([]byte, 1)
-	var nread int
-	var err error
+// readKey waits for the next keypress and decodes escape sequences into key aliases.
+// The key and the bytes it was read from are written to the diagnostics log.
+func (e *Editor) readKey() (int, error) {
+	if e.input == nil {
+		e.input = newInput(e.terminal.inputFile())
+	}
 
-	for nread, err = os.Stdin.Read(buf); nread != 1; {
-		if nread == -1 && err != nil {
-			return 0, errors.New("reading keyboard input")
-		}
-		if err != nil {
-			return 0, errors.New("reading keyboard input")
-		}
+	e.input.consumed = e.input.consumed[:0]
+	key, err := e.decodeKey()
+	if err == nil {
+		e.logKey(key, e.input.consumed)
 	}
+	return key, err
+}
 
-	c := buf[0]
-	if c == '\x1b' {
-		seq := make([]byte, 3)
-		if nread, err := os.Stdin.Read(seq[0:1]); nread != 1 || err != nil {
-			return '\x1b', nil
-		}
-		if nread, err := os.Stdin.Read(seq[1:2]); nread != 1 || err != nil {
-			return '\x1b', nil
-		}
+// decodeKey reads the bytes of one keypress. A lone ESC is told apart from the
+// start of a sequence by waiting at most escapeTimeout for the following byte.
+func (e *Editor) decodeKey() (int, error) {
+	c, err := e.waitForKey()
+	if err != nil {
+		return 0, fmt.Errorf("reading keyboard input: %w", err)
+	}
 
-		switch seq[0] {
-		case '[':
-			if seq[1] >= '0' && seq[1] <= '9' {
-				if nread, err := os.Stdin.Read(seq[2:3]); nread != 1 || err != nil {
-					return '\x1b', nil
-				}
-				if seq[2] == '~' {
-					switch seq[1] {
-					case '1':
-						return HOME_KEY, nil
-					case '3':
-						return DELETE_KEY, nil
-					case '4':
-						return END_KEY, nil
-					case '5':
-						return PAGE_UP, nil
-					case '6':
-						return PAGE_DOWN, nil
-					case '7':
-						return HOME_KEY, nil
-					case '8':
-						return END_KEY, nil
-					}
-				}
-			} else {
-				switch seq[1] {
-				case 'A':
-					return ARROW_UP, nil
-				case 'B':
-					return ARROW_DOWN, nil
-				case 'C':
-					return ARROW_RIGHT, nil
-				case 'D':
-					return ARROW_LEFT, nil
-				case 'H':
-					return HOME_KEY, nil
-				case 'F':
-					return END_KEY, nil
-				}
-			}
-		case 'O':
-			switch seq[1] {
-			case 'H':
-				return HOME_KEY, nil
-			case 'F':
-				return END_KEY, nil
+	if c != '\x1b' {
+		return int(c), nil
+	}
+
+	seq := make([]byte, 2)
+	if seq[0], err = e.input.readByte(e.escapeTimeout()); err != nil {
+		return '\x1b', nil
+	}
+	if seq[0] != '[' && seq[0] != 'O' {
+		return withAltKey(int(seq[0])), nil // Terminals send Alt+key as ESC followed by the key
+	}
+	if seq[1], err = e.input.readByte(e.escapeTimeout()); err != nil {
+		return '\x1b', nil
+	}
+
+	switch seq[0] {
+	case '[':
+		// Collect parameters like "3;5" and any intermediate bytes up to the final byte,
+		// so that the rest of a sequence that isn't recognized isn't taken for typed text
+		params := []byte{}
+		final := seq[1]
+		for final >= 0x20 && final <= 0x3f {
+			params = append(params, final)
+			if final, err = e.input.readByte(e.escapeTimeout()); err != nil {
+				return '\x1b', nil
 			}
 		}
-		return '\x1b', nil
-	} else {
-		return int(c), nil
+		return decodeCSI(string(params), final), nil
+	case 'O':
+		switch seq[1] {
+		case 'H':
+			return HOME_KEY, nil
+		case 'F':
+			return END_KEY, nil
+		}
 	}
+	return '\x1b', nil
+}
 
+// decodeCSI maps the parameters and final byte of a "\x1b[" sequence to a key alias
+func decodeCSI(params string, final byte) int {
+	if final == '~' {
+		switch params {
+		case "1", "7":
+			return HOME_KEY
+		case "3":
+			return DELETE_KEY
+		case "3;5":
+			return CTRL_DELETE_KEY
+		case "4", "8":
+			return END_KEY
+		case "5":
+			return PAGE_UP
+		case "6":
+			return PAGE_DOWN
+		}
+		return '\x1b'
+	}
+
+	if params == "1;3" {
+		switch final {
+		case 'A':
+			return withAltKey(ARROW_UP)
+		case 'B':
+			return withAltKey(ARROW_DOWN)
+		case 'C':
+			return withAltKey(ARROW_RIGHT)
+		case 'D':
+			return withAltKey(ARROW_LEFT)
+		}
+		return '\x1b'
+	}
+	if params == "1;5" {
+		switch final {
+		case 'H':
+			return CTRL_HOME_KEY
+		case 'F':
+			return CTRL_END_KEY
+		}
+		return '\x1b'
+	}
+	if params == "1;2" {
+		switch final {
+		case 'A':
+			return SHIFT_ARROW_UP
+		case 'B':
+			return SHIFT_ARROW_DOWN
+		case 'C':
+			return SHIFT_ARROW_RIGHT
+		case 'D':
+			return SHIFT_ARROW_LEFT
+		}
+		return '\x1b'
+	}
+	if params != "" {
+		return '\x1b'
+	}
+	switch final {
+	case 'Z':
+		return SHIFT_TAB
+	case 'A':
+		return ARROW_UP
+	case 'B':
+		return ARROW_DOWN
+	case 'C':
+		return ARROW_RIGHT
+	case 'D':
+		return ARROW_LEFT
+	case 'H':
+		return HOME_KEY
+	case 'F':
+		return END_KEY
+	}
+	return '\x1b'
 }
 
-func getWindowsSize() (int, int, error) {
-	cols, rows, err := term.GetSize(int(os.Stdout.Fd()))
+func getWindowsSize(tty *os.File) (int, int, error) {
+	cols, rows, err := term.GetSize(int(tty.Fd()))
 	return rows, cols, err
 }
 
+// setScreenSize sets the text area size from the terminal size, leaving room
+// for the status and message bar. Tiny terminals yield an empty text area.
+func (e *Editor) setScreenSize(rows, cols int) {
+	e.screenRows = max(rows-2, 0) // Adjust for status bar and message bar
+	e.screenCols = max(cols, 0)
+	if e.isSplit() {
+		e.arrangeWindows(max(rows-1, 0), max(cols, 0))
+		if !e.isModal() {
+			e.fitWindow(e.activeWindow())
+		}
+	}
+	e.logDiagnostic("window size %dx%d", cols, rows)
+}
+
 func (e *Editor) Redraw() {
-	var err error
-	e.screenRows, e.screenCols, err = getWindowsSize()
+	e.updateScreenSize()
+	e.invalidateFrame()
+	e.RefreshScreen()
+}
+
+// updateScreenSize sets the text area size from the current size of the terminal, and
+// lays out a shown modal screen again for it
+func (e *Editor) updateScreenSize() {
+	rows, cols, err := getWindowsSize(e.terminal.outputFile())
 	if err != nil {
 		e.ShowError("%v", err)
 	}
-	e.screenRows -= 2 // Adjust for status bar and message bar
-	e.RefreshScreen()
+	e.setScreenSize(rows, cols)
+	if e.modal != nil {
+		e.modal.resized()
+	}
 }
 
 /*** syntax highlighting ***/
@@ -318,6 +751,7 @@ func isSeparator(c int) bool {
 
 func (row *editorRow) UpdateSyntax(e *Editor) {
 	row.hl = make([]int, len(row.render))
+	row.dirty = true
 
 	if e.syntax == nil {
 		return
@@ -329,7 +763,6 @@ func (row *editorRow) UpdateSyntax(e *Editor) {
 	mcs := e.syntax.multilineCommentStart
 	mce := e.syntax.multilineCommentEnd
 
-	scsBytes := []byte(scs)
 	mcsBytes := []byte(mcs)
 	mceBytes := []byte(mce)
 
@@ -339,7 +772,12 @@ func (row *editorRow) UpdateSyntax(e *Editor) {
 
 	prevSep := true
 	var inString byte = 0
-	var inComment bool = row.idx > 0 && row.idx-1 < len(e.row) && e.row[row.idx-1].hlOpenComment
+	idx := e.rowIndex(row)
+	var inComment bool = idx > 0 && e.row[idx-1].hlOpenComment
+	if row.render == nil && row.isLong() {
+		// Long rows aren't highlighted, but the rows after them need their comment state
+		inComment = e.endsInComment(row.chars, inComment)
+	}
 
 	for i := 0; i < len(row.render); {
 		c := row.render[i]
@@ -348,13 +786,14 @@ func (row *editorRow) UpdateSyntax(e *Editor) {
 			prevHl = row.hl[i-1]
 		}
 
-		// Highlight control sequences like ^[ ^A ^B etc.
-		if inString == 0 && !inComment && c == '^' && i+1 < len(row.render) {
-			row.hl[i] = HL_CONTROL
-			row.hl[i+1] = HL_CONTROL
+		// Highlight control sequences like ^[ ^A ^B etc., or \x1b \x01 \x02 in hex mode
+		if n, escape := e.controlSequenceAt(row.render[i:]); inString == 0 && !inComment && n > 0 {
+			j := i
+			for ; j < i+n; j++ {
+				row.hl[j] = HL_CONTROL
+			}
 
-			if i+1 < len(row.render) && row.render[i+1] == '[' {
-				j := i + 2
+			if escape {
 				for j < len(row.render) {
 					ch := row.render[j]
 					row.hl[j] = HL_CONTROL
@@ -367,16 +806,14 @@ func (row *editorRow) UpdateSyntax(e *Editor) {
 						break
 					}
 				}
-				i = j
-			} else {
-				i += 2 // Skip both characters for simple control sequences
 			}
+			i = j
 			prevSep = true
 			continue
 		}
 
 		if scsLen > 0 && inString == 0 && !inComment {
-			if bytes.HasPrefix(row.render[i:], scsBytes) {
+			if e.syntax.commentStartsAt(row.render, i) {
 				for j := i; j < len(row.render); j++ {
 					row.hl[j] = HL_COMMENT
 				}
@@ -430,7 +867,7 @@ func (row *editorRow) UpdateSyntax(e *Editor) {
 				prevSep = true
 				continue
 			} else {
-				if c == '"' || c == '\'' {
+				if strings.IndexByte(e.syntax.quotes(), c) >= 0 {
 					inString = c
 					row.hl[i] = HL_STRING
 					i++
@@ -439,8 +876,38 @@ func (row *editorRow) UpdateSyntax(e *Editor) {
 			}
 		}
 
+		if e.syntax.flags&HL_HIGHLIGHT_MAKE != 0 {
+			if n := makeVariableLength(row.render[i:]); n > 0 {
+				for k := range n {
+					row.hl[i+k] = HL_KEYWORD2
+				}
+				i += n
+				prevSep = true
+				continue
+			}
+		}
+
+		if e.syntax.flags&HL_HIGHLIGHT_VERSIONS != 0 && prevSep && c == 'v' && i+1 < len(row.render) && isDigit(row.render[i+1]) {
+			// A version runs until the next whitespace, including suffixes like -pre or +incompatible
+			for i < len(row.render) && row.render[i] != ' ' && row.render[i] != '/' {
+				row.hl[i] = HL_NUMBER
+				i++
+			}
+			prevSep = false
+			continue
+		}
+
 		if e.syntax.flags&HL_HIGHLIGHT_NUMBERS != 0 {
-			if (isDigit(c) && (prevSep || prevHl == HL_NUMBER)) || (c == '.' && prevHl == HL_NUMBER) {
+			if isDigit(c) && prevSep {
+				n := numberLiteralLength(row.render[i:])
+				for k := range n {
+					row.hl[i+k] = HL_NUMBER
+				}
+				i += n
+				prevSep = false
+				continue
+			}
+			if (isDigit(c) && prevHl == HL_NUMBER) || (c == '.' && prevHl == HL_NUMBER) {
 				row.hl[i] = HL_NUMBER
 				i++
 				prevSep = false
@@ -453,7 +920,9 @@ func (row *editorRow) UpdateSyntax(e *Editor) {
 			for j, sublist := range keywords {
 				for _, keyword := range sublist {
 					klen := len(keyword)
-					if bytes.HasPrefix(row.render[i:], []byte(keyword)) {
+					end := i + klen
+					if bytes.HasPrefix(row.render[i:], []byte(keyword)) &&
+						(end == len(row.render) || !isIdentifierChar(row.render[end]) || !isIdentifierChar(keyword[klen-1])) {
 						for k := range klen {
 							row.hl[i+k] = HL_KEYWORD1 + j
 						}
@@ -468,11 +937,122 @@ func (row *editorRow) UpdateSyntax(e *Editor) {
 		i++
 	}
 
+	if e.syntax.flags&HL_HIGHLIGHT_MAKE != 0 {
+		if n := makeTargetLength(row.render); n > 0 {
+			for k := range min(n, len(row.hl)) {
+				if row.hl[k] == HL_NORMAL {
+					row.hl[k] = HL_KEYWORD1
+				}
+			}
+		}
+	}
+	if e.syntax.flags&HL_HIGHLIGHT_HEADERS != 0 && !inComment && markdownHeaderLevel(row.render) > 0 {
+		for k := range row.hl {
+			row.hl[k] = HL_KEYWORD1
+		}
+	}
+	if e.syntax.flags&HL_HIGHLIGHT_HEADERS != 0 && !inComment {
+		// The backticks of a fence aren't quotes, so only the fence is a string
+		if n := markdownFenceLength(row.render); n > 0 {
+			for k := range row.hl {
+				row.hl[k] = HL_NORMAL
+			}
+			for k := range n {
+				row.hl[k] = HL_STRING
+			}
+		}
+	}
+	if e.syntax.flags&HL_HIGHLIGHT_KEYS != 0 {
+		if start, end, ok := yamlKeyRange(row.render); ok {
+			for k := start; k < end; k++ {
+				row.hl[k] = HL_KEYWORD1
+			}
+		}
+	}
+
 	changed := row.hlOpenComment != inComment
 	row.hlOpenComment = inComment
-	if changed && row.idx+1 < e.totalRows {
-		e.row[row.idx+1].UpdateSyntax(e)
+	if changed && idx >= 0 && idx+1 < e.totalRows {
+		e.row[idx+1].UpdateSyntax(e)
+	}
+}
+
+// endsInComment reports whether a multi-line comment is still open at the end of
+// chars, following comments and strings like UpdateSyntax without highlighting them
+func (e *Editor) endsInComment(chars []byte, inComment bool) bool {
+	mcs := []byte(e.syntax.multilineCommentStart)
+	mce := []byte(e.syntax.multilineCommentEnd)
+	if len(mcs) == 0 || len(mce) == 0 {
+		return false
+	}
+	strs := e.syntax.flags&HL_HIGHLIGHT_STRINGS != 0
+
+	var inString byte
+	for i := 0; i < len(chars); i++ {
+		switch {
+		case inString != 0:
+			if chars[i] == '\\' {
+				i++
+			} else if chars[i] == inString {
+				inString = 0
+			}
+		case inComment:
+			if bytes.HasPrefix(chars[i:], mce) {
+				inComment = false
+				i += len(mce) - 1
+			}
+		case e.syntax.commentStartsAt(chars, i):
+			return false
+		case bytes.HasPrefix(chars[i:], mcs):
+			inComment = true
+			i += len(mcs) - 1
+		case strs && strings.IndexByte(e.syntax.quotes(), chars[i]) >= 0:
+			inString = chars[i]
+		}
+	}
+	return inComment
+}
+
+// numberLiteralLength returns the length of the number literal at the start of s, which
+// must start with a digit. It understands 0x, 0b and 0o prefixes, '_' digit separators,
+// fractions and exponents like 1.5e-9.
+func numberLiteralLength(s []byte) int {
+	digits := func(i int, valid func(byte) bool) int {
+		for i < len(s) && (valid(s[i]) || s[i] == '_') {
+			i++
+		}
+		return i
+	}
+
+	if len(s) > 2 && s[0] == '0' {
+		var valid func(byte) bool
+		switch s[1] {
+		case 'x', 'X':
+			valid = func(c byte) bool { return isDigit(c) || (c|0x20 >= 'a' && c|0x20 <= 'f') }
+		case 'b', 'B':
+			valid = func(c byte) bool { return c == '0' || c == '1' }
+		case 'o', 'O':
+			valid = func(c byte) bool { return c >= '0' && c <= '7' }
+		}
+		if valid != nil && valid(s[2]) {
+			return digits(2, valid)
+		}
+	}
+
+	i := digits(0, isDigit)
+	if i+1 < len(s) && s[i] == '.' && isDigit(s[i+1]) {
+		i = digits(i+1, isDigit)
+	}
+	if i+1 < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j+1 < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if isDigit(s[j]) {
+			i = digits(j, isDigit)
+		}
 	}
+	return i
 }
 
 func syntaxToGraphics(hl int) (int, int) {
@@ -491,6 +1071,12 @@ func syntaxToGraphics(hl int) (int, int) {
 		return ANSI_COLOR_BLUE, ANSI_REVERSE
 	case HL_CONTROL:
 		return ANSI_COLOR_RED, ANSI_REVERSE
+	case HL_SELECTION:
+		return ANSI_COLOR_DEFAULT, ANSI_REVERSE
+	case HL_WORD:
+		return ANSI_COLOR_DEFAULT, ANSI_UNDERLINE
+	case HL_CURSOR:
+		return ANSI_COLOR_DEFAULT, ANSI_REVERSE
 	default:
 		return ANSI_COLOR_DEFAULT, 0
 	}
@@ -504,47 +1090,79 @@ func getStyleResetCode(style int) int {
 	return 0
 }
 
+// SelectSyntaxHighlight picks the syntax for the current filename and rehighlights all rows.
+// No syntax is used while highlighting is switched off for the buffer.
 func (e *Editor) SelectSyntaxHighlight() {
+	e.detectedSyntax = e.detectSyntax(e.filename)
 	e.syntax = nil
-	if e.filename == "" {
-		return
+	if !e.highlightOff {
+		e.syntax = e.detectedSyntax
 	}
-
-	filename := e.filename
-	var ext string
-	if lastDot := strings.LastIndex(filename, "."); lastDot != -1 {
-		ext = filename[lastDot:]
+	for filerow := range e.totalRows {
+		e.row[filerow].UpdateSyntax(e)
 	}
+}
 
-	for j := range HLDB_ENTRIES {
-		s := &HLDB_ENTRIES[j]
-		for i := range s.filematch {
-			pattern := s.filematch[i]
-
-			isExt := pattern[0] == '.'
-			if (isExt && ext != "" && ext == pattern) ||
-				(!isExt && strings.Contains(filename, pattern)) {
-				e.syntax = s
-
-				for filerow := range e.totalRows {
-					e.row[filerow].UpdateSyntax(e)
+// detectSyntax returns the syntax whose filematch patterns match filename, or nil.
+// Syntaxes loaded with LoadSyntaxDir are consulted before HLDB_ENTRIES, so that they
+// can take the place of a built-in one.
+func (e *Editor) detectSyntax(filename string) *editorSyntax {
+	if filename == "" {
+		return nil
+	}
+	for _, db := range [][]editorSyntax{e.syntaxes, HLDB_ENTRIES} {
+		for j := range db {
+			s := &db[j]
+			for i := range s.filematch {
+				if matchesFilename(s.filematch[i], filename) {
+					return s
 				}
-				return
 			}
 		}
 	}
+	return nil
+}
+
+// ToggleSyntaxHighlight switches syntax highlighting of the current buffer off or back on
+func (e *Editor) ToggleSyntaxHighlight() {
+	e.highlightOff = !e.highlightOff
+	e.SelectSyntaxHighlight()
+	if e.highlightOff {
+		e.SetStatusMessage("Syntax highlighting off")
+	} else {
+		e.SetStatusMessage("Syntax highlighting on")
+	}
+}
+
+// matchesFilename reports whether a filematch pattern applies to filename. Patterns starting
+// with '.' match the extension, patterns with glob characters are matched against the base
+// name with filepath.Match, and all others must equal the base name exactly.
+func matchesFilename(pattern, filename string) bool {
+	base := filepath.Base(filename)
+	switch {
+	case pattern == "":
+		return false
+	case pattern[0] == '.':
+		return filepath.Ext(base) == pattern
+	case strings.ContainsAny(pattern, "*?["):
+		matched, err := filepath.Match(pattern, base)
+		return err == nil && matched
+	default:
+		return base == pattern
+	}
 }
 
 /*** row operations ***/
 
 // Convert cursor X to render X, since rendered characters may differ from original characters (e.g., tabs)
-func (row *editorRow) cxToRx(cx int) int {
+func (row *editorRow) cxToRx(e *Editor, cx int) int {
+	tabStop := e.tabStop()
 	rx := 0
 	for j := range cx {
 		if row.chars[j] == '\t' {
-			rx += TAB_STOP - (rx % TAB_STOP) // Expand tab to next TAB_STOP boundary
+			rx += tabStop - (rx % tabStop) // Expand tab to next tab stop boundary
 		} else if isControl(row.chars[j]) {
-			rx += CONTROL_SEQUENCE_WIDTH
+			rx += e.controlWidth()
 		} else {
 			rx++
 		}
@@ -552,14 +1170,15 @@ func (row *editorRow) cxToRx(cx int) int {
 	return rx
 }
 
-func (row *editorRow) rxToCx(rx int) int {
+func (row *editorRow) rxToCx(e *Editor, rx int) int {
+	tabStop := e.tabStop()
 	curRx := 0
 	var cx int
 	for cx = 0; cx < len(row.chars); cx++ {
 		if row.chars[cx] == '\t' {
-			curRx += (TAB_STOP - 1) - (curRx % TAB_STOP) // Expand tab to next TAB_STOP boundary
+			curRx += (tabStop - 1) - (curRx % tabStop) // Expand tab to next tab stop boundary
 		} else if isControl(row.chars[cx]) {
-			curRx += CONTROL_SEQUENCE_WIDTH
+			curRx += e.controlWidth() - 1
 		}
 		curRx++
 
@@ -571,6 +1190,15 @@ func (row *editorRow) rxToCx(rx int) int {
 }
 
 func (row *editorRow) Update(e *Editor) {
+	if row.isLong() {
+		// Only the visible columns of very long lines are rendered, when drawing
+		row.render = nil
+		row.renderWidth = row.cxToRx(e, len(row.chars))
+		row.UpdateSyntax(e)
+		e.contentVersion++
+		return
+	}
+
 	tabs := 0
 	controlSequences := 0
 	for _, char := range row.chars {
@@ -582,30 +1210,21 @@ func (row *editorRow) Update(e *Editor) {
 	}
 
 	// Size: for worst case tab expansion
-	row.render = make([]byte, len(row.chars)+tabs*(TAB_STOP-1)+controlSequences*(CONTROL_SEQUENCE_WIDTH-1))
+	tabStop := e.tabStop()
+	row.render = make([]byte, len(row.chars)+tabs*(tabStop-1)+controlSequences*(e.controlWidth()-1))
 
 	idx := 0
 	for _, char := range row.chars {
 		if char == '\t' {
 			row.render[idx] = ' '
 			idx++
-			// Add spaces until we reach the next TAB_STOP boundary
-			for idx%TAB_STOP != 0 {
+			// Add spaces until we reach the next tab stop boundary
+			for idx%tabStop != 0 {
 				row.render[idx] = ' '
 				idx++
 			}
 		} else if isControl(char) {
-			row.render[idx] = '^'
-			idx++
-			switch char {
-			case 127: // DEL character
-				row.render[idx] = '?'
-			case '\x1b': // ESC character
-				row.render[idx] = '['
-			default:
-				row.render[idx] = char + '@' // Convert control character to printable
-			}
-			idx++
+			idx += copy(row.render[idx:], e.controlSequence(char))
 		} else {
 			row.render[idx] = char
 			idx++
@@ -613,67 +1232,231 @@ func (row *editorRow) Update(e *Editor) {
 	}
 
 	row.render = row.render[:idx] // Truncate to actual size
+	row.renderWidth = idx
 	row.UpdateSyntax(e)
+	e.contentVersion++
 }
 
-func (e *Editor) InsertRow(at int, s []byte, rowlen int) {
-	if at < 0 || at > e.totalRows {
-		return
+// controlWidth returns the display width of a control character in the current buffer
+func (e *Editor) controlWidth() int {
+	if e.hexControl {
+		return HEX_CONTROL_WIDTH
 	}
+	return CONTROL_SEQUENCE_WIDTH
+}
 
-	// Create new row
-	newRow := editorRow{
-		idx:           at,
-		chars:         slices.Clone(s[:rowlen]), // Create copy of s with specified length
-		render:        nil,
-		hl:            nil,
-		hlOpenComment: false,
+// controlSequence returns how a control character is shown: in caret notation like ^A,
+// or as a hex escape like \x01 if hex display is switched on
+func (e *Editor) controlSequence(char byte) []byte {
+	if e.hexControl {
+		return fmt.Appendf(nil, "\\x%02x", char)
 	}
+	return []byte{'^', controlGlyph(char)}
+}
 
-	// Insert row using slice operations
-	e.row = append(e.row[:at], append([]editorRow{newRow}, e.row[at:]...)...)
-
-	// Update indices for rows that were shifted
-	for j := at + 1; j < e.totalRows+1; j++ {
-		e.row[j].idx = j
+// controlSequenceAt returns the length of the shown control character at the start
+// of render, or 0 if there is none, and whether it is an escape character
+func (e *Editor) controlSequenceAt(render []byte) (int, bool) {
+	if e.hexControl {
+		if len(render) >= HEX_CONTROL_WIDTH && render[0] == '\\' && render[1] == 'x' &&
+			isHexDigit(render[2]) && isHexDigit(render[3]) {
+			return HEX_CONTROL_WIDTH, string(render[2:4]) == "1b"
+		}
+		return 0, false
 	}
+	if len(render) >= CONTROL_SEQUENCE_WIDTH && render[0] == '^' {
+		return CONTROL_SEQUENCE_WIDTH, render[1] == '['
+	}
+	return 0, false
+}
 
-	e.row[at].Update(e)
-	e.totalRows++
-	e.dirty++
+// isHexDigit reports whether c is a lowercase hexadecimal digit
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f')
 }
 
-func (e *Editor) DeleteRow(at int) {
-	if at < 0 || at >= e.totalRows {
-		return
+// ToggleHexControl switches between showing control characters as ^X and as \xNN
+func (e *Editor) ToggleHexControl() {
+	e.hexControl = !e.hexControl
+	for i := range e.row {
+		e.row[i].Update(e)
 	}
-
-	// Delete row using slice operations
-	e.row = append(e.row[:at], e.row[at+1:]...)
-
-	// Update indices for remaining rows
-	for j := at; j < len(e.row); j++ {
-		e.row[j].idx = j
+	if e.hexControl {
+		e.SetStatusMessage("Control characters shown as hex")
+	} else {
+		e.SetStatusMessage("Control characters shown as ^X")
 	}
-
-	e.totalRows--
-	e.dirty++
 }
 
-func (row *editorRow) InsertChar(e *Editor, at int, c int) {
-	if at < 0 || at > len(row.chars) {
-		at = len(row.chars)
+// controlGlyph returns the printable character shown after '^' for a control character
+func controlGlyph(char byte) byte {
+	switch char {
+	case 127: // DEL character
+		return '?'
+	case '\x1b': // ESC character
+		return '['
+	default:
+		return char + '@' // Convert control character to printable
 	}
+}
 
-	// Insert character at position using slices
-	row.chars = append(row.chars[:at], append([]byte{byte(c)}, row.chars[at:]...)...)
-
-	row.Update(e)
-	e.dirty++
+// isLong reports whether the row is too long to be rendered and highlighted as a whole
+func (row *editorRow) isLong() bool {
+	return len(row.chars) > LONG_LINE_LENGTH
 }
 
-func (row *editorRow) appendBytes(e *Editor, s []byte) {
-	row.chars = append(row.chars, s...)
+// renderColumns returns the rendered characters in the columns [from, to).
+// For long rows without a render it only materializes the requested columns.
+func (row *editorRow) renderColumns(e *Editor, from, to int) []byte {
+	to = min(to, row.renderWidth)
+	from = min(from, to)
+	if row.render != nil || !row.isLong() {
+		return row.render[from:to]
+	}
+
+	tabStop := e.tabStop()
+	out := make([]byte, 0, to-from)
+	rx := 0
+	for _, char := range row.chars {
+		if rx >= to {
+			break
+		}
+		switch {
+		case char == '\t':
+			for next := rx + tabStop - rx%tabStop; rx < next; rx++ {
+				if rx >= from && rx < to {
+					out = append(out, ' ')
+				}
+			}
+		case isControl(char):
+			for _, c := range e.controlSequence(char) {
+				if rx >= from && rx < to {
+					out = append(out, c)
+				}
+				rx++
+			}
+		default:
+			if rx >= from {
+				out = append(out, char)
+			}
+			rx++
+		}
+	}
+	return out
+}
+
+// ensureRender builds the full render of a long row, for operations like search
+// that need the whole line. It is dropped again on the next Update.
+func (row *editorRow) ensureRender(e *Editor) {
+	if row.render == nil && row.isLong() {
+		row.render = row.renderColumns(e, 0, row.renderWidth)
+		row.hl = make([]int, len(row.render))
+	}
+}
+
+// rowIndex returns the position of row in e.row, or -1 if it is not part of the buffer,
+// like the rows of a modal screen before they are shown. Row indices are renumbered on
+// demand, so that inserting or deleting a row doesn't have to touch all following rows.
+// Code replacing e.row as a whole resets e.indexedRows.
+func (e *Editor) rowIndex(row *editorRow) int {
+	if i := row.idx; i >= 0 && i < len(e.row) && &e.row[i] == row {
+		return i
+	}
+
+	// Renumber the rows after the last known good index until row is found. Once all
+	// rows are numbered, a row that isn't found this way is not in the buffer.
+	for e.indexedRows = min(e.indexedRows, len(e.row)); e.indexedRows < len(e.row); {
+		i := e.indexedRows
+		e.row[i].idx = i
+		e.indexedRows++
+		if &e.row[i] == row {
+			return i
+		}
+	}
+	return -1
+}
+
+func (e *Editor) InsertRow(at int, s []byte, rowlen int) {
+	if at < 0 || at > e.totalRows {
+		return
+	}
+
+	// Create new row
+	newRow := editorRow{
+		idx:           at,
+		chars:         slices.Clone(s[:rowlen]), // Create copy of s with specified length
+		render:        nil,
+		hl:            nil,
+		hlOpenComment: false,
+	}
+
+	// Rows after the new one are renumbered lazily by rowIndex
+	e.row = slices.Insert(e.row, at, newRow)
+	e.indexedRows = min(e.indexedRows, at)
+
+	e.row[at].Update(e)
+	e.totalRows++
+	e.markRowsDirty(at)
+	e.dirty++
+	e.recordUndo(undoOp{kind: UNDO_INSERT_ROW, at: at})
+}
+
+// markRowsDirty marks the rows on screen from the given one on as changed, after
+// rows were inserted or deleted before them moved them to other screen lines
+func (e *Editor) markRowsDirty(from int) {
+	for i := from; i < min(e.totalRows, e.rowOffset+e.screenRows); i++ {
+		e.row[i].dirty = true
+	}
+}
+
+func (e *Editor) DeleteRow(at int) {
+	if at < 0 || at >= e.totalRows {
+		return
+	}
+
+	// The deleted row's content is kept for undo, nothing else refers to it anymore
+	e.recordUndo(undoOp{kind: UNDO_DELETE_ROW, at: at, chars: e.row[at].chars})
+
+	// Rows after the deleted one are renumbered lazily by rowIndex
+	e.row = slices.Delete(e.row, at, at+1)
+	e.indexedRows = min(e.indexedRows, at)
+	e.contentVersion++
+
+	e.totalRows--
+	e.markRowsDirty(at)
+	e.dirty++
+
+	// The following row may have been inside a comment opened by the deleted one
+	if at < e.totalRows {
+		e.row[at].UpdateSyntax(e)
+	}
+}
+
+func (row *editorRow) InsertChar(e *Editor, at int, c int) {
+	if at < 0 || at > len(row.chars) {
+		at = len(row.chars)
+	}
+
+	// Insert character at position using slices
+	e.saveRow(row)
+	row.chars = append(row.chars[:at], append([]byte{byte(c)}, row.chars[at:]...)...)
+
+	row.Update(e)
+	e.dirty++
+}
+
+func (row *editorRow) appendBytes(e *Editor, s []byte) {
+	e.saveRow(row)
+	row.chars = append(row.chars, s...)
+
+	row.Update(e)
+	e.dirty++
+}
+
+// replaceBytes replaces the characters from up to to with s
+func (row *editorRow) replaceBytes(e *Editor, from, to int, s []byte) {
+	e.saveRow(row)
+	row.chars = slices.Replace(row.chars, from, to, s...)
 
 	row.Update(e)
 	e.dirty++
@@ -685,6 +1468,7 @@ func (row *editorRow) deleteChar(e *Editor, at int) {
 	}
 
 	// Delete character using slice operations
+	e.saveRow(row)
 	row.chars = slices.Delete(row.chars, at, at+1)
 
 	row.Update(e)
@@ -702,23 +1486,52 @@ func (e *Editor) InsertChar(c int) {
 }
 
 func (e *Editor) InsertNewline() {
+	var prefix []byte
 	if e.cx == 0 {
 		e.InsertRow(e.cy, []byte(""), 0)
 	} else {
 		row := &e.row[e.cy]
 
-		// Insert new row with text from cursor to end of line
-		remainingText := make([]byte, len(row.chars)-e.cx)
-		copy(remainingText, row.chars[e.cx:])
-		e.InsertRow(e.cy+1, remainingText, len(row.chars)-e.cx)
+		var isMarker bool
+		prefix, isMarker = e.continuationPrefix(row.chars[:e.cx])
+		if isMarker && len(prefix) == len(row.chars) && len(bytes.TrimRight(prefix, " \t")) < len(prefix) {
+			// Enter on an empty list item or comment, a marker followed by nothing but
+			// whitespace, ends it instead of continuing it
+			e.saveRow(row)
+			row.chars = row.chars[:len(leadingWhitespace(row.chars))]
+			row.Update(e)
+			e.cx = len(row.chars)
+			e.dirty++
+			return
+		}
 
-		// Truncate current row to text before cursor
-		row = &e.row[e.cy]
-		row.chars = row.chars[:e.cx]
-		row.Update(e)
+		e.splitRow(prefix)
 	}
 	e.cy++
-	e.cx = 0
+	e.cx = len(prefix)
+}
+
+// splitRow moves the text from the cursor to the end of the line to a new row
+// below, starting with prefix. The cursor doesn't move.
+func (e *Editor) splitRow(prefix []byte) {
+	row := &e.row[e.cy]
+	remainingText := append(slices.Clone(prefix), row.chars[e.cx:]...)
+	e.InsertRow(e.cy+1, remainingText, len(remainingText))
+
+	// Truncate current row to text before cursor
+	row = &e.row[e.cy]
+	e.saveRow(row)
+	row.chars = row.chars[:e.cx]
+	row.Update(e)
+}
+
+// SplitLine breaks the line at the cursor like Enter, but without indenting the new
+// line and with the cursor staying at the end of the first line
+func (e *Editor) SplitLine() {
+	if e.cy >= e.totalRows {
+		return
+	}
+	e.splitRow(nil)
 }
 
 func (e *Editor) DeleteChar() {
@@ -731,8 +1544,10 @@ func (e *Editor) DeleteChar() {
 
 	row := &e.row[e.cy]
 	if e.cx > 0 {
-		row.deleteChar(e, e.cx-1)
-		e.cx--
+		for range e.softTabWidth() {
+			row.deleteChar(e, e.cx-1)
+			e.cx--
+		}
 	} else {
 		e.cx = len(e.row[e.cy-1].chars)
 		e.row[e.cy-1].appendBytes(e, row.chars)
@@ -741,11 +1556,86 @@ func (e *Editor) DeleteChar() {
 	}
 }
 
+// DeleteCharForward deletes the character under the cursor.
+// At the end of a line the following line is joined instead.
+func (e *Editor) DeleteCharForward() {
+	if e.cy >= e.totalRows {
+		return
+	}
+
+	row := &e.row[e.cy]
+	if e.cx < len(row.chars) {
+		row.deleteChar(e, e.cx)
+	} else if e.cy+1 < e.totalRows {
+		row.appendBytes(e, e.row[e.cy+1].chars)
+		e.DeleteRow(e.cy + 1)
+	}
+}
+
+// DeleteWordForward deletes from the cursor to the end of the next word, where a
+// separator like punctuation counts as a word of its own. At the end of a line the
+// following line is joined instead.
+func (e *Editor) DeleteWordForward() {
+	if e.cy >= e.totalRows {
+		return
+	}
+
+	row := &e.row[e.cy]
+	if e.cx >= len(row.chars) {
+		if e.cy+1 < e.totalRows {
+			row.appendBytes(e, e.row[e.cy+1].chars)
+			e.DeleteRow(e.cy + 1)
+		}
+		return
+	}
+
+	end := e.cx
+	for end < len(row.chars) && (row.chars[end] == ' ' || row.chars[end] == '\t') {
+		end++
+	}
+	wordStart := end
+	for end < len(row.chars) && !isSeparator(int(row.chars[end])) {
+		end++
+	}
+	if end == wordStart && end < len(row.chars) {
+		end++ // The word is punctuation, delete at least that character
+	}
+
+	e.saveRow(row)
+	row.chars = slices.Delete(row.chars, e.cx, end)
+	row.Update(e)
+	e.dirty++
+}
+
 /*** file i/o ***/
 
 func (e *Editor) RowsToString() ([]byte, int) {
+	result := e.joinRows(e.fileEncoding())
+	if e.bom && e.fileEncoding() == ENCODING_UTF8 {
+		result = UTF8_BOM + result
+	}
+	return []byte(result), len(result)
+}
+
+// Text returns the current buffer content, including unsaved edits, with the
+// buffer's line ending after each row
+func (e *Editor) Text() string {
+	return e.joinRows(ENCODING_UTF8)
+}
+
+// Lines returns a copy of the rows of the current buffer without line endings
+func (e *Editor) Lines() []string {
+	lines := make([]string, len(e.row))
+	for i, row := range e.row {
+		lines[i] = string(row.chars)
+	}
+	return lines
+}
+
+// joinRows joins the rows with the buffer's line ending, encoding each row
+func (e *Editor) joinRows(encoding string) string {
 	var buf strings.Builder
-	lineEnding := getLineEnding()
+	lineEnding := e.lineEndingFor()
 
 	// Pre-calculate total size for efficiency
 	totalSize := 0
@@ -754,155 +1644,453 @@ func (e *Editor) RowsToString() ([]byte, int) {
 	}
 	buf.Grow(totalSize)
 
-	for _, row := range e.row {
-		buf.Write(row.chars)
-		buf.WriteString(lineEnding)
+	for i, row := range e.row {
+		chars, _ := encodeText(row.chars, encoding)
+		buf.Write(chars)
+		if i < len(e.row)-1 {
+			buf.WriteString(lineEnding)
+		} else if e.config.TrailingNewlineRow {
+			// The final line ending is the empty last row, unless the policy forces one
+			if e.insertFinalNewline && len(row.chars) > 0 {
+				buf.WriteString(lineEnding)
+			}
+		} else if e.finalNewline || e.insertFinalNewline {
+			buf.WriteString(lineEnding)
+		}
 	}
 
-	result := buf.String()
-	return []byte(result), len(result)
+	return buf.String()
+}
+
+// lastByteReader remembers the last byte read through it
+type lastByteReader struct {
+	r    io.Reader
+	last byte
+}
+
+func (lr *lastByteReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	if n > 0 {
+		lr.last = p[n-1]
+	}
+	return n, err
+}
+
+// lineEndingCounter splits lines at LF, CRLF and lone CR line endings, counting
+// how often each of them occurs
+type lineEndingCounter struct {
+	lf, crlf, cr int
+}
+
+// scanLines is a bufio.SplitFunc like bufio.ScanLines that also ends lines at a lone CR
+func (c *lineEndingCounter) scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	i := bytes.IndexAny(data, "\r\n")
+	switch {
+	case i < 0 && atEOF && len(data) > 0:
+		return len(data), data, nil // Last line without a line ending
+	case i < 0:
+		return 0, nil, nil
+	case data[i] == '\n':
+		c.lf++
+		return i + 1, data[:i], nil
+	case i+1 < len(data) && data[i+1] == '\n':
+		c.crlf++
+		return i + 2, data[:i], nil
+	case i+1 == len(data) && !atEOF:
+		return 0, nil, nil // The LF of a CRLF may still follow
+	}
+	c.cr++
+	return i + 1, data[:i], nil
+}
+
+// mostCommon returns the line ending that occurred most often, or "" if there was none
+func (c *lineEndingCounter) mostCommon() string {
+	switch {
+	case c.lf == 0 && c.crlf == 0 && c.cr == 0:
+		return ""
+	case c.lf >= c.crlf && c.lf >= c.cr:
+		return "\n"
+	case c.crlf >= c.cr:
+		return "\r\n"
+	}
+	return "\r"
 }
 
 func (e *Editor) Open(filename string) error {
-	e.filename = filename
+	if filename == STDIO_FILENAME {
+		err := e.OpenStdin()
+		e.filename = STDIO_FILENAME
+		return err
+	}
+	if info, err := os.Stat(filename); err == nil && info.IsDir() {
+		return fmt.Errorf("'%s' %w", filename, ErrIsDirectory)
+	}
+
+	e.disk = diskState{}
 	file, err := os.Open(filename)
 	if err != nil {
+		e.filename = filename
+		if errors.Is(err, os.ErrPermission) {
+			return fmt.Errorf("permission denied opening '%s'", filename)
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("could not open file '%s': %w", filename, fs.ErrNotExist)
+		}
 		return fmt.Errorf("could not open file '%s'", filename)
 	}
 	defer file.Close()
 
-	// Reset editor state, because we are opening a new file
+	if e.filename != filename {
+		e.rememberClosed()
+	}
+	if err := e.Load(file, filename); err != nil {
+		return fmt.Errorf("reading '%s': %w", filename, err)
+	}
+
+	if e.config.EditorConfig {
+		e.applyEditorConfig(filename)
+	}
+	if e.config.WarnMixedIndent {
+		e.CheckMixedIndentation()
+	}
+	if e.syntax != nil && e.syntax.flags&HL_HIGHLIGHT_MAKE != 0 {
+		e.CheckRecipeIndentation()
+	}
+	e.rememberDiskState()
+	e.rememberRecentFile(filename)
+	if !e.isValidUTF8() {
+		e.ShowWarning("File is not valid UTF-8, press Alt-E to read it in another encoding like windows-1252")
+	}
+	return nil
+}
+
+// ErrIsDirectory is returned by Open for a directory, which can be shown in the explorer instead
+var ErrIsDirectory = errors.New("is a directory")
+
+// Load replaces the buffer with the lines read from r. The name is used as
+// the filename and selects the syntax highlighting, but nothing is read from
+// or written to it until the buffer is saved.
+func (e *Editor) Load(r io.Reader, name string) error {
+	// Reset editor state, because we are loading new content
+	e.undo = undoHistory{suspended: true}
+	defer e.resetUndo()
+	e.lastSave = time.Time{}
+	e.filename = name
 	e.row = make([]editorRow, 0)
+	e.indexedRows = 0
+	e.contentVersion++
 	e.totalRows = 0
-	e.cx = 0
-	e.cy = 0
-	e.rowOffset = 0
-	e.colOffset = 0
+	e.cx, e.cy = 0, 0
+	e.rowOffset, e.colOffset = 0, 0
 	e.rx = 0
+	e.resetBufferSettings()
 	e.SelectSyntaxHighlight()
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		// Remove trailing newlines and carriage returns
-		for len(line) > 0 && (line[len(line)-1] == '\n' || line[len(line)-1] == '\r') {
-			line = line[:len(line)-1]
-		}
+	// A byte order mark isn't part of the text, it is only kept for saving
+	buffered := bufio.NewReader(r)
+	if start, _ := buffered.Peek(len(UTF8_BOM)); string(start) == UTF8_BOM {
+		buffered.Discard(len(UTF8_BOM))
+		e.bom = true
+	}
 
-		e.InsertRow(e.totalRows, []byte(line), len(line))
+	reader := &lastByteReader{r: buffered}
+	endings := &lineEndingCounter{}
+	scanner := bufio.NewScanner(reader)
+	// Lines may be longer than the scanner's default limit, like minified files
+	scanner.Buffer(make([]byte, 0, 64*1024), math.MaxInt)
+	scanner.Split(endings.scanLines)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		e.InsertRow(e.totalRows, line, len(line))
 	}
 
 	if err := scanner.Err(); err != nil {
-		e.Die("reading file: " + err.Error())
+		return err
+	}
+	e.lineEnding = endings.mostCommon()
+	e.finalNewline = reader.last == '\n' || reader.last == '\r'
+	if e.finalNewline && e.config.TrailingNewlineRow {
+		e.InsertRow(e.totalRows, []byte(""), 0)
 	}
 	e.dirty = 0
+	e.applyIndentStyle()
 	return nil
 }
 
+// OpenStdin reads piped standard input into an unnamed scratch buffer
+func (e *Editor) OpenStdin() error {
+	if err := e.Load(os.Stdin, ""); err != nil {
+		return fmt.Errorf("reading standard input: %w", err)
+	}
+	return nil
+}
+
+// Quit restores the terminal and exits. When editing standard input and output,
+// the last saved content is written to stdout first.
+func (e *Editor) Quit() {
+	e.clearScreen()
+	e.RestoreTerminal()
+	if e.filename == STDIO_FILENAME {
+		if e.stdoutContent != nil {
+			if _, err := os.Stdout.Write(e.stdoutContent); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: writing standard output: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	} else {
+		fmt.Fprintln(e.terminal.outputFile(), "Exiting KIGO editor")
+	}
+	os.Exit(0)
+}
+
 func (e *Editor) Save() {
+	if e.filename == STDIO_FILENAME {
+		// Standard output can only be written once, so it is written when quitting
+		if e.trimTrailingSpace {
+			e.TrimTrailingWhitespace()
+		}
+		e.stdoutContent, _ = e.RowsToString()
+		e.SetStatusMessage("%d bytes will be written to standard output on exit", len(e.stdoutContent))
+		e.dirty = 0
+		return
+	}
+
 	if e.filename == "" {
-		e.filename = e.Prompt("Save as: %s (ESC to cancel)", nil)
-		if e.filename == "" {
+		e.SaveAs()
+		return
+	}
+	if !e.confirmOverwrite() {
+		e.SetStatusMessage("Save aborted")
+		return
+	}
+	e.writeFile()
+}
+
+// SaveAs asks for a filename and saves the buffer under it, even if it already has a
+// name. Cancelling, declining to overwrite another file or a failed write leave the
+// buffer with its previous name and unsaved. A new name selects its syntax highlighting.
+func (e *Editor) SaveAs() {
+	name := e.Prompt("Save as: %s (ESC to cancel)", nil)
+	if name == "" {
+		e.SetStatusMessage("Save aborted")
+		return
+	}
+	if _, err := os.Stat(name); err == nil && name != e.filename {
+		key := e.PromptKey("%s already exists. Overwrite? (y/n)", name)
+		if key != 'y' && key != 'Y' {
 			e.SetStatusMessage("Save aborted")
 			return
 		}
+	}
+
+	previous := e.filename
+	e.filename = name
+	if !e.writeFile() {
+		e.filename = previous
+		return
+	}
+	if name != previous {
 		e.SelectSyntaxHighlight()
 	}
+}
+
+// writeFile writes the buffer to its file and reports whether it succeeded
+func (e *Editor) writeFile() bool {
+	if e.trimTrailingSpace {
+		e.TrimTrailingWhitespace()
+	}
 
 	buf, length := e.RowsToString()
 
 	// Open file for read/write, create if not exists (equivalent to O_RDWR | O_CREAT, 0644)
 	file, err := os.OpenFile(e.filename, os.O_RDWR|os.O_CREATE, 0644)
 	if err != nil {
-		e.SetStatusMessage("Can't save! I/O error: %v", err)
-		return
+		e.ShowError("Can't save! I/O error: %v", err)
+		return false
 	}
 	defer file.Close()
 
 	// Truncate file to exact length (equivalent to ftruncate(fd, len))
 	err = file.Truncate(int64(length))
 	if err != nil {
-		e.SetStatusMessage("Can't save! I/O error: %v", err)
-		return
+		e.ShowError("Can't save! I/O error: %v", err)
+		return false
 	}
 
 	// Write buffer to file (equivalent to write(fd, buf, len))
 	bytesWritten, err := file.Write(buf)
 	if err != nil {
-		e.SetStatusMessage("Can't save! I/O error: %v", err)
-		return
+		e.ShowError("Can't save! I/O error: %v", err)
+		return false
 	}
 
 	// Check if all bytes were written
 	if bytesWritten != length {
-		e.SetStatusMessage("Can't save! Partial write: %d/%d bytes", bytesWritten, length)
-		return
+		e.ShowError("Can't save! Partial write: %d/%d bytes", bytesWritten, length)
+		return false
 	}
 
 	// Success message with byte count (equivalent to C version's success case)
-	e.SetStatusMessage("%d bytes written to disk", length)
+	e.rememberDiskState()
+	if lost := e.unencodableChars(); lost > 0 {
+		e.ShowWarning("%d bytes written to disk, %d characters not in %s were written as '?'", length, lost, e.fileEncoding())
+	} else {
+		e.SetStatusMessage("%d bytes written to disk", length)
+	}
 	e.dirty = 0 // Reset dirty flag after successful save
+	e.lastSave = time.Now()
+	return true
+}
+
+// resetBufferSettings sets the per-buffer settings back to the configured defaults
+func (e *Editor) resetBufferSettings() {
+	e.indent = e.configuredIndent()
+	e.tabSize = e.config.TabStop
+	e.lineEnding = ""
+	e.trimTrailingSpace = false
+	e.insertFinalNewline = e.config.InsertFinalNewline
+	e.finalNewline = true
+	e.bom = false
+	e.encoding = ENCODING_UTF8
+	e.highlightOff = false
+	e.hexControl = e.config.HexControlChars
+}
+
+// TrimTrailingWhitespace removes spaces and tabs at the end of every row
+func (e *Editor) TrimTrailingWhitespace() {
+	for i := range e.row {
+		row := &e.row[i]
+		trimmed := bytes.TrimRight(row.chars, " \t")
+		if len(trimmed) == len(row.chars) {
+			continue
+		}
+		e.saveRow(row)
+		row.chars = trimmed
+		row.Update(e)
+		if e.cy == i {
+			e.cx = min(e.cx, len(row.chars))
+		}
+		e.dirty++
+	}
 }
 
 /*** find ***/
 
-var (
-	lastMatch   = -1
-	direction   = 1
-	savedHlLine int
-	savedHl     []int = nil
-)
+// findState is the state of the search prompt while it is open
+type findState struct {
+	lastMatch int           // row of the match shown, -1 for none
+	lastRx    int           // render column of the match shown
+	direction int           // 1 while searching forward, -1 while searching backward
+	savedHl   map[int][]int // highlighting of the rows with a match highlighted, by row
+}
 
-func (e *Editor) FindCallback(query []byte, key int) {
+// reset starts over from the top of the file, searching forward
+func (f *findState) reset() {
+	f.lastMatch = -1
+	f.lastRx = -1
+	f.direction = 1
+}
+
+// hasMatch reports whether a match is shown highlighted
+func (f *findState) hasMatch() bool {
+	return len(f.savedHl) > 0
+}
+
+// isSearchMatchRow reports whether filerow holds the match the search prompt jumped to
+func (e *Editor) isSearchMatchRow(filerow int) bool {
+	return e.mode == SEARCH_MODE && e.find.lastMatch != -1 && filerow == e.find.lastMatch
+}
 
-	if savedHl != nil {
-		// Restore previous highlights
-		copy(e.row[savedHlLine].hl, savedHl)
-		savedHl = nil
+// restoreSearchHighlight puts back the highlighting of every row a match was highlighted in
+func (e *Editor) restoreSearchHighlight() {
+	for y, hl := range e.find.savedHl {
+		if y >= e.totalRows {
+			continue
+		}
+		row := &e.row[y]
+		row.dirty = true
+		if len(row.hl) == len(hl) {
+			copy(row.hl, hl)
+		} else {
+			// The row changed since its highlighting was saved, so compute it again
+			row.UpdateSyntax(e)
+		}
+		e.contentVersion++
 	}
+	clear(e.find.savedHl)
+}
+
+func (e *Editor) FindCallback(query []byte, key int) {
+	e.restoreSearchHighlight()
 
 	switch key {
 	case '\r', '\x1b':
-		lastMatch = -1
-		direction = 1
+		e.find.reset()
 		return
+	case withAltKey('w'):
+		e.searchWholeWord = !e.searchWholeWord
+		e.find.reset()
+	case withAltKey('r'):
+		e.searchRegex = !e.searchRegex
+		e.find.reset()
 	case ARROW_RIGHT, ARROW_DOWN:
-		direction = 1
+		e.find.direction = 1
 	case ARROW_LEFT, ARROW_UP:
-		direction = -1
+		e.find.direction = -1
 	default:
-		lastMatch = -1
-		direction = 1
+		e.find.reset()
 	}
 
-	if lastMatch == -1 {
-		direction = 1
+	if e.find.lastMatch == -1 {
+		e.find.direction = 1
 	}
-	current := lastMatch
-
-	for range e.totalRows {
-		current += direction
-		if current == -1 {
-			current = e.totalRows - 1
-		} else if current == e.totalRows {
-			current = 0
+	p, err := e.patternFor(query)
+	if e.totalRows == 0 || len(query) == 0 || err != nil {
+		return
+	}
+	current := e.find.lastMatch
+
+	// The row of the last match is searched from the match on, then the other rows
+	// as a whole, ending with that row again to wrap around
+	for i := range e.totalRows + 1 {
+		if i > 0 || current == -1 {
+			current += e.find.direction
+			if current <= -1 {
+				current = e.totalRows - 1
+			} else if current >= e.totalRows {
+				current = 0
+			}
 		}
 
 		row := &e.row[current]
-		match := bytes.Index(row.render, query)
+		row.ensureRender(e)
+		match, end := -1, -1
+		switch {
+		case e.find.direction > 0 && i == 0 && current == e.find.lastMatch:
+			match, end = p.next(row, e.find.lastRx+1)
+		case e.find.direction > 0:
+			match, end = p.next(row, 0)
+		case i == 0:
+			match, end = p.last(row, e.find.lastRx)
+		default:
+			match, end = p.last(row, row.renderWidth+1)
+		}
 		if match != -1 {
-			lastMatch = current
+			e.find.lastMatch, e.find.lastRx = current, match
 			e.cy = current
-			e.cx = row.rxToCx(match)
-			e.rowOffset = e.totalRows
+			e.cx = row.rxToCx(e, match)
+			e.EnsureCursorVisible()
 
-			savedHlLine = current
-			savedHl = make([]int, len(row.hl))
-			copy(savedHl, row.hl)
+			if e.find.savedHl == nil {
+				e.find.savedHl = map[int][]int{}
+			}
+			e.find.savedHl[current] = slices.Clone(row.hl)
 			// Highlight the match
-			for k := match; k < match+len(query) && k < len(row.hl); k++ {
+			for k := match; k < end && k < len(row.hl); k++ {
 				row.hl[k] = HL_MATCH
 			}
+			row.dirty = true
+			e.contentVersion++
 			break
 		}
 	}
@@ -914,14 +2102,36 @@ func (e *Editor) Find() {
 	savedColOffset := e.colOffset
 	savedRowOffset := e.rowOffset
 
-	query := e.Prompt("Search: %s (Use ESC/Arrows/Enter)", e.FindCallback)
+	e.mode = SEARCH_MODE
+	e.searchWholeWord = false
+	e.restoreSearchHighlight()
+	e.find.reset()
+	query := e.PromptFunc(func(input string) string {
+		options := ""
+		if e.searchWholeWord {
+			options = "[word] "
+		}
+		if e.searchRegex {
+			options += "[regex] "
+			if _, err := e.patternFor([]byte(input)); err != nil {
+				input += " (invalid pattern)"
+			}
+		}
+		return fmt.Sprintf("Search: %s%s (Use ESC/Arrows/Enter, Alt-W whole word, Alt-R regex)", options, input)
+	}, e.FindCallback)
+	e.mode = EDIT_MODE
 
 	if query == "" {
 		e.cx = savedCx
 		e.cy = savedCy
 		e.colOffset = savedColOffset
 		e.rowOffset = savedRowOffset
+		return
 	}
+
+	// Remember the query so it can be repeated with FindNext
+	e.searchQuery = []byte(query)
+	e.searchDirection = 1
 }
 
 /*** append buffer ***/
@@ -941,7 +2151,7 @@ func (ab *appendBuffer) append(s []byte) {
 func (e *Editor) Scroll() {
 	e.rx = 0
 	if e.cy < e.totalRows {
-		e.rx = e.row[e.cy].cxToRx(e.cx)
+		e.rx = e.row[e.cy].cxToRx(e, e.cx)
 	}
 
 	if e.cy < e.rowOffset {
@@ -951,99 +2161,236 @@ func (e *Editor) Scroll() {
 		e.rowOffset = e.cy - e.screenRows + 1
 	}
 
+	// Modal screens scroll sideways on their own, independent of the cursor
+	if e.isModal() {
+		return
+	}
 	if e.rx < e.colOffset {
 		e.colOffset = e.rx
 	}
-	if e.rx >= e.colOffset+e.screenCols {
-		e.colOffset = e.rx - e.screenCols + 1
+	if e.rx >= e.colOffset+e.textCols() {
+		e.colOffset = e.rx - e.textCols() + 1
 	}
 }
 
+// isModal reports whether a modal screen like the help or the explorer is shown
+func (e *Editor) isModal() bool {
+	return e.mode == EXPLORER_MODE || e.mode == HELP_MODE || e.mode == QUICK_OPEN_MODE ||
+		e.mode == DIAGNOSTICS_MODE
+}
+
+// textCols returns the number of screen columns available for text,
+// leaving out the gutter and the column reserved for the scrollbar
+func (e *Editor) textCols() int {
+	cols := e.screenCols - e.gutterWidth()
+	if e.config.ShowScrollbar && cols > 1 {
+		return cols - 1
+	}
+	return cols
+}
+
+// scrollbarCell returns the scrollbar glyph for the given visual row.
+// The thumb covers the part of the file currently shown in the viewport.
+func (e *Editor) scrollbarCell(y int) string {
+	total := max(e.totalRows, e.rowOffset+e.screenRows)
+	thumbSize := max(e.screenRows*e.screenRows/total, 1)
+	thumbStart := min(e.rowOffset*e.screenRows/total, e.screenRows-thumbSize)
+	if y >= thumbStart && y < thumbStart+thumbSize {
+		return SCROLLBAR_THUMB
+	}
+	return SCROLLBAR_TRACK
+}
+
 func (e *Editor) DrawRows(abuf *appendBuffer) {
+	// Unchanged rows are copied from the last frame if nothing else about them changed
+	key, cursorIndependent := e.currentRowsKey()
+	reuse := !e.isSplit() && cursorIndependent && e.frame.cursorIndependent && e.frame.rows.sameLayout(key) &&
+		len(e.frame.lines) == e.screenRows+2
+
 	for y := range e.screenRows {
 		filerow := y + e.rowOffset
+		if filerow < e.totalRows && reuse && !e.row[filerow].dirty {
+			abuf.append(e.frame.lines[y])
+			continue
+		}
+		e.drawGutter(abuf, filerow)
 		if filerow >= e.totalRows {
-			if e.totalRows == 0 && y == e.screenRows/3 {
-				welcome := "KIGO editor -- version " + KIGO_VERSION
-				welcomelen := min(len(welcome), e.screenCols)
-				padding := (e.screenCols - welcomelen) / 2
-				if padding > 0 {
-					abuf.append([]byte("~"))
-					padding--
-				}
-				for range padding {
-					abuf.append([]byte(" "))
-				}
-				abuf.append([]byte(welcome[:welcomelen]))
-			} else {
+			// An empty buffer shows the welcome message or the start screen
+			drawn := e.totalRows == 0 && e.drawStartScreenRow(abuf, y)
+			if !drawn && e.textCols() > 0 {
 				abuf.append([]byte("~"))
 			}
 		} else {
-			lineLen := min(max(len(e.row[filerow].render)-e.colOffset, 0), e.screenCols)
+			// The line of the current search match stands out from the other matches
+			if e.isSearchMatchRow(filerow) {
+				abuf.append([]byte(BACKGROUND_HIGHLIGHT))
+			}
 			// Character-by-character rendering with syntax highlighting
 			start := e.colOffset
-			hl := e.row[filerow].hl
-			render := e.row[filerow].render
-			currentColor := -1
-			currentStyle := 0
-			for j := range lineLen {
-				c := render[start+j]
-				h := hl[start+j]
-				if h == HL_NORMAL {
-					// Reset both color and style for normal text
-					if currentColor != -1 {
-						abuf.append(fmt.Appendf(nil, "\x1b[%dm", ANSI_COLOR_DEFAULT))
-						currentColor = -1
-					}
-					if currentStyle != 0 {
-						resetCode := getStyleResetCode(currentStyle)
-						if resetCode != 0 {
-							abuf.append(fmt.Appendf(nil, "\x1b[%dm", resetCode))
-						}
-						currentStyle = 0
-					}
-					abuf.append([]byte{c})
-				} else {
-					// Get both color and style from the combined function
-					color, style := syntaxToGraphics(h)
-
-					// Apply style if different from current
-					if currentStyle != style {
-						// Reset previous style if it was set and not normal
-						if currentStyle != 0 {
-							resetCode := getStyleResetCode(currentStyle)
-							if resetCode != 0 {
-								abuf.append(fmt.Appendf(nil, "\x1b[%dm", resetCode))
-							}
-						}
-						// Apply new style if not normal
-						if style != 0 {
-							abuf.append(fmt.Appendf(nil, "\x1b[%dm", style))
-						}
-						currentStyle = style
-					}
+			render := e.row[filerow].renderColumns(e, start, start+e.textCols())
+			hl := e.displayHighlight(filerow, start, start+len(render))
+			abuf.append(renderColoredRow(render, hl, 0, len(render), e.theme()))
+			// Additional cursors at the end of the line have no character to highlight
+			if endRx := e.row[filerow].renderWidth; endRx-start < e.textCols() && slices.Contains(e.extraCursorColumns(filerow), endRx) {
+				abuf.append([]byte(COLORS_INVERT + " " + COLORS_RESET))
+			}
+			e.row[filerow].dirty = false
+		}
 
-					// Apply color if different from current
-					if color != currentColor {
-						currentColor = color
-						abuf.append(fmt.Appendf(nil, "\x1b[%dm", color))
-					}
-					abuf.append([]byte{c})
-				}
+		abuf.append([]byte(CLEAR_LINE)) // Clear line
+		if e.isSearchMatchRow(filerow) {
+			abuf.append([]byte(BACKGROUND_RESET))
+		}
+		if e.gutterWidth()+e.textCols() < e.screenCols {
+			abuf.append(fmt.Appendf(nil, CURSOR_COLUMN_FORMAT, e.screenLeft+e.screenCols))
+			abuf.append([]byte(e.scrollbarCell(y)))
+		}
+		abuf.append([]byte("\r\n"))
+	}
+}
+
+// Theme maps a highlight class like HL_STRING to its ANSI color and style
+type Theme func(hl int) (color, style int)
+
+// defaultTheme colors the highlighting classes with the terminal's basic colors
+var defaultTheme Theme = syntaxToGraphics
+
+// highlightClassNames names the highlighting classes for config.Colors
+var highlightClassNames = map[int]string{
+	HL_COMMENT:   "comment",
+	HL_MLCOMMENT: "comment",
+	HL_KEYWORD1:  "keyword1",
+	HL_KEYWORD2:  "keyword2",
+	HL_STRING:    "string",
+	HL_NUMBER:    "number",
+	HL_MATCH:     "match",
+	HL_CONTROL:   "control",
+	HL_SELECTION: "selection",
+	HL_WORD:      "word",
+	HL_CURSOR:    "cursor",
+}
+
+// theme returns defaultTheme with the colors replaced by config.Colors
+func (e *Editor) theme() Theme {
+	if len(e.config.Colors) == 0 {
+		return defaultTheme
+	}
+	return func(hl int) (int, int) {
+		color, style := defaultTheme(hl)
+		if configured, ok := e.config.Colors[highlightClassNames[hl]]; ok {
+			color = configured
+		}
+		return color, style
+	}
+}
+
+// renderColoredRow returns the rendered characters in the columns [from, to) with the
+// escape sequences for the colors and styles of their highlighting in the theme, and
+// resets all formatting at the end. Characters past the end of hl are normal text.
+func renderColoredRow(render []byte, hl []int, from, to int, theme Theme) []byte {
+	var abuf appendBuffer
+	currentColor := -1
+	currentStyle := 0
+	for j := max(from, 0); j < min(to, len(render)); j++ {
+		c := render[j]
+		h := HL_NORMAL
+		if j < len(hl) {
+			h = hl[j]
+		}
+		if h == HL_NORMAL {
+			// Reset both color and style for normal text
+			if currentColor != -1 {
+				abuf.append(fmt.Appendf(nil, "\x1b[%dm", ANSI_COLOR_DEFAULT))
+				currentColor = -1
 			}
-			// Reset all formatting at end of line
-			abuf.append(fmt.Appendf(nil, "\x1b[%dm", ANSI_COLOR_DEFAULT))
 			if currentStyle != 0 {
 				resetCode := getStyleResetCode(currentStyle)
 				if resetCode != 0 {
 					abuf.append(fmt.Appendf(nil, "\x1b[%dm", resetCode))
 				}
+				currentStyle = 0
+			}
+			abuf.append([]byte{c})
+		} else {
+			// Get both color and style from the combined function
+			color, style := theme(h)
+
+			// Apply style if different from current
+			if currentStyle != style {
+				// Reset previous style if it was set and not normal
+				if currentStyle != 0 {
+					resetCode := getStyleResetCode(currentStyle)
+					if resetCode != 0 {
+						abuf.append(fmt.Appendf(nil, "\x1b[%dm", resetCode))
+					}
+				}
+				// Apply new style if not normal
+				if style != 0 {
+					abuf.append(fmt.Appendf(nil, "\x1b[%dm", style))
+				}
+				currentStyle = style
+			}
+
+			// Apply color if different from current
+			if color != currentColor {
+				currentColor = color
+				abuf.append(fmt.Appendf(nil, "\x1b[%dm", color))
 			}
+			abuf.append([]byte{c})
 		}
+	}
+	// Reset all formatting at end of line
+	abuf.append(fmt.Appendf(nil, "\x1b[%dm", ANSI_COLOR_DEFAULT))
+	if currentStyle != 0 {
+		resetCode := getStyleResetCode(currentStyle)
+		if resetCode != 0 {
+			abuf.append(fmt.Appendf(nil, "\x1b[%dm", resetCode))
+		}
+	}
+	return abuf.b
+}
 
-		abuf.append([]byte(CLEAR_LINE)) // Clear line
-		abuf.append([]byte("\r\n"))
+// displayHighlight returns the highlighting to draw for the render columns [from, to)
+// of a row, with word occurrences and the selection layered over the syntax highlighting
+func (e *Editor) displayHighlight(filerow, from, to int) []int {
+	row := &e.row[filerow]
+	occurrences := e.wordOccurrences(filerow)
+	selFrom, selTo, hasSel := e.selectionRenderRange(filerow)
+	cursorColumns := e.extraCursorColumns(filerow)
+	if len(row.hl) < to {
+		// Long rows are not syntax highlighted
+		hl := make([]int, to-from)
+		for k := max(selFrom, from); hasSel && k < min(selTo, to); k++ {
+			hl[k-from] = HL_SELECTION
+		}
+		for _, rx := range cursorColumns {
+			if rx >= from && rx < to {
+				hl[rx-from] = HL_CURSOR
+			}
+		}
+		return hl
+	}
+	if len(occurrences) == 0 && !hasSel && len(cursorColumns) == 0 {
+		return row.hl[from:to]
 	}
+
+	hl := slices.Clone(row.hl)
+	for _, start := range occurrences {
+		for k := start; k < start+len(e.highlightedWord); k++ {
+			hl[k] = HL_WORD
+		}
+	}
+	if hasSel {
+		for k := selFrom; k < selTo; k++ {
+			hl[k] = HL_SELECTION
+		}
+	}
+	for _, rx := range cursorColumns {
+		if rx < len(hl) {
+			hl[rx] = HL_CURSOR
+		}
+	}
+	return hl[from:to]
 }
 
 func (e *Editor) DrawStatusBar(abuf *appendBuffer) {
@@ -1051,33 +2398,44 @@ func (e *Editor) DrawStatusBar(abuf *appendBuffer) {
 
 	var status string
 	var rstatus string
-	filename := "[No Name]"
-	if e.filename != "" {
-		filename = e.filename
-		// Truncate filename to 20 characters if needed
-		if len(filename) > 20 {
-			filename = filename[:20]
-		}
-	}
+	// Truncate filename to 20 columns if needed
+	filename := truncateToWidth(e.displayName(), 20)
 	dirtyFlag := ""
 	if e.dirty > 0 {
 		dirtyFlag = "(modified)"
 	}
+	if e.disk.changed != "" {
+		dirtyFlag += "(" + e.disk.changed + ")"
+	}
 	switch e.mode {
 	case EXPLORER_MODE:
 		status = fmt.Sprintf("Explorer - %s %s", filename, dirtyFlag)
+	case QUICK_OPEN_MODE:
+		status = fmt.Sprintf("Quick Open - %s %s", filename, dirtyFlag)
 	default:
 		status = fmt.Sprintf("%.20s - %d lines %s %d", filename, e.totalRows, dirtyFlag, e.dirty)
+		if label := e.bufferLabel(); label != "" {
+			status = "[" + label + "] " + status
+		}
 	}
-	statusLen := min(len(status), e.screenCols)
 
 	filetype := "no ft"
 	if e.syntax != nil {
 		filetype = e.syntax.filetype
+	} else if e.highlightOff && e.detectedSyntax != nil {
+		filetype = e.detectedSyntax.filetype + "/off"
+	}
+	rstatus = fmt.Sprintf("%s | %s | %s | %d/%d %s", filetype, e.fileEncoding(), e.indent, e.cy+1, e.totalRows, e.scrollPosition())
+	rstatusLen := displayWidth(rstatus)
+
+	// The time of the last save is only shown if it fits next to everything else
+	if saved := e.saveIndicator(time.Now()); saved != "" && !e.isModal() &&
+		displayWidth(status)+len(saved)+1+rstatusLen < e.screenCols {
+		status += " " + saved
 	}
-	rstatus = fmt.Sprintf("%s | %d/%d", filetype, e.cy+1, e.totalRows)
-	rstatusLen := len(rstatus)
-	abuf.append([]byte(status[:statusLen]))
+	status = truncateToWidth(status, e.screenCols)
+	statusLen := displayWidth(status)
+	abuf.append([]byte(status))
 
 	for statusLen < e.screenCols {
 		if e.screenCols-statusLen == rstatusLen {
@@ -1093,56 +2451,245 @@ func (e *Editor) DrawStatusBar(abuf *appendBuffer) {
 	abuf.append([]byte("\r\n"))
 }
 
+// saveIndicator describes when the file was last saved, like "saved 12s ago", or
+// returns "" if it wasn't saved since it was opened or changed on disk afterwards
+func (e *Editor) saveIndicator(now time.Time) string {
+	if e.lastSave.IsZero() || e.disk.changed != "" {
+		return ""
+	}
+	ago := now.Sub(e.lastSave)
+	switch {
+	case ago < time.Minute:
+		return fmt.Sprintf("saved %ds ago", int(ago.Seconds()))
+	case ago < time.Hour:
+		return fmt.Sprintf("saved %dm ago", int(ago.Minutes()))
+	default:
+		return "saved " + e.lastSave.Format("15:04")
+	}
+}
+
+// scrollPosition describes how far the viewport is through the file,
+// using "All", "Top", "Bot" or a percentage like classic editors
+func (e *Editor) scrollPosition() string {
+	above := e.rowOffset
+	below := max(e.totalRows-(e.rowOffset+e.screenRows), 0)
+	switch {
+	case above == 0 && below == 0:
+		return "All"
+	case above == 0:
+		return "Top"
+	case below == 0:
+		return "Bot"
+	default:
+		return fmt.Sprintf("%d%%", above*100/(above+below))
+	}
+}
+
 func (e *Editor) DrawMessageBar(abuf *appendBuffer) {
 	abuf.append([]byte(CLEAR_LINE))
-	messageLen := min(len(e.statusMessage), e.screenCols)
-	if time.Since(e.statusMessageTime) < 5*time.Second {
-		abuf.append([]byte(e.statusMessage[:messageLen]))
+	if !e.messageVisible() {
+		return
+	}
+	width := e.screenCols
+	if e.isSplit() && !e.isModal() {
+		width = e.split.cols // The message bar spans all windows
+	}
+	message := truncateToWidth(e.statusMessage, width)
+	switch e.messagePriority {
+	case MESSAGE_ERROR:
+		abuf.append(fmt.Appendf(nil, "\x1b[%dm%s\x1b[%dm", ANSI_COLOR_RED, message, ANSI_COLOR_DEFAULT))
+	case MESSAGE_WARNING:
+		abuf.append(fmt.Appendf(nil, "\x1b[%dm%s\x1b[%dm", ANSI_COLOR_YELLOW, message, ANSI_COLOR_DEFAULT))
+	default:
+		abuf.append([]byte(message))
 	}
 }
 
+// screenFrame remembers what was last written to the terminal, so that
+// RefreshScreen only has to send the lines that changed
+type screenFrame struct {
+	lines             [][]byte
+	rowOffset         int
+	colOffset         int
+	rows              rowsKey
+	cursorIndependent bool // whether the rows were drawn without overlays that follow the cursor
+}
+
+// rowsKey captures what the text rows on screen depend on, apart from
+// overlays like the selection that change with the cursor position
+type rowsKey struct {
+	version    int
+	rowOffset  int
+	colOffset  int
+	screenRows int
+	textCols   int
+	mode       int
+	totalRows  int // the scrollbar depends on the number of rows
+}
+
+// sameLayout reports whether k and other only differ in the content version, so that
+// the rows on screen are drawn to the same screen lines
+func (k rowsKey) sameLayout(other rowsKey) bool {
+	k.version = other.version
+	return k == other
+}
+
+// currentRowsKey returns the key for the rows on screen, and whether drawn rows
+// can be reused while the key stays the same
+func (e *Editor) currentRowsKey() (rowsKey, bool) {
+	key := rowsKey{e.contentVersion, e.rowOffset, e.colOffset, e.screenRows, e.textCols(), e.mode, e.totalRows}
+	cursorIndependent := e.mode == EDIT_MODE && !e.selection.active && !e.hasExtraCursors() && len(e.highlightedWord) == 0 &&
+		!e.gutterDependsOnCursor()
+	return key, cursorIndependent
+}
+
 func (e *Editor) RefreshScreen() {
 	e.Scroll()
+	e.updateHighlightedWord()
+
+	var lines [][]byte
+	key, reusable := e.currentRowsKey()
+	split := e.isSplit() && !e.isModal()
+	if split {
+		lines = e.drawWindows() // With their status bars
+		reusable = false
+	} else if reusable && key == e.frame.rows && len(e.frame.lines) == e.screenRows+2 {
+		// Only the cursor moved, so the rows on screen are still up to date
+		lines = slices.Clone(e.frame.lines[:e.screenRows])
+	} else {
+		var rows appendBuffer
+		e.DrawRows(&rows)
+		lines = bytes.SplitAfter(rows.b, []byte("\r\n"))
+		lines = lines[:len(lines)-1] // Drop the empty piece after the last row
+	}
+
+	var bars appendBuffer
+	if !split {
+		e.DrawStatusBar(&bars)
+	}
+	e.DrawMessageBar(&bars)
+	lines = append(lines, bytes.SplitAfter(bars.b, []byte("\r\n"))...)
 
 	var abuf appendBuffer
 
-	abuf.append([]byte(CURSOR_HIDE))
-	abuf.append([]byte(CURSOR_HOME)) // Move cursor to the top-left corner
+	// A full redraw hides the cursor while the whole screen is repainted. Otherwise
+	// only changed lines are rewritten in place and the visible cursor is repositioned,
+	// which avoids cursor flicker while typing.
+	full := len(lines) != len(e.frame.lines) || e.rowOffset != e.frame.rowOffset || e.colOffset != e.frame.colOffset
+	if full {
+		abuf.append([]byte(CURSOR_HIDE))
+		abuf.append([]byte(CURSOR_HOME)) // Move cursor to the top-left corner
+		abuf.append(bytes.Join(lines, nil))
+	} else {
+		for i, line := range lines {
+			if !bytes.Equal(line, e.frame.lines[i]) {
+				abuf.append(fmt.Appendf(nil, CURSOR_POSITION_FORMAT, i+1, 1))
+				abuf.append(bytes.TrimSuffix(line, []byte("\r\n")))
+			}
+		}
+	}
 
-	e.DrawRows(&abuf)
-	e.DrawStatusBar(&abuf)
-	e.DrawMessageBar(&abuf)
+	abuf.append(fmt.Appendf(nil, CURSOR_POSITION_FORMAT, e.screenTop+e.cy-e.rowOffset+1, e.screenLeft+e.gutterWidth()+e.rx-e.colOffset+1))
 
-	abuf.append(fmt.Appendf(nil, CURSOR_POSITION_FORMAT, e.cy-e.rowOffset+1, e.rx-e.colOffset+1))
+	if full {
+		abuf.append([]byte(CURSOR_SHOW))
+	}
 
-	abuf.append([]byte(CURSOR_SHOW))
+	out := e.writer()
+	out.Write(abuf.b)
+	if err := out.Flush(); err != nil {
+		// The frame may be only partly on screen, so repaint everything next time
+		e.invalidateFrame()
+		e.ShowError("writing to terminal: %v", err)
+		return
+	}
+	e.frame = screenFrame{lines: lines, rowOffset: e.rowOffset, colOffset: e.colOffset, rows: key, cursorIndependent: reusable}
+	e.ringBell()
+	e.sendClipboard()
+}
 
-	os.Stdout.Write(abuf.b)
+// invalidateFrame forces the next RefreshScreen to repaint the whole screen
+func (e *Editor) invalidateFrame() {
+	e.frame = screenFrame{}
 }
 
 func (e *Editor) SetStatusMessage(format string, args ...any) {
-	e.statusMessage = fmt.Sprintf(format, args...)
+	e.setMessage(MESSAGE_INFO, fmt.Sprintf(format, args...))
+}
+
+// setMessage shows a message in the message bar, unless a shown message has a higher priority
+func (e *Editor) setMessage(priority int, message string) {
+	if priority < e.messagePriority && e.messageVisible() {
+		return
+	}
+	e.statusMessage = message
 	e.statusMessageTime = time.Now()
+	e.messagePriority = priority
+}
+
+// messageVisible reports whether the message is still shown. Messages disappear after
+// the configured timeout, but sticky errors stay until the next keypress.
+func (e *Editor) messageVisible() bool {
+	timeout := e.config.MessageTimeout
+	if e.messagePriority > MESSAGE_INFO {
+		if e.config.StickyErrors {
+			return e.statusMessage != ""
+		}
+		timeout = e.config.ErrorTimeout
+	}
+	if timeout <= 0 {
+		timeout = MESSAGE_TIMEOUT
+	}
+	return time.Since(e.statusMessageTime) < timeout
+}
+
+// acknowledgeMessage lowers the priority of the shown message once a key was pressed,
+// so that it can be replaced, and dismisses sticky errors
+func (e *Editor) acknowledgeMessage() {
+	if e.messagePriority > MESSAGE_INFO && e.config.StickyErrors {
+		e.statusMessage = ""
+	}
+	e.messagePriority = MESSAGE_INFO
 }
 
 /*** input ***/
 
 func (e *Editor) Prompt(prompt string, callback func([]byte, int)) string {
-	bufSize := 128
-	buf := make([]byte, 0, bufSize)
+	return e.PromptFunc(func(input string) string {
+		return fmt.Sprintf(prompt, input)
+	}, callback)
+}
+
+// PromptFunc works like Prompt, but builds the prompt text with render on every
+// keypress so that it can reflect state changed by the callback
+func (e *Editor) PromptFunc(render func(input string) string, callback func([]byte, int)) string {
+	input, _ := e.prompt(render, callback, false)
+	return input
+}
+
+// prompt reads a line of input like PromptFunc. With allowEmpty, Enter also accepts
+// an empty input. ok is false if the prompt was cancelled.
+func (e *Editor) prompt(render func(input string) string, callback func([]byte, int), allowEmpty bool) (input string, ok bool) {
+	var buf []rune
+	var pending []byte // bytes of a multi-byte character that is still being read
+	defer e.startPrompt()()
 
 	for {
-		e.SetStatusMessage(prompt, string(buf))
+		e.acknowledgeMessage()
+		e.SetStatusMessage("%s", render(string(buf)))
 		e.RefreshScreen()
 
-		key, err := readKey()
-		if err != nil {
+		key, err := e.readKey()
+		if errors.Is(err, io.EOF) {
+			key = '\x1b' // Input that ended can't complete the prompt, so it is cancelled
+		} else if err != nil {
 			e.ShowError("%v", err)
 			continue // Try again instead of terminating
 		}
 
 		switch key {
 		case DELETE_KEY, BACKSPACE, withControlKey('h'):
+			pending = nil
 			if len(buf) != 0 {
 				buf = buf[:len(buf)-1]
 			}
@@ -1150,37 +2697,66 @@ func (e *Editor) Prompt(prompt string, callback func([]byte, int)) string {
 		case '\x1b':
 			e.SetStatusMessage("")
 			if callback != nil {
-				callback(buf, key)
+				callback([]byte(string(buf)), key)
 			}
-			return ""
+			return "", false
 
 		case '\r':
-			if len(buf) != 0 {
+			if len(buf) != 0 || allowEmpty {
 				e.SetStatusMessage("")
 				if callback != nil {
-					callback(buf, key)
+					callback([]byte(string(buf)), key)
 				}
-				return string(buf)
+				return string(buf), true
 			}
 
 		default:
-			if !isControl(byte(key)) && key < 128 {
-				if len(buf) == bufSize-1 {
-					bufSize *= 2
-					newBuf := make([]byte, len(buf), bufSize)
-					copy(newBuf, buf)
-					buf = newBuf
+			if key >= utf8.RuneSelf && key <= 0xff {
+				// Collect the bytes of a UTF-8 encoded character until it is complete
+				pending = append(pending, byte(key))
+				if !utf8.FullRune(pending) {
+					continue
 				}
-				buf = append(buf, byte(key))
+				if r, _ := utf8.DecodeRune(pending); r != utf8.RuneError {
+					buf = append(buf, r)
+				}
+				pending = nil
+			} else if key < utf8.RuneSelf && !isControl(byte(key)) {
+				pending = nil
+				buf = append(buf, rune(key))
 			}
 		}
 		if callback != nil {
-			callback(buf, key)
+			callback([]byte(string(buf)), key)
 		}
 	}
 }
 
+// startPrompt marks a prompt as waiting for input until the returned function is called
+func (e *Editor) startPrompt() func() {
+	prompting := e.prompting
+	e.prompting = true
+	return func() { e.prompting = prompting }
+}
+
+// PromptKey shows a message in the status bar and returns the next key pressed
+func (e *Editor) PromptKey(format string, args ...any) int {
+	defer e.startPrompt()()
+	e.acknowledgeMessage()
+	e.SetStatusMessage(format, args...)
+	e.RefreshScreen()
+
+	key, err := e.readKey()
+	if err != nil {
+		e.ShowError("%v", err)
+		return '\x1b'
+	}
+	return key
+}
+
 func (e *Editor) MoveCursor(key int) {
+	startCx, startCy := e.cx, e.cy
+
 	var row *editorRow
 	if e.cy >= e.totalRows {
 		row = nil
@@ -1190,24 +2766,28 @@ func (e *Editor) MoveCursor(key int) {
 
 	switch key {
 	case ARROW_LEFT:
+		e.resetGoalColumn()
 		if e.cx != 0 {
 			e.cx--
-		} else if e.cy > 0 {
+		} else if e.cy > 0 && e.config.WrapCursor {
 			e.cy--
 			e.cx = len(e.row[e.cy].chars)
 		}
 	case ARROW_RIGHT:
+		e.resetGoalColumn()
 		if row != nil && e.cx < len(row.chars) {
 			e.cx++
-		} else if row != nil && e.cx == len(row.chars) {
+		} else if row != nil && e.cx == len(row.chars) && e.config.WrapCursor {
 			e.cy++
 			e.cx = 0
 		}
 	case ARROW_UP:
+		e.rememberGoalColumn()
 		if e.cy != 0 {
 			e.cy--
 		}
 	case ARROW_DOWN:
+		e.rememberGoalColumn()
 		if e.cy < e.totalRows {
 			e.cy++
 		}
@@ -1218,6 +2798,9 @@ func (e *Editor) MoveCursor(key int) {
 	} else {
 		row = &e.row[e.cy]
 	}
+	if (key == ARROW_UP || key == ARROW_DOWN) && row != nil {
+		e.cx = row.rxToCx(e, e.goalRx)
+	}
 	rowlen := 0
 	if row != nil {
 		rowlen = len(row.chars)
@@ -1225,89 +2808,376 @@ func (e *Editor) MoveCursor(key int) {
 	if e.cx > rowlen {
 		e.cx = rowlen
 	}
+	if e.cx == startCx && e.cy == startCy {
+		e.Bell() // Already at the start or end of the file
+	}
+}
+
+// rememberGoalColumn stores the current render column as the column that
+// vertical movement tries to return to, unless a goal is already set
+func (e *Editor) rememberGoalColumn() {
+	if e.hasGoalRx {
+		return
+	}
+	e.goalRx = 0
+	if e.cy < e.totalRows {
+		e.goalRx = e.row[e.cy].cxToRx(e, e.cx)
+	}
+	e.hasGoalRx = true
+}
+
+// resetGoalColumn forgets the goal column after horizontal moves and edits
+func (e *Editor) resetGoalColumn() {
+	e.hasGoalRx = false
 }
 
-var quitTimes = QUIT_TIMES
+// MovePage scrolls the cursor and the viewport by one screen height.
+// The cursor keeps its render column on the destination line.
+func (e *Editor) MovePage(key int) {
+	e.rememberGoalColumn()
+	rx := e.goalRx
+
+	delta := e.screenRows
+	if key == PAGE_UP {
+		delta = -delta
+	}
+
+	lastRow := max(e.totalRows-1, 0)
+	e.cy = min(max(e.cy+delta, 0), lastRow)
+	e.rowOffset = min(max(e.rowOffset+delta, 0), max(e.totalRows-e.screenRows, 0))
+
+	e.cx = 0
+	if e.cy < e.totalRows {
+		e.cx = e.row[e.cy].rxToCx(e, rx)
+	}
+}
 
 func (e *Editor) ProcessKeypress() {
 
-	key, err := readKey()
+	key, err := e.readKey()
 	if err != nil {
 		e.ShowError("%v", err)
 		return // Skip this keypress and continue
 	}
+	e.processKey(key)
+}
 
-	switch key {
-	case '\r':
-		e.InsertNewline()
+// processKey runs the command bound to key
+func (e *Editor) processKey(key int) {
+	e.acknowledgeMessage()
+	defer e.commitUndo() // The changes of every command are undone together
+	key = e.mapKey(key)
 
-	case withControlKey('q'):
-		if e.dirty > 0 && quitTimes > 0 {
-			e.SetStatusMessage("WARNING: File has unsaved changes. Press Ctrl-Q %d more times to quit.", quitTimes)
-			quitTimes--
-			return
-		}
+	if e.startScreenActive() && e.startScreenKey(key) {
+		return
+	}
 
-		e.RestoreTerminal()
-		os.Stdout.Write([]byte(CLEAR_SCREEN))
-		os.Stdout.Write([]byte(CURSOR_HOME))
-		fmt.Println("Exiting KIGO editor")
-		os.Exit(0)
+	// Alt+digits give the next command a repeat count
+	if digit, ok := repeatDigit(key); ok {
+		e.repeatCount = min(e.repeatCount*10+digit, MAX_REPEAT_COUNT)
+		e.SetStatusMessage("Repeat: %d", e.repeatCount)
+		return
+	}
+	count := e.repeatCount
+	times := 1
+	if count > 0 && isRepeatable(key) {
+		times = count
+	}
+	e.repeatCount = 0
 
-	case withControlKey('s'):
-		e.Save()
+	if key != ARROW_UP && key != ARROW_DOWN && key != PAGE_UP && key != PAGE_DOWN &&
+		key != SHIFT_ARROW_UP && key != SHIFT_ARROW_DOWN {
+		e.resetGoalColumn() // Only consecutive vertical moves keep the goal column
+	}
 
-	case HOME_KEY:
-		e.cx = 0
+	keepSelection := false
+	keepCursors := false
 
-	case END_KEY:
-		if e.cy < e.totalRows {
-			e.cx = len(e.row[e.cy].chars)
-		}
+	for range times {
+		switch key {
+		case '\r':
+			e.DeleteSelection() // Typing replaces the selected text
+			e.InsertNewline()
+
+		case SHIFT_ARROW_LEFT, SHIFT_ARROW_RIGHT, SHIFT_ARROW_UP, SHIFT_ARROW_DOWN:
+			e.extendSelection(key)
+			keepSelection = true
+
+		case '\t':
+			// A selection within a row is replaced like by typing, one spanning rows is indented
+			if _, _, ok := e.selectedRows(); ok && e.selection.anchorY != e.cy {
+				e.IndentSelection()
+				keepSelection = true
+			} else if e.DeleteSelection() || e.hasExtraCursors() || !e.ExpandSnippet() {
+				e.InsertTab()
+			}
 
-	case withControlKey('e'):
-		e.Explorer()
-		e.mode = EDIT_MODE
+		case SHIFT_TAB:
+			e.DedentSelection()
+			keepSelection = true
 
-	case withControlKey('f'):
-		e.Find()
+		case withControlKey('q'):
+			if (e.dirty > 0 || e.otherBuffersDirty()) && e.quitTimes > 0 {
+				e.ShowWarning("File has unsaved changes. Press Ctrl-Q %d more times to quit.", e.quitTimes)
+				e.quitTimes--
+				return
+			}
 
-	case withControlKey('r'):
-		e.Redraw()
+			e.Quit()
 
-	case withControlKey('h'):
-		e.Help()
+		case withControlKey('s'):
+			e.Save()
 
-	case BACKSPACE, DELETE_KEY:
-		if key == DELETE_KEY {
-			e.MoveCursor(ARROW_RIGHT)
-		}
-		e.DeleteChar()
+		case withControlKey('z'):
+			e.Undo()
 
-	case PAGE_UP:
-		e.cy = e.rowOffset
-		for range e.screenRows {
-			e.MoveCursor(ARROW_UP)
-		}
+		case withControlKey('w'):
+			e.WindowCommand()
 
-	case PAGE_DOWN:
-		e.cy = min(e.rowOffset+e.screenRows-1, e.totalRows)
-		for range e.screenRows {
-			e.MoveCursor(ARROW_DOWN)
-		}
+		case withControlKey('b'):
+			e.ToggleSelectMode()
+			keepSelection = e.mode == SELECT_MODE
 
-	case ARROW_LEFT, ARROW_RIGHT, ARROW_UP, ARROW_DOWN:
-		e.MoveCursor(key)
+		case withControlKey('c'):
+			e.Copy()
 
-	case withControlKey('l'):
-	case '\x1b':
-		break
+		case withControlKey('x'):
+			e.Cut()
 
-	default:
-		e.InsertChar(key)
+		case withControlKey('u'):
+			e.Paste()
+
+		case withControlKey('y'):
+			e.Redo()
+
+		case withAltKey('s'):
+			e.SaveAs()
+
+		case withAltKey('n'):
+			e.NextBuffer(1)
+
+		case withAltKey('e'):
+			e.ChangeEncodingPrompt()
+
+		case withAltKey('l'):
+			e.Reload()
+
+		case withAltKey('h'):
+			e.ToggleSyntaxHighlight()
+
+		case withAltKey('q'):
+			e.CloseBuffer()
+
+		case withAltKey('Q'):
+			e.ReopenClosed()
+
+		case withAltKey('t'):
+			e.ToggleHeaderSource()
+
+		case withAltKey('c'):
+			e.ToggleHexControl()
+
+		case withAltKey('L'):
+			e.CycleLineNumbers()
+
+		case withAltKey('g'):
+			e.ShowDiagnostics()
+
+		case withAltKey('C'):
+			e.CropToSelection()
+
+		case withAltKey('a'):
+			e.ExpandSelection()
+			keepSelection = true
+
+		case withControlKey('v'):
+			e.QuotedInsert()
+
+		case withAltKey('/'):
+			e.ToggleBlockComment()
+			keepSelection = true
+
+		case withAltKey('k'):
+			e.DeleteSelection()
+			e.SplitLine()
+
+		case withAltKey('x'):
+			e.ExportPrompt()
+
+		case withAltKey('>'):
+			e.ShiftLine(1)
+
+		case withAltKey('<'):
+			e.ShiftLine(-1)
+
+		case withAltKey('p'):
+			e.NextBuffer(-1)
+
+		case CTRL_HOME_KEY, CTRL_END_KEY:
+			// A repeat count goes to that line instead, like 12G in vi
+			switch {
+			case count > 0:
+				e.GotoLine(count)
+			case key == CTRL_HOME_KEY:
+				e.GotoFileStart()
+			default:
+				e.GotoFileEnd()
+			}
+
+		case HOME_KEY:
+			e.cx = 0
+
+		case END_KEY:
+			if e.cy < e.totalRows {
+				e.cx = len(e.row[e.cy].chars)
+			}
+
+		case SMART_HOME_KEY:
+			e.SmartHome()
+
+		case withControlKey('e'):
+			e.Explorer()
+			e.mode = EDIT_MODE
+
+		case withControlKey('o'):
+			e.QuickOpen()
+			e.mode = EDIT_MODE
+
+		case withControlKey('f'):
+			e.Find()
+
+		case withControlKey('r'):
+			e.ReplaceInteractive()
+
+		case withControlKey('h'):
+			e.Help()
+
+		case withControlKey('t'):
+			e.ToggleWordHighlight()
+
+		case withControlKey('g'):
+			e.Goto()
+
+		case withAltKey('r'):
+			e.Replace()
+			keepSelection = true
+
+		case withAltKey('i'):
+			e.NormalizeIndentationPrompt()
+
+		case withAltKey('v'):
+			e.ReverseLines()
+			keepSelection = true
+
+		case withAltKey('u'):
+			e.RemoveDuplicateLines(true)
+
+		case withAltKey('U'):
+			e.RemoveDuplicateLines(false)
+
+		case withAltKey('d'):
+			e.DuplicateLines(1)
+
+		case withAltKey('D'):
+			e.DuplicateLines(-1)
+
+		case withControlKey('n'):
+			e.FindNext(false)
+
+		case withControlKey('p'):
+			e.FindNext(true)
+
+		case withAltKey('*'):
+			e.FindWordUnderCursor(1)
+
+		case withAltKey('#'):
+			e.FindWordUnderCursor(-1)
+
+		case CTRL_DELETE_KEY:
+			e.DeleteWordForward()
+
+		case BACKSPACE, DELETE_KEY:
+			if e.DeleteSelection() {
+				break
+			}
+			if e.hasExtraCursors() {
+				// Joining lines is not supported with multiple cursors
+				e.forEachCursor(func() {
+					if key == DELETE_KEY && e.cy < e.totalRows && e.cx < len(e.row[e.cy].chars) {
+						e.DeleteCharForward()
+					} else if key == BACKSPACE && e.cx > 0 {
+						e.DeleteChar()
+					}
+				})
+				keepCursors = true
+				break
+			}
+			if key == DELETE_KEY {
+				e.DeleteCharForward()
+			} else {
+				e.DeleteChar()
+			}
+
+		case PAGE_UP, PAGE_DOWN:
+			e.MovePage(key)
+
+		case ARROW_LEFT, ARROW_RIGHT:
+			if e.hasExtraCursors() {
+				e.MoveCursors(key)
+				keepCursors = true
+				break
+			}
+			e.MoveCursor(key)
+
+		case ARROW_UP, ARROW_DOWN:
+			e.MoveCursor(key)
+
+		case withAltKey(ARROW_DOWN):
+			e.AddCursorBelow()
+			keepCursors = true
+
+		case withControlKey('d'):
+			e.AddCursorAtNextOccurrence()
+			keepCursors = true
+
+		case withControlKey('l'):
+			e.Redraw()
+
+		case '\x1b':
+			break
+
+		default:
+			if key > 0xff {
+				break // Unbound special key
+			}
+			if e.hasExtraCursors() {
+				e.forEachCursor(func() {
+					e.dedentClosingBracket(key)
+					e.InsertChar(key)
+				})
+				keepCursors = true
+				break
+			}
+			e.DeleteSelection() // Typing replaces the selected text
+			e.dedentClosingBracket(key)
+			e.InsertChar(key)
+		}
 	}
 
-	quitTimes = QUIT_TIMES // Reset quit times after processing a key
+	if e.mode == SELECT_MODE {
+		if selectModeKeys[key] {
+			keepSelection = true
+		} else if !keepSelection {
+			e.mode = EDIT_MODE // Any other command ends selecting
+		}
+	}
+	if !keepSelection {
+		e.clearSelection()
+	}
+	if !keepCursors {
+		e.clearCursors()
+	}
+	e.quitTimes = e.config.QuitTimes // Reset quit times after processing a key
 }
 
 /*** init ***/
@@ -1319,30 +3189,43 @@ func NewTerminal() *Terminal {
 
 // NewEditor creates a new Editor instance with proper initialization
 func NewEditor() Editor {
-	return Editor{
-		terminal: NewTerminal(),
-	}
+	e := Editor{
+		terminal:  NewTerminal(),
+		config:    DefaultConfig(),
+		quitTimes: QUIT_TIMES,
+		find:      findState{lastMatch: -1, direction: 1},
+	}
+	e.resetBufferSettings() // The empty buffer has the configured settings from the start
+	return e
 }
 
 func (e *Editor) Init() error {
+	e.quitTimes = e.config.QuitTimes
+	e.start = startScreen{files: readRecentFiles(e.config.RecentFilesPath)}
+	e.find.reset()
+	clear(e.find.savedHl)
 	e.cx, e.cy = 0, 0
 	e.rx = 0
 	e.rowOffset = 0
 	e.colOffset = 0
 	e.totalRows = 0
 	e.row = make([]editorRow, 0)
+	e.indexedRows = 0
+	e.contentVersion++
 	e.dirty = 0
 	e.filename = ""
 	e.statusMessage = ""
 	e.statusMessageTime = time.Time{}
+	e.messagePriority = MESSAGE_INFO
 	e.syntax = nil
+	e.detectedSyntax = nil
 	e.mode = EDIT_MODE
+	e.resetBufferSettings()
 
-	var err error
-	e.screenRows, e.screenCols, err = getWindowsSize()
+	rows, cols, err := getWindowsSize(e.terminal.outputFile())
 	if err != nil {
 		return errors.New("getting window size")
 	}
-	e.screenRows -= 2
+	e.setScreenSize(rows, cols)
 	return nil
 }
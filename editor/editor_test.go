@@ -1,7 +1,17 @@
 package editor
 
 import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 )
 
 func TestEditorRowDeleteChar(t *testing.T) {
@@ -64,3 +74,1092 @@ func TestEditorRowDeleteCharMultiple(t *testing.T) {
 		t.Errorf("Expected chars slice length 1, got %d", len(row.chars))
 	}
 }
+
+// newTestEditor creates an editor with the given lines and a fixed screen size
+func newTestEditor(screenRows, screenCols int, lines ...string) *Editor {
+	e := &Editor{screenRows: screenRows, screenCols: screenCols}
+	for _, line := range lines {
+		e.InsertRow(e.totalRows, []byte(line), len(line))
+	}
+	e.dirty = 0
+	e.resetUndo()
+	return e
+}
+
+func TestDeleteWordForward(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		cx       int
+		expected []string
+	}{
+		{"word", []string{"foo bar"}, 0, []string{" bar"}},
+		{"whitespace and word", []string{"foo bar"}, 3, []string{"foo"}},
+		{"inside a word", []string{"foobar baz"}, 3, []string{"foo baz"}},
+		{"whitespace and punctuation", []string{"  ,foo"}, 0, []string{"foo"}},
+		{"punctuation", []string{"a(b)"}, 1, []string{"ab)"}},
+		{"trailing whitespace", []string{"foo  "}, 3, []string{"foo"}},
+		{"end of line joins", []string{"foo", "bar"}, 3, []string{"foobar"}},
+		{"end of file", []string{"foo"}, 3, []string{"foo"}},
+	}
+	for _, tt := range tests {
+		e := newTestEditor(10, 80, tt.lines...)
+		e.cx = tt.cx
+		e.DeleteWordForward()
+		if got := e.Lines(); !slices.Equal(got, tt.expected) {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, got)
+		}
+		if e.cx != tt.cx || e.cy != 0 {
+			t.Errorf("%s: expected the cursor to stay at %d,0, got %d,%d", tt.name, tt.cx, e.cx, e.cy)
+		}
+	}
+}
+
+func TestDecodeCSI(t *testing.T) {
+	tests := []struct {
+		params string
+		final  byte
+		want   int
+	}{
+		{"", 'A', ARROW_UP},
+		{"", 'D', ARROW_LEFT},
+		{"", 'H', HOME_KEY},
+		{"", 'Z', SHIFT_TAB},
+		{"3", '~', DELETE_KEY},
+		{"3;5", '~', CTRL_DELETE_KEY},
+		{"5", '~', PAGE_UP},
+		{"8", '~', END_KEY},
+		{"1;2", 'A', SHIFT_ARROW_UP},
+		{"1;2", 'C', SHIFT_ARROW_RIGHT},
+		{"1;3", 'B', withAltKey(ARROW_DOWN)},
+		{"1;5", 'H', CTRL_HOME_KEY},
+		{"1;5", 'F', CTRL_END_KEY},
+		{"1;5", 'A', '\x1b'},
+		{"3;2", '~', '\x1b'},
+		{"2", 'A', '\x1b'},
+	}
+	for _, tt := range tests {
+		if got := decodeCSI(tt.params, tt.final); got != tt.want {
+			t.Errorf("decodeCSI(%q, %q) = %d, want %d", tt.params, tt.final, got, tt.want)
+		}
+	}
+}
+
+func TestDeleteKey(t *testing.T) {
+	for _, wrap := range []bool{true, false} {
+		e := newTestEditor(10, 80, "abc", "def")
+		e.config = DefaultConfig()
+		e.config.WrapCursor = wrap
+		e.output = newOutput(io.Discard)
+
+		e.cx = 1
+		pressKeys(e, "\x1b[3~")
+		if got := e.Lines(); !slices.Equal(got, []string{"ac", "def"}) || e.cx != 1 {
+			t.Errorf("wrap=%v: expected the character under the cursor deleted, got %q at %d", wrap, got, e.cx)
+		}
+
+		// At the end of a line the next line is joined, regardless of cursor wrapping
+		e.cx = 2
+		pressKeys(e, "\x1b[3~")
+		if got := e.Lines(); !slices.Equal(got, []string{"acdef"}) || e.cx != 2 || e.cy != 0 {
+			t.Errorf("wrap=%v: expected the next line joined, got %q at %d,%d", wrap, got, e.cx, e.cy)
+		}
+
+		e.cx = 5
+		pressKeys(e, "\x1b[3~")
+		if got := e.Lines(); !slices.Equal(got, []string{"acdef"}) || e.cx != 5 {
+			t.Errorf("wrap=%v: expected nothing deleted at the end of the file, got %q at %d", wrap, got, e.cx)
+		}
+	}
+}
+
+func TestMoveCursorWrap(t *testing.T) {
+	for _, wrap := range []bool{true, false} {
+		e := newTestEditor(10, 80, "first", "last")
+		e.config.WrapCursor = wrap
+
+		// Left at the start of the first line stays put either way
+		e.MoveCursor(ARROW_LEFT)
+		if e.cx != 0 || e.cy != 0 {
+			t.Errorf("wrap=%v: expected to stay at 0,0, got %d,%d", wrap, e.cx, e.cy)
+		}
+
+		e.cx = 5
+		e.MoveCursor(ARROW_RIGHT)
+		if wrap && (e.cx != 0 || e.cy != 1) {
+			t.Errorf("wrap=%v: expected Right to wrap to the last line, got %d,%d", wrap, e.cx, e.cy)
+		} else if !wrap && (e.cx != 5 || e.cy != 0) {
+			t.Errorf("wrap=%v: expected Right to stop at the line end, got %d,%d", wrap, e.cx, e.cy)
+		}
+
+		e.cx, e.cy = 0, 1
+		e.MoveCursor(ARROW_LEFT)
+		if wrap && (e.cx != 5 || e.cy != 0) {
+			t.Errorf("wrap=%v: expected Left to wrap to the first line, got %d,%d", wrap, e.cx, e.cy)
+		} else if !wrap && (e.cx != 0 || e.cy != 1) {
+			t.Errorf("wrap=%v: expected Left to stop at the line start, got %d,%d", wrap, e.cx, e.cy)
+		}
+
+		e.cx, e.cy = 4, 1
+		e.MoveCursor(ARROW_RIGHT)
+		e.MoveCursor(ARROW_RIGHT)
+		if wrap && (e.cx != 0 || e.cy != 2) {
+			t.Errorf("wrap=%v: expected Right to wrap below the last line, got %d,%d", wrap, e.cx, e.cy)
+		} else if !wrap && (e.cx != 4 || e.cy != 1) {
+			t.Errorf("wrap=%v: expected Right to stop at the end of the last line, got %d,%d", wrap, e.cx, e.cy)
+		}
+	}
+}
+
+func TestMovePagePreservesColumn(t *testing.T) {
+	lines := make([]string, 50)
+	for i := range lines {
+		lines[i] = "\tabcdefgh"
+	}
+	lines[20] = "ab"
+	e := newTestEditor(10, 80, lines...)
+	e.cx = 3 // render column 6, after the tab
+
+	e.MovePage(PAGE_DOWN)
+	if e.cy != 10 || e.rowOffset != 10 {
+		t.Fatalf("Expected cy=10 rowOffset=10, got cy=%d rowOffset=%d", e.cy, e.rowOffset)
+	}
+	if e.cx != 3 {
+		t.Errorf("Expected cx 3 after page down, got %d", e.cx)
+	}
+
+	e.MovePage(PAGE_DOWN)
+	if e.cy != 20 || e.cx != 2 {
+		t.Errorf("Expected cursor clamped to end of short line (20,2), got (%d,%d)", e.cy, e.cx)
+	}
+
+	e.cy, e.cx, e.rowOffset = 30, 3, 25
+	e.MovePage(PAGE_UP)
+	if e.cy != 20 || e.cx != 2 {
+		t.Errorf("Expected (20,2) after page up, got (%d,%d)", e.cy, e.cx)
+	}
+
+	e.cy, e.cx, e.rowOffset = 45, 3, 36
+	e.MovePage(PAGE_DOWN)
+	if e.cy != 49 || e.cx != 3 || e.rowOffset != 40 {
+		t.Errorf("Expected page down clamped at (49,3) with rowOffset 40, got (%d,%d) rowOffset %d", e.cy, e.cx, e.rowOffset)
+	}
+
+	e.MovePage(PAGE_UP)
+	e.MovePage(PAGE_UP)
+	e.MovePage(PAGE_UP)
+	e.MovePage(PAGE_UP)
+	e.MovePage(PAGE_UP)
+	if e.cy != 0 || e.rowOffset != 0 || e.cx != 3 {
+		t.Errorf("Expected page up clamped at (0,3) with rowOffset 0, got (%d,%d) rowOffset %d", e.cy, e.cx, e.rowOffset)
+	}
+}
+
+func TestMoveCursorKeepsGoalColumn(t *testing.T) {
+	e := newTestEditor(10, 80,
+		"a long line of text",
+		"short",
+		"",
+		"\tanother long line",
+		"a long line of text",
+	)
+	e.cx = 12
+
+	expected := []struct{ cy, cx int }{
+		{1, 5},  // clamped to end of "short"
+		{2, 0},  // empty line
+		{3, 9},  // tab expands to 4 columns, so render column 12 is cx 9
+		{4, 12}, // back at the original column
+	}
+	for _, want := range expected {
+		e.MoveCursor(ARROW_DOWN)
+		if e.cy != want.cy || e.cx != want.cx {
+			t.Errorf("Expected (%d,%d) moving down, got (%d,%d)", want.cy, want.cx, e.cy, e.cx)
+		}
+	}
+
+	for range 3 {
+		e.MoveCursor(ARROW_UP)
+	}
+	if e.cy != 1 || e.cx != 5 {
+		t.Errorf("Expected (1,5) after moving up, got (%d,%d)", e.cy, e.cx)
+	}
+	e.MoveCursor(ARROW_UP)
+	if e.cy != 0 || e.cx != 12 {
+		t.Errorf("Expected goal column restored at (0,12), got (%d,%d)", e.cy, e.cx)
+	}
+}
+
+func TestMoveCursorHorizontalResetsGoalColumn(t *testing.T) {
+	e := newTestEditor(10, 80, "a long line of text", "short", "a long line of text")
+	e.cx = 12
+
+	e.MoveCursor(ARROW_DOWN)
+	e.MoveCursor(ARROW_LEFT) // cx 4 on "short" becomes the new goal
+	e.MoveCursor(ARROW_DOWN)
+	if e.cy != 2 || e.cx != 4 {
+		t.Errorf("Expected (2,4) after horizontal move reset the goal, got (%d,%d)", e.cy, e.cx)
+	}
+}
+
+func TestOpenSaveKeepsMissingFinalNewline(t *testing.T) {
+	nl := getLineEnding()
+	tests := []string{
+		"first line" + nl + "second line",
+		"first line" + nl + "second line" + nl,
+		"single line",
+		nl,
+	}
+
+	for _, content := range tests {
+		path := filepath.Join(t.TempDir(), "file.txt")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		e := NewEditor()
+		if err := e.Open(path); err != nil {
+			t.Fatal(err)
+		}
+		e.Save()
+
+		saved, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(saved) != content {
+			t.Errorf("Expected saved content %q, got %q", content, saved)
+		}
+	}
+}
+
+func TestSaveUnnamedBuffer(t *testing.T) {
+	dir := t.TempDir()
+	e := newTestEditor(10, 80, "package main")
+	e.config = DefaultConfig()
+	e.output = newOutput(io.Discard)
+	e.dirty = 1
+
+	// Cancelling the name prompt leaves the buffer unnamed and unsaved
+	e.input = newInput(strings.NewReader("\x1b"))
+	e.Save()
+	if e.filename != "" || e.dirty == 0 || e.statusMessage != "Save aborted" {
+		t.Errorf("Expected an unnamed, unsaved buffer after cancelling, got %q, dirty %d, %q", e.filename, e.dirty, e.statusMessage)
+	}
+
+	// A failed write keeps the buffer unnamed too
+	missing := filepath.Join(dir, "missing", "main.go")
+	e.input = newInput(strings.NewReader(missing + "\r"))
+	e.Save()
+	if e.filename != "" || e.dirty == 0 || e.messagePriority != MESSAGE_ERROR {
+		t.Errorf("Expected an unnamed buffer and an error after a failed write, got %q: %q", e.filename, e.statusMessage)
+	}
+
+	path := filepath.Join(dir, "main.go")
+	e.input = newInput(strings.NewReader(path + "\r"))
+	e.Save()
+	if e.filename != path || e.dirty != 0 {
+		t.Fatalf("Expected the buffer saved as %q, got %q, dirty %d", path, e.filename, e.dirty)
+	}
+	if e.syntax == nil || e.row[0].hl[0] != HL_KEYWORD1 {
+		t.Errorf("Expected the rows highlighted as Go after naming the buffer")
+	}
+	if data, _ := os.ReadFile(path); !strings.HasPrefix(string(data), "package main") {
+		t.Errorf("Unexpected file content %q", data)
+	}
+}
+
+func TestSaveAs(t *testing.T) {
+	dir := t.TempDir()
+	original := filepath.Join(dir, "notes.txt")
+	other := filepath.Join(dir, "other.txt")
+	os.WriteFile(original, []byte("text\n"), 0644)
+	os.WriteFile(other, []byte("keep\n"), 0644)
+
+	e := newTestEditor(10, 80)
+	e.config = DefaultConfig()
+	e.output = newOutput(io.Discard)
+	e.Open(original)
+
+	// Declining to overwrite another file keeps the current name
+	e.input = newInput(strings.NewReader(other + "\rn"))
+	e.SaveAs()
+	if e.filename != original {
+		t.Errorf("Expected the name to stay %q, got %q", original, e.filename)
+	}
+	if data, _ := os.ReadFile(other); string(data) != "keep\n" {
+		t.Errorf("Expected %q to be left alone, got %q", other, data)
+	}
+
+	copied := filepath.Join(dir, "copy.txt")
+	e.input = newInput(strings.NewReader(copied + "\r"))
+	e.SaveAs()
+	if e.filename != copied || e.dirty != 0 {
+		t.Errorf("Expected the buffer to be saved as %q, got %q", copied, e.filename)
+	}
+	if data, _ := os.ReadFile(copied); string(data) != "text\n" {
+		t.Errorf("Unexpected content of the copy: %q", data)
+	}
+}
+
+func TestTrailingNewlineRow(t *testing.T) {
+	nl := getLineEnding()
+	tests := []struct {
+		content string
+		rows    int
+	}{
+		{"first" + nl + "second" + nl, 3},
+		{"first" + nl + "second", 2},
+		{nl, 2},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "file.txt")
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		e := NewEditor()
+		e.config.TrailingNewlineRow = true
+		if err := e.Open(path); err != nil {
+			t.Fatal(err)
+		}
+		if e.totalRows != tt.rows {
+			t.Errorf("Expected %d rows for %q, got %d", tt.rows, tt.content, e.totalRows)
+		}
+		if e.dirty != 0 {
+			t.Errorf("Expected clean buffer after open, got dirty %d", e.dirty)
+		}
+		e.Save()
+
+		saved, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(saved) != tt.content {
+			t.Errorf("Expected saved content %q, got %q", tt.content, saved)
+		}
+	}
+}
+
+func TestRenderColumnsLongLine(t *testing.T) {
+	line := strings.Repeat("\t\x01ab", LONG_LINE_LENGTH/2)
+	e := newTestEditor(10, 80, line)
+	row := &e.row[0]
+	if row.render != nil {
+		t.Fatalf("Expected long row to have no full render")
+	}
+
+	short := editorRow{chars: []byte(line[:40])}
+	short.Update(e)
+	if got, want := string(row.renderColumns(e, 3, 20)), string(short.render[3:20]); got != want {
+		t.Errorf("renderColumns(3, 20) = %q, expected %q", got, want)
+	}
+	if want := LONG_LINE_LENGTH * 4; row.renderWidth != want {
+		t.Errorf("Expected render width %d, got %d", want, row.renderWidth)
+	}
+}
+
+func TestControlCharacterCursorAlignment(t *testing.T) {
+	tests := []struct {
+		hex    bool
+		render string
+	}{
+		{false, "a^Ab^[c"},
+		{true, "a\\x01b\\x1bc"},
+	}
+	for _, tt := range tests {
+		e := newTestEditor(10, 80, "a\x01b\x1bc")
+		if tt.hex {
+			e.ToggleHexControl()
+		}
+		row := &e.row[0]
+		if got := string(row.render); got != tt.render {
+			t.Errorf("hex=%v: render = %q", tt.hex, got)
+		}
+
+		// Every rendered column maps back to the character it shows
+		for rx := range row.renderWidth {
+			cx := row.rxToCx(e, rx)
+			if start, end := row.cxToRx(e, cx), row.cxToRx(e, cx+1); rx < start || rx >= end {
+				t.Errorf("hex=%v: rxToCx(%d) = %d, which is shown in columns [%d, %d)", tt.hex, rx, cx, start, end)
+			}
+		}
+		if got, want := row.cxToRx(e, len(row.chars)), row.renderWidth; got != want {
+			t.Errorf("hex=%v: cxToRx at end of line = %d, expected %d", tt.hex, got, want)
+		}
+	}
+}
+
+func TestHexControlHighlighting(t *testing.T) {
+	e := newTestEditor(10, 80)
+	e.Load(strings.NewReader("x\x1b[31my\x02"), "main.go")
+	e.ToggleHexControl()
+	want := "x\\x1b[31my\\x02"
+	if got := string(e.row[0].render); got != want {
+		t.Fatalf("render = %q, expected %q", got, want)
+	}
+	for i, hl := range e.row[0].hl {
+		control := i >= 1 && i < 9 || i >= 10
+		if (hl == HL_CONTROL) != control {
+			t.Errorf("hl[%d] = %d, expected control highlighting: %v", i, hl, control)
+		}
+	}
+}
+
+func TestLongLineCommentState(t *testing.T) {
+	filler := strings.Repeat("x", LONG_LINE_LENGTH)
+	tests := []struct {
+		name    string
+		lines   []string
+		comment bool
+	}{
+		{"opens a comment", []string{filler + " /* open", "y"}, true},
+		{"closes a comment", []string{"/* open", filler + " */ x", "y"}, false},
+		{"comment marker in a string", []string{filler + ` "/*"`, "y"}, false},
+		{"comment marker after a line comment", []string{filler + " // /*", "y"}, false},
+		{"inside a comment", []string{"/* open", filler, "y"}, true},
+	}
+	for _, tt := range tests {
+		e := newTestEditor(10, 80)
+		e.Load(strings.NewReader(strings.Join(tt.lines, "\n")), "main.c")
+		last := e.row[len(tt.lines)-1]
+		if got := last.hl[0] == HL_MLCOMMENT; got != tt.comment {
+			t.Errorf("%s: expected the row after the long row in a comment to be %v", tt.name, tt.comment)
+		}
+	}
+}
+
+func TestLoadLongLine(t *testing.T) {
+	e := newTestEditor(24, 80)
+	e.config = DefaultConfig()
+	if err := e.Load(strings.NewReader(strings.Repeat("x", 100000)+"\nend\n"), "long.txt"); err != nil {
+		t.Fatalf("Expected the long line to be loaded, got %v", err)
+	}
+	if e.totalRows != 2 || e.row[0].renderWidth != 100000 || string(e.row[1].chars) != "end" {
+		t.Errorf("Expected a row of width 100000 and a row \"end\", got %d rows", e.totalRows)
+	}
+}
+
+func BenchmarkDrawLongLine(b *testing.B) {
+	e := newTestEditor(24, 80, strings.Repeat("x", 100000))
+	e.colOffset = 50000
+	for b.Loop() {
+		var abuf appendBuffer
+		e.DrawRows(&abuf)
+	}
+}
+
+func BenchmarkInsertCharLongLine(b *testing.B) {
+	e := newTestEditor(24, 80, strings.Repeat("x", 100000))
+	for b.Loop() {
+		e.cx = 50000
+		e.InsertChar('y')
+	}
+}
+
+func TestRowIndexAfterInsertAndDelete(t *testing.T) {
+	e := newTestEditor(10, 80, "a", "b", "c", "d")
+	e.InsertRow(0, []byte("new"), 3)
+	e.DeleteRow(2)
+
+	for i := range e.row {
+		if got := e.rowIndex(&e.row[i]); got != i {
+			t.Errorf("rowIndex of row %d (%q) = %d", i, e.row[i].chars, got)
+		}
+	}
+	if got := e.rowIndex(&editorRow{}); got != -1 {
+		t.Errorf("Expected -1 for a row outside the buffer, got %d", got)
+	}
+}
+
+func BenchmarkUpdateDetachedRow(b *testing.B) {
+	lines := make([]string, 100000)
+	for i := range lines {
+		lines[i] = "some text on a line"
+	}
+	e := newTestEditor(24, 80, lines...)
+	e.syntax = &HLDB_ENTRIES[0]
+	row := editorRow{chars: []byte("/* a row of a modal screen")}
+	for b.Loop() {
+		row.Update(e)
+	}
+}
+
+func BenchmarkInsertRowsAtStart(b *testing.B) {
+	lines := make([]string, 100000)
+	for i := range lines {
+		lines[i] = "some text on a line"
+	}
+	e := newTestEditor(24, 80, lines...)
+	line := []byte("inserted line")
+	for b.Loop() {
+		e.InsertRow(0, line, len(line))
+	}
+}
+
+func TestRefreshScreenCursorMoveSkipsRows(t *testing.T) {
+	e := newTestEditor(5, 40, "first line", "second line")
+	var out bytes.Buffer
+	e.output = newOutput(&out)
+	e.RefreshScreen()
+	if !strings.Contains(out.String(), "first line") {
+		t.Fatalf("Expected the first frame to contain the rows, got %q", out.String())
+	}
+
+	out.Reset()
+	e.MoveCursor(ARROW_RIGHT)
+	e.RefreshScreen()
+	if strings.Contains(out.String(), "line") {
+		t.Errorf("Expected only a cursor update after moving right, got %q", out.String())
+	}
+
+	out.Reset()
+	e.InsertChar('x')
+	e.RefreshScreen()
+	if !strings.Contains(out.String(), "fxirst line") {
+		t.Errorf("Expected the edited row to be redrawn, got %q", out.String())
+	}
+}
+
+func TestKeywordBoundaries(t *testing.T) {
+	tests := []struct {
+		filename, text string
+		col            int
+		expected       int
+		what           string
+	}{
+		{"main.c", "if(x) return;", 0, HL_KEYWORD1, "a keyword before a parenthesis"},
+		{"main.c", "return;", 0, HL_KEYWORD1, "a keyword before a semicolon"},
+		{"main.c", "} else{", 2, HL_KEYWORD1, "a keyword before a brace"},
+		{"main.c", "case 1: break;", 0, HL_KEYWORD1, "a case label"},
+		{"main.c", "int*p;", 0, HL_KEYWORD2, "a type before a pointer"},
+		{"main.c", "format(x);", 0, HL_NORMAL, "a word starting with a keyword"},
+		{"main.go", "default:", 0, HL_KEYWORD1, "a keyword before a colon"},
+		{"main.go", "var s struct{}", 6, HL_KEYWORD1, "a keyword before a brace"},
+		{"main.go", "var v interface{}", 6, HL_KEYWORD2, "a type keyword before a brace"},
+		{"main.go", "x := ok!", 5, HL_NORMAL, "a non-keyword word"},
+		{"main.go", "formatted := 1", 0, HL_NORMAL, "a word starting with a keyword"},
+		{"Makefile", "ifeq ($(A),)", 0, HL_KEYWORD1, "a conditional"},
+		{"Makefile", "endif", 0, HL_KEYWORD1, "a keyword at the end of the line"},
+		{"Makefile", "includes = a", 0, HL_NORMAL, "a word starting with a keyword"},
+		{"Makefile", "\tcp $< out", TAB_STOP + 3, HL_KEYWORD2, "an automatic variable"},
+		{"Makefile", "\techo $@", TAB_STOP + 5, HL_KEYWORD2, "an automatic variable at the end of the line"},
+	}
+	for _, tt := range tests {
+		e := newTestEditor(10, 80)
+		e.config = DefaultConfig()
+		e.Load(strings.NewReader(tt.text), tt.filename)
+		if got := e.row[0].hl[tt.col]; got != tt.expected {
+			t.Errorf("%s: expected %s in %q to be highlighted as %d, got %d", tt.filename, tt.what, tt.text, tt.expected, got)
+		}
+	}
+}
+
+func TestGoModSyntax(t *testing.T) {
+	e := newTestEditor(10, 80, "require golang.org/x/term v0.1.0-pre // indirect")
+	e.filename = "go.mod"
+	e.SelectSyntaxHighlight()
+	if e.syntax == nil || e.syntax.filetype != "go.mod" {
+		t.Fatalf("Expected go.mod syntax, got %+v", e.syntax)
+	}
+
+	hl := e.row[0].hl
+	line := string(e.row[0].chars)
+	expectations := []struct {
+		at       int
+		expected int
+	}{
+		{0, HL_KEYWORD1}, // require
+		{strings.Index(line, "golang"), HL_NORMAL}, // no "go" keyword inside words
+		{strings.Index(line, "v0.1.0"), HL_NUMBER}, // version
+		{strings.Index(line, "-pre"), HL_NUMBER},   // version suffix
+		{strings.Index(line, "// indirect"), HL_COMMENT},
+	}
+	for _, tt := range expectations {
+		if hl[tt.at] != tt.expected {
+			t.Errorf("hl at %d (%q) = %d, expected %d", tt.at, line[tt.at:], hl[tt.at], tt.expected)
+		}
+	}
+}
+
+func TestMatchesFilename(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		filename string
+		expected bool
+	}{
+		{".go", "main.go", true},
+		{".go", "dir.go/main.c", false},
+		{"Dockerfile", "Dockerfile", true},
+		{"Dockerfile", "build/Dockerfile", true},
+		{"Dockerfile", "mydockerfile.txt", false},
+		{"Dockerfile", "Dockerfile.dev", false},
+		{"Dockerfile.*", "Dockerfile.dev", true},
+		{"*.mk", "rules.mk", true},
+		{"Makefile", "Makefile.bak", false},
+	}
+	for _, tt := range tests {
+		if actual := matchesFilename(tt.pattern, tt.filename); actual != tt.expected {
+			t.Errorf("matchesFilename(%q, %q) = %v, expected %v", tt.pattern, tt.filename, actual, tt.expected)
+		}
+	}
+}
+
+func TestSelectSyntaxHighlightByName(t *testing.T) {
+	tests := map[string]string{
+		"Dockerfile":       "dockerfile",
+		"Makefile":         "makefile",
+		"go.mod":           "go.mod",
+		"main.go":          "go",
+		"mydockerfile.txt": "",
+	}
+	for filename, expected := range tests {
+		e := newTestEditor(10, 80)
+		e.filename = filename
+		e.SelectSyntaxHighlight()
+		actual := ""
+		if e.syntax != nil {
+			actual = e.syntax.filetype
+		}
+		if actual != expected {
+			t.Errorf("%s: expected filetype %q, got %q", filename, expected, actual)
+		}
+	}
+}
+
+func TestToggleSyntaxHighlight(t *testing.T) {
+	e := newTestEditor(10, 80)
+	e.Load(strings.NewReader("package main"), "main.go")
+
+	e.ToggleSyntaxHighlight()
+	if e.syntax != nil || e.row[0].hl[0] != HL_NORMAL {
+		t.Errorf("Expected no highlighting after toggling it off, got %d", e.row[0].hl[0])
+	}
+
+	var abuf appendBuffer
+	e.screenCols = 120
+	e.DrawStatusBar(&abuf)
+	if !strings.Contains(string(abuf.b), "go/off") {
+		t.Errorf("Expected the switched off filetype in the status bar, got %q", abuf.b)
+	}
+
+	// Highlighting stays off when the file is renamed
+	e.filename = "other.c"
+	e.SelectSyntaxHighlight()
+	if e.syntax != nil || e.detectedSyntax == nil || e.detectedSyntax.filetype != "c" {
+		t.Errorf("Expected highlighting to stay off after reselecting the syntax")
+	}
+	e.filename = "main.go"
+
+	e.ToggleSyntaxHighlight()
+	if e.syntax == nil || e.row[0].hl[0] != HL_KEYWORD1 {
+		t.Errorf("Expected Go highlighting after toggling it on, got %d", e.row[0].hl[0])
+	}
+}
+
+func TestNumberHighlighting(t *testing.T) {
+	tests := []struct {
+		line   string
+		number string // the part highlighted as a number, starting at column 4
+	}{
+		{"x = 1_000_000;", "1_000_000"},
+		{"x = 0xFF_ff;", "0xFF_ff"},
+		{"x = 0b1010;", "0b1010"},
+		{"x = 0o755;", "0o755"},
+		{"x = 1e-9;", "1e-9"},
+		{"x = 6.02E+23;", "6.02E+23"},
+		{"x = 3.14;", "3.14"},
+		{"x = 0x;", "0"},
+		{"x = a1;", ""},
+	}
+	for _, tt := range tests {
+		e := newTestEditor(10, 80, tt.line)
+		e.filename = "test.go"
+		e.SelectSyntaxHighlight()
+		hl := e.row[0].hl
+		for k := range tt.line {
+			expected := k >= 4 && k < 4+len(tt.number)
+			if (hl[k] == HL_NUMBER) != expected {
+				t.Errorf("%q: column %d highlighted as number = %v, expected %v", tt.line, k, hl[k] == HL_NUMBER, expected)
+			}
+		}
+	}
+}
+
+func TestPromptUnicodeEditing(t *testing.T) {
+	e := newTestEditor(10, 80)
+	e.output = newOutput(io.Discard)
+	// Type "aé", delete the "é", then type "ñ€" and confirm
+	e.input = newInput(strings.NewReader("aé\x7fñ€\r"))
+
+	var seen []string
+	result := e.Prompt("Input: %s", func(query []byte, key int) {
+		if !utf8.Valid(query) {
+			t.Errorf("Callback got invalid UTF-8: %q", query)
+		}
+		seen = append(seen, string(query))
+	})
+
+	if result != "añ€" {
+		t.Errorf("Expected %q, got %q", "añ€", result)
+	}
+	expected := []string{"a", "aé", "a", "añ", "añ€", "añ€"}
+	if !slices.Equal(seen, expected) {
+		t.Errorf("Expected callback inputs %q, got %q", expected, seen)
+	}
+}
+
+var ansiSequence = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+func TestRefreshScreenTinyTerminals(t *testing.T) {
+	for _, scrollbar := range []bool{false, true} {
+		for rows := range 4 {
+			for cols := range 11 {
+				for _, lines := range [][]string{nil, {"a line that is longer than the screen", "\tx", ""}} {
+					e := newTestEditor(0, 0, lines...)
+					e.config.ShowScrollbar = scrollbar
+					e.setScreenSize(rows, cols)
+					e.output = newOutput(io.Discard)
+					e.filename = "a rather long file name.go"
+					e.SetStatusMessage("a status message wider than the screen")
+					e.RefreshScreen()
+
+					for i, line := range e.frame.lines {
+						visible := ansiSequence.ReplaceAllString(strings.TrimSuffix(string(line), "\r\n"), "")
+						if w := displayWidth(visible); w > cols {
+							t.Errorf("%dx%d (scrollbar %v): line %d is %d columns wide: %q", cols, rows, scrollbar, i, w, visible)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestOpenDirectory(t *testing.T) {
+	e := newTestEditor(10, 80, "kept")
+	err := e.Open(t.TempDir())
+	if !errors.Is(err, ErrIsDirectory) {
+		t.Fatalf("Expected ErrIsDirectory, got %v", err)
+	}
+	if e.Text() != "kept" || e.filename != "" {
+		t.Errorf("Expected the buffer to be left alone, got %q named %q", e.Text(), e.filename)
+	}
+}
+
+func TestOpenPermissionDenied(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can read files without permission")
+	}
+	path := filepath.Join(t.TempDir(), "secret.txt")
+	os.WriteFile(path, []byte("x"), 0)
+
+	e := newTestEditor(10, 80)
+	if err := e.Open(path); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Errorf("Expected a permission error, got %v", err)
+	}
+}
+
+func TestOpenStdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+	w.WriteString("piped\ncontent\n")
+	w.Close()
+
+	e := newTestEditor(10, 80)
+	if err := e.OpenStdin(); err != nil {
+		t.Fatalf("OpenStdin returned %v", err)
+	}
+	if e.totalRows != 2 || string(e.row[1].chars) != "content" || e.filename != "" || e.dirty != 0 {
+		t.Errorf("Unexpected buffer after OpenStdin: %d rows, filename %q, dirty %d", e.totalRows, e.filename, e.dirty)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	e := newTestEditor(10, 80, "old")
+	if err := e.Load(strings.NewReader("package main\r\n\r\nfunc main() {}"), "main.go"); err != nil {
+		t.Fatalf("Load returned %v", err)
+	}
+	if e.totalRows != 3 || string(e.row[0].chars) != "package main" || string(e.row[1].chars) != "" {
+		t.Errorf("Unexpected rows after Load: %d rows", e.totalRows)
+	}
+	if e.filename != "main.go" || e.syntax == nil || e.syntax.filetype != "go" {
+		t.Errorf("Expected Go syntax for main.go, got filename %q", e.filename)
+	}
+	if e.finalNewline || e.dirty != 0 {
+		t.Errorf("Expected no final newline and a clean buffer, got %v and %d", e.finalNewline, e.dirty)
+	}
+	if e.row[0].hl[0] != HL_KEYWORD1 {
+		t.Errorf("Expected 'package' to be highlighted as a keyword, got %d", e.row[0].hl[0])
+	}
+}
+
+func TestLoadFinalNewline(t *testing.T) {
+	e := newTestEditor(10, 80)
+	if err := e.Load(strings.NewReader("a\nb\n"), "notes"); err != nil {
+		t.Fatalf("Load returned %v", err)
+	}
+	if e.totalRows != 2 || !e.finalNewline || e.syntax != nil {
+		t.Errorf("Unexpected buffer after Load: %d rows, final newline %v", e.totalRows, e.finalNewline)
+	}
+	content, _ := e.RowsToString()
+	if !strings.HasPrefix(string(content), "a") || !strings.HasSuffix(string(content), "b"+e.lineEndingFor()) {
+		t.Errorf("Unexpected content after Load: %q", content)
+	}
+}
+
+func TestLoadByteOrderMark(t *testing.T) {
+	e := newTestEditor(10, 80)
+	if err := e.Load(strings.NewReader(UTF8_BOM+"package main\n"), "main.go"); err != nil {
+		t.Fatalf("Load returned %v", err)
+	}
+	if got := e.Lines(); !slices.Equal(got, []string{"package main"}) || !e.bom {
+		t.Errorf("Expected the byte order mark to be stripped and remembered, got %q", got)
+	}
+	if e.row[0].hl[0] != HL_KEYWORD1 {
+		t.Errorf("Expected the first word to be highlighted as a keyword, got %d", e.row[0].hl[0])
+	}
+	if content, _ := e.RowsToString(); string(content) != UTF8_BOM+"package main"+e.lineEndingFor() {
+		t.Errorf("Expected the byte order mark to be written back, got %q", content)
+	}
+	if strings.HasPrefix(e.Text(), UTF8_BOM) {
+		t.Errorf("Expected no byte order mark in the text")
+	}
+
+	e.Load(strings.NewReader("text"), "notes")
+	if content, _ := e.RowsToString(); e.bom || string(content) != "text" {
+		t.Errorf("Expected no byte order mark for a file without one, got %q", content)
+	}
+}
+
+func TestLoadLineEndings(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		lineEnding string
+	}{
+		{"LF", "a\nb\n", "\n"},
+		{"CRLF", "a\r\nb\r\n", "\r\n"},
+		{"CR", "a\rb\r", "\r"},
+		{"mixed", "a\r\nb\nc\rd\r\n", "\r\n"},
+		{"none", "a", ""},
+	}
+	for _, tt := range tests {
+		e := newTestEditor(10, 80)
+		if err := e.Load(strings.NewReader(tt.content), "notes"); err != nil {
+			t.Fatalf("%s: Load returned %v", tt.name, err)
+		}
+		want := []string{"a", "b"}
+		switch tt.name {
+		case "mixed":
+			want = []string{"a", "b", "c", "d"}
+		case "none":
+			want = []string{"a"}
+		}
+		if got := e.Lines(); !slices.Equal(got, want) {
+			t.Errorf("%s: expected lines %q, got %q", tt.name, want, got)
+		}
+		if e.lineEnding != tt.lineEnding {
+			t.Errorf("%s: expected line ending %q, got %q", tt.name, tt.lineEnding, e.lineEnding)
+		}
+		if e.finalNewline != (tt.name != "none") {
+			t.Errorf("%s: unexpected final newline %v", tt.name, e.finalNewline)
+		}
+	}
+}
+
+func TestLoadCRLFAcrossReads(t *testing.T) {
+	// The CR and LF of a line ending arriving in separate reads are still one line ending
+	e := newTestEditor(10, 80)
+	r := io.MultiReader(strings.NewReader("a\r"), strings.NewReader("\nb\r"), strings.NewReader("\r\n"))
+	if err := e.Load(r, "notes"); err != nil {
+		t.Fatalf("Load returned %v", err)
+	}
+	if got := e.Lines(); !slices.Equal(got, []string{"a", "b", ""}) {
+		t.Errorf("Unexpected lines %q", got)
+	}
+}
+
+func TestTextRoundTrip(t *testing.T) {
+	for _, content := range []string{"", "one", "one\n", "one\ntwo\n\nthree\n"} {
+		e := newTestEditor(10, 80)
+		if err := e.Load(strings.NewReader(content), "file.txt"); err != nil {
+			t.Fatalf("Load returned %v", err)
+		}
+		e.lineEnding = "\n"
+		if got := e.Text(); got != content {
+			t.Errorf("Text() after loading %q = %q", content, got)
+		}
+	}
+}
+
+func TestTextReflectsEdits(t *testing.T) {
+	e := newTestEditor(10, 80)
+	if e.Text() != "" || len(e.Lines()) != 0 {
+		t.Errorf("Expected an empty buffer, got %q", e.Text())
+	}
+
+	e.Load(strings.NewReader("ab\ncd"), "file.txt")
+	e.lineEnding = "\r\n"
+	e.InsertChar('x')
+	if got := e.Text(); got != "xab\r\ncd" {
+		t.Errorf("Expected unsaved edits in the text, got %q", got)
+	}
+	if got := e.Lines(); !slices.Equal(got, []string{"xab", "cd"}) {
+		t.Errorf("Unexpected lines %q", got)
+	}
+}
+
+func TestErrorMessagePriority(t *testing.T) {
+	e := newTestEditor(10, 80)
+	e.config = DefaultConfig()
+
+	e.ShowError("disk full")
+	e.SetStatusMessage("Saved")
+	if e.statusMessage != "Error: disk full" {
+		t.Errorf("Expected the error to stay shown, got %q", e.statusMessage)
+	}
+
+	// After a keypress the error can be replaced
+	e.acknowledgeMessage()
+	e.SetStatusMessage("Saved")
+	if e.statusMessage != "Saved" {
+		t.Errorf("Expected the message to be replaced, got %q", e.statusMessage)
+	}
+
+	// Expired errors don't hold back new messages
+	e.ShowError("disk full")
+	e.statusMessageTime = time.Now().Add(-e.config.ErrorTimeout)
+	e.SetStatusMessage("Saved")
+	if e.statusMessage != "Saved" {
+		t.Errorf("Expected an expired error to be replaced, got %q", e.statusMessage)
+	}
+}
+
+func TestStickyErrors(t *testing.T) {
+	e := newTestEditor(10, 80)
+	e.config = DefaultConfig()
+	e.config.StickyErrors = true
+
+	e.ShowError("disk full")
+	e.statusMessageTime = time.Now().Add(-time.Hour)
+	if !e.messageVisible() {
+		t.Errorf("Expected a sticky error to stay visible")
+	}
+	e.acknowledgeMessage()
+	if e.messageVisible() {
+		t.Errorf("Expected a sticky error to disappear after a keypress")
+	}
+
+	e.SetStatusMessage("Saved")
+	e.statusMessageTime = time.Now().Add(-e.config.MessageTimeout)
+	if e.messageVisible() {
+		t.Errorf("Expected an ordinary message to time out")
+	}
+}
+
+func TestDrawMessageBarSeverity(t *testing.T) {
+	e := newTestEditor(10, 80)
+	e.config = DefaultConfig()
+	tests := []struct {
+		show     func(format string, args ...any)
+		expected string
+	}{
+		{e.SetStatusMessage, CLEAR_LINE + "saved"},
+		{e.ShowWarning, CLEAR_LINE + "\x1b[33mWarn: saved\x1b[39m"},
+		{e.ShowError, CLEAR_LINE + "\x1b[31mError: saved\x1b[39m"},
+	}
+	for _, tt := range tests {
+		e.acknowledgeMessage()
+		tt.show("saved")
+		var abuf appendBuffer
+		e.DrawMessageBar(&abuf)
+		if string(abuf.b) != tt.expected {
+			t.Errorf("Expected message bar %q, got %q", tt.expected, abuf.b)
+		}
+	}
+}
+
+func TestRenderColoredRow(t *testing.T) {
+	// Expected output as produced by the coloring inlined in DrawRows before
+	tests := []struct {
+		render   string
+		hl       []int
+		expected string
+	}{
+		{"if x", []int{HL_KEYWORD1, HL_KEYWORD1, HL_NORMAL, HL_NORMAL}, "\x1b[33mif\x1b[39m x\x1b[39m"},
+		{"ab", []int{HL_MATCH, HL_SELECTION}, "\x1b[7m\x1b[34ma\x1b[39mb\x1b[39m\x1b[27m"},
+		{"a\"s\"", []int{HL_NORMAL, HL_STRING, HL_STRING, HL_STRING}, "a\x1b[35m\"s\"\x1b[39m"},
+		{"w1", []int{HL_WORD, HL_NUMBER}, "\x1b[4m\x1b[39mw\x1b[24m\x1b[31m1\x1b[39m"},
+		{"xyz", nil, "xyz\x1b[39m"},
+	}
+	for _, tt := range tests {
+		got := renderColoredRow([]byte(tt.render), tt.hl, 0, len(tt.render), defaultTheme)
+		if string(got) != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.render, tt.expected, got)
+		}
+	}
+}
+
+func TestRenderColoredRowRangeAndTheme(t *testing.T) {
+	render := []byte("if x")
+	hl := []int{HL_KEYWORD1, HL_KEYWORD1, HL_NORMAL, HL_NORMAL}
+	if got := string(renderColoredRow(render, hl, 1, 3, defaultTheme)); got != "\x1b[33mf\x1b[39m \x1b[39m" {
+		t.Errorf("Unexpected output for columns 1-3: %q", got)
+	}
+
+	bold := func(hl int) (int, int) { return ANSI_COLOR_DEFAULT, ANSI_BOLD }
+	if got := string(renderColoredRow(render, hl, 0, 2, bold)); got != "\x1b[1m\x1b[39mif\x1b[39m\x1b[22m" {
+		t.Errorf("Unexpected output with a custom theme: %q", got)
+	}
+}
+
+func TestRowDirtyTracking(t *testing.T) {
+	e := newTestEditor(5, 80, "one", "two", "three")
+	e.output = newOutput(io.Discard)
+	e.RefreshScreen()
+	for i, row := range e.row {
+		if row.dirty {
+			t.Errorf("Expected row %d to be clean after drawing", i)
+		}
+	}
+
+	e.cy, e.cx = 1, 3
+	e.InsertChar('!')
+	if !e.row[1].dirty || e.row[0].dirty || e.row[2].dirty {
+		t.Errorf("Expected only the edited row to be dirty")
+	}
+
+	// Clean rows are copied from the last frame instead of being drawn again
+	e.frame.lines[0] = []byte("stale\r\n")
+	e.RefreshScreen()
+	if got := string(e.frame.lines[0]); got != "stale\r\n" {
+		t.Errorf("Expected the clean row to be reused, got %q", got)
+	}
+	if got := string(e.frame.lines[1]); !strings.HasPrefix(got, "two!") {
+		t.Errorf("Expected the edited row to be drawn again, got %q", got)
+	}
+
+	// Rows below an inserted row move to other screen lines
+	e.InsertRow(1, []byte("new"), 3)
+	if !e.row[2].dirty || !e.row[3].dirty || e.row[0].dirty {
+		t.Errorf("Expected the rows from the inserted one on to be dirty")
+	}
+
+	// Scrolling draws every row again
+	e.cy, e.cx = 3, 0
+	e.RefreshScreen()
+	e.frame.lines[0] = []byte("stale\r\n")
+	e.rowOffset = 1
+	e.RefreshScreen()
+	if got := string(e.frame.lines[0]); !strings.HasPrefix(got, "new") {
+		t.Errorf("Expected the rows to be drawn again after scrolling, got %q", got)
+	}
+}
@@ -0,0 +1,225 @@
+package editor
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// EDITORCONFIG_FILENAME is the name of the files holding per-directory editor settings
+const EDITORCONFIG_FILENAME = ".editorconfig"
+
+// editorConfigSection is a glob with the properties that apply to files matching it
+type editorConfigSection struct {
+	glob       string
+	properties map[string]string
+}
+
+// editorConfigFile is a parsed .editorconfig file
+type editorConfigFile struct {
+	dir      string // directory the file is in, globs are relative to it
+	root     bool   // stop searching parent directories
+	sections []editorConfigSection
+}
+
+// parseEditorConfig reads an INI-style .editorconfig file.
+// Keys and values are lowercased, comments start with '#' or ';'.
+func parseEditorConfig(r io.Reader, dir string) (editorConfigFile, error) {
+	file := editorConfigFile{dir: dir}
+	var section *editorConfigSection
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			file.sections = append(file.sections, editorConfigSection{
+				glob:       line[1 : len(line)-1],
+				properties: map[string]string{},
+			})
+			section = &file.sections[len(file.sections)-1]
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.ToLower(strings.TrimSpace(value))
+
+		if section == nil {
+			if key == "root" {
+				file.root = value == "true"
+			}
+			continue
+		}
+		section.properties[key] = value
+	}
+	return file, scanner.Err()
+}
+
+// editorConfigGlobToRegexp translates an .editorconfig glob into a regular expression
+// matching slash-separated paths relative to the .editorconfig file.
+// Supported are *, **, ?, [chars], [!chars] and {alt1,alt2}.
+func editorConfigGlobToRegexp(glob string) (*regexp.Regexp, error) {
+	var re strings.Builder
+	if strings.Contains(glob, "/") {
+		glob = strings.TrimPrefix(glob, "/")
+	} else {
+		re.WriteString("(?:.*/)?") // Globs without a slash match in any directory
+	}
+
+	braceDepth := 0
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '*' && i+1 < len(glob) && glob[i+1] == '*':
+			re.WriteString(".*")
+			i++
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end == -1 {
+				re.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + class + "]")
+			i += end
+		case c == '{':
+			re.WriteString("(?:")
+			braceDepth++
+		case c == '}' && braceDepth > 0:
+			re.WriteString(")")
+			braceDepth--
+		case c == ',' && braceDepth > 0:
+			re.WriteString("|")
+		case c == '\\' && i+1 < len(glob):
+			re.WriteString(regexp.QuoteMeta(string(glob[i+1])))
+			i++
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return regexp.Compile("^" + re.String() + "$")
+}
+
+// editorConfigProperties collects the .editorconfig properties for the given file,
+// reading .editorconfig files from the file's directory upwards until a root file.
+// Properties from files closer to the file take precedence.
+func editorConfigProperties(filename string) map[string]string {
+	path, err := filepath.Abs(filename)
+	if err != nil {
+		return nil
+	}
+
+	var files []editorConfigFile
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		f, err := os.Open(filepath.Join(dir, EDITORCONFIG_FILENAME))
+		if err == nil {
+			parsed, err := parseEditorConfig(f, dir)
+			f.Close()
+			if err == nil {
+				files = append(files, parsed)
+				if parsed.root {
+					break
+				}
+			}
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	properties := map[string]string{}
+	for i := len(files) - 1; i >= 0; i-- {
+		rel, err := filepath.Rel(files[i].dir, path)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for _, section := range files[i].sections {
+			re, err := editorConfigGlobToRegexp(section.glob)
+			if err != nil || !re.MatchString(rel) {
+				continue
+			}
+			for key, value := range section.properties {
+				properties[key] = value
+			}
+		}
+	}
+	return properties
+}
+
+// applyEditorConfig applies the .editorconfig settings for filename to the current buffer.
+// Honored keys are indent_style, indent_size, tab_width, end_of_line,
+// trim_trailing_whitespace and insert_final_newline. Other keys are ignored.
+func (e *Editor) applyEditorConfig(filename string) {
+	properties := editorConfigProperties(filename)
+	if len(properties) == 0 {
+		return
+	}
+
+	switch properties["indent_style"] {
+	case "tab":
+		e.indent.expandTab = false
+	case "space":
+		e.indent.expandTab = true
+	}
+	e.requireTabs()
+
+	width, err := strconv.Atoi(properties["tab_width"])
+	if err != nil || width <= 0 {
+		width, err = strconv.Atoi(properties["indent_size"]) // tab_width defaults to indent_size
+	}
+	if err == nil && width > 0 {
+		e.tabSize = width
+	}
+	if size, err := strconv.Atoi(properties["indent_size"]); err == nil && size > 0 {
+		e.indent.width = size
+	} else if properties["indent_size"] == "tab" {
+		e.indent.width = e.tabStop()
+	}
+
+	switch properties["end_of_line"] {
+	case "lf":
+		e.lineEnding = "\n"
+	case "crlf":
+		e.lineEnding = "\r\n"
+	case "cr":
+		e.lineEnding = "\r"
+	}
+
+	switch properties["trim_trailing_whitespace"] {
+	case "true":
+		e.trimTrailingSpace = true
+	case "false":
+		e.trimTrailingSpace = false
+	}
+
+	switch properties["insert_final_newline"] {
+	case "true":
+		e.insertFinalNewline = true
+	case "false":
+		e.insertFinalNewline = false
+	}
+
+	// The tab width affects how every row is rendered
+	for i := range e.row {
+		e.row[i].Update(e)
+	}
+}
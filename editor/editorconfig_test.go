@@ -0,0 +1,104 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEditorConfigGlob(t *testing.T) {
+	tests := []struct {
+		glob     string
+		path     string
+		expected bool
+	}{
+		{"*", "main.go", true},
+		{"*", "editor/editor.go", true},
+		{"*.go", "editor/editor.go", true},
+		{"*.go", "go.mod", false},
+		{"*.{c,h}", "src/main.h", true},
+		{"Makefile", "sub/Makefile", true},
+		{"/Makefile", "sub/Makefile", false},
+		{"lib/**.js", "lib/a/b/c.js", true},
+		{"lib/*.js", "lib/a/c.js", false},
+		{"file?.txt", "file1.txt", true},
+		{"[!a]*.txt", "abc.txt", false},
+	}
+
+	for _, tt := range tests {
+		re, err := editorConfigGlobToRegexp(tt.glob)
+		if err != nil {
+			t.Fatalf("glob %q: %v", tt.glob, err)
+		}
+		if actual := re.MatchString(tt.path); actual != tt.expected {
+			t.Errorf("glob %q matching %q = %v, expected %v", tt.glob, tt.path, actual, tt.expected)
+		}
+	}
+}
+
+func TestParseEditorConfig(t *testing.T) {
+	input := `# top-most file
+root = true
+
+[*]
+Indent_Style = Space
+indent_size = 2
+
+; comment
+[Makefile]
+indent_style = tab
+`
+	file, err := parseEditorConfig(strings.NewReader(input), "/project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !file.root {
+		t.Errorf("Expected root = true")
+	}
+	if len(file.sections) != 2 {
+		t.Fatalf("Expected 2 sections, got %d", len(file.sections))
+	}
+	if file.sections[0].properties["indent_style"] != "space" || file.sections[0].properties["indent_size"] != "2" {
+		t.Errorf("Unexpected properties for [*]: %v", file.sections[0].properties)
+	}
+	if file.sections[1].glob != "Makefile" || file.sections[1].properties["indent_style"] != "tab" {
+		t.Errorf("Unexpected section %+v", file.sections[1])
+	}
+}
+
+func TestApplyEditorConfigTabWidth(t *testing.T) {
+	tests := []struct {
+		properties string
+		want       int
+	}{
+		{"indent_size = 2", 2},
+		{"indent_size = 2\ntab_width = 8", 8},
+		{"indent_size = tab", TAB_STOP},
+		{"indent_style = tab", TAB_STOP},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, ".editorconfig"), []byte("root = true\n[*]\n"+tt.properties+"\n"), 0644)
+		path := filepath.Join(dir, "file.txt")
+		os.WriteFile(path, []byte("\tx\n"), 0644)
+
+		e := newTestEditor(10, 80)
+		e.config = DefaultConfig()
+		if err := e.Open(path); err != nil {
+			t.Fatal(err)
+		}
+		if got := e.tabStop(); got != tt.want {
+			t.Errorf("%q: expected a tab width of %d, got %d", tt.properties, tt.want, got)
+		}
+	}
+}
+
+func TestNewEditorBufferSettings(t *testing.T) {
+	e := NewEditor()
+	if e.tabSize != e.config.TabStop || e.insertFinalNewline != e.config.InsertFinalNewline ||
+		e.encoding != ENCODING_UTF8 {
+		t.Errorf("Expected the buffer settings from the config, got tab size %d, final newline %v, encoding %q",
+			e.tabSize, e.insertFinalNewline, e.encoding)
+	}
+}
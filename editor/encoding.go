@@ -0,0 +1,135 @@
+package editor
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Supported file encodings. Rows always hold UTF-8, other encodings are
+// converted when a file is read and written.
+const (
+	ENCODING_UTF8        = "utf-8"
+	ENCODING_LATIN1      = "latin-1"
+	ENCODING_WINDOWS1252 = "windows-1252"
+)
+
+var encodingNames = []string{ENCODING_UTF8, ENCODING_LATIN1, ENCODING_WINDOWS1252}
+
+// windows1252 maps the bytes 0x80-0x9f, the only ones that differ from Latin-1.
+// Undefined bytes map to the C1 control character of the same value.
+var windows1252 = [32]rune{
+	0x20ac, 0x0081, 0x201a, 0x0192, 0x201e, 0x2026, 0x2020, 0x2021,
+	0x02c6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008d, 0x017d, 0x008f,
+	0x0090, 0x2018, 0x2019, 0x201c, 0x201d, 0x2022, 0x2013, 0x2014,
+	0x02dc, 0x2122, 0x0161, 0x203a, 0x0153, 0x009d, 0x017e, 0x0178,
+}
+
+// decodeText converts text in the given encoding to UTF-8
+func decodeText(raw []byte, encoding string) []byte {
+	if encoding == ENCODING_UTF8 {
+		return raw
+	}
+	out := make([]byte, 0, len(raw))
+	for _, b := range raw {
+		r := rune(b)
+		if encoding == ENCODING_WINDOWS1252 && b >= 0x80 && b <= 0x9f {
+			r = windows1252[b-0x80]
+		}
+		out = utf8.AppendRune(out, r)
+	}
+	return out
+}
+
+// encodeRune returns the byte for r in a single byte encoding
+func encodeRune(r rune, encoding string) (byte, bool) {
+	if r < 0x80 || (r >= 0xa0 && r <= 0xff) || (encoding == ENCODING_LATIN1 && r <= 0xff) {
+		return byte(r), true
+	}
+	if i := slices.Index(windows1252[:], r); i >= 0 && encoding == ENCODING_WINDOWS1252 {
+		return byte(0x80 + i), true
+	}
+	return 0, false
+}
+
+// encodeText converts UTF-8 text to the given encoding. Characters the encoding can't
+// represent are written as '?' and counted. Invalid UTF-8 bytes are kept as they are.
+func encodeText(text []byte, encoding string) ([]byte, int) {
+	if encoding == ENCODING_UTF8 {
+		return text, 0
+	}
+	out := make([]byte, 0, len(text))
+	lost := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRune(text[i:])
+		if r == utf8.RuneError && size == 1 {
+			out = append(out, text[i])
+		} else if b, ok := encodeRune(r, encoding); ok {
+			out = append(out, b)
+		} else {
+			out = append(out, '?')
+			lost++
+		}
+		i += size
+	}
+	return out, lost
+}
+
+// fileEncoding returns the encoding of the current buffer
+func (e *Editor) fileEncoding() string {
+	if e.encoding == "" {
+		return ENCODING_UTF8
+	}
+	return e.encoding
+}
+
+// isValidUTF8 reports whether all rows are valid UTF-8
+func (e *Editor) isValidUTF8() bool {
+	for i := range e.row {
+		if !utf8.Valid(e.row[i].chars) {
+			return false
+		}
+	}
+	return true
+}
+
+// unencodableChars counts the characters the buffer's encoding can't represent
+func (e *Editor) unencodableChars() int {
+	lost := 0
+	for i := range e.row {
+		_, n := encodeText(e.row[i].chars, e.fileEncoding())
+		lost += n
+	}
+	return lost
+}
+
+// SetEncoding reinterprets the bytes of the file in another encoding.
+// The bytes saved to disk stay the same until the text is edited.
+func (e *Editor) SetEncoding(encoding string) bool {
+	if !slices.Contains(encodingNames, encoding) {
+		return false
+	}
+	for i := range e.row {
+		row := &e.row[i]
+		raw, _ := encodeText(row.chars, e.fileEncoding())
+		row.chars = slices.Clone(decodeText(raw, encoding))
+		row.Update(e)
+	}
+	e.encoding = encoding
+	e.resetUndo() // The recorded changes hold text decoded in the previous encoding
+	return true
+}
+
+// ChangeEncodingPrompt asks for an encoding to reinterpret the file in
+func (e *Editor) ChangeEncodingPrompt() {
+	input := e.Prompt("Encoding ("+strings.Join(encodingNames, ", ")+"): %s (ESC to cancel)", nil)
+	if input == "" {
+		return
+	}
+	encoding := strings.ToLower(strings.TrimSpace(input))
+	if !e.SetEncoding(encoding) {
+		e.ShowError("Unknown encoding: %s", input)
+		return
+	}
+	e.SetStatusMessage("Reading file as %s", encoding)
+}
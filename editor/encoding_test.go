@@ -0,0 +1,47 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncodingRoundTrip(t *testing.T) {
+	raw := []byte("caf\xe9 \x80 \x81")
+	for _, encoding := range []string{ENCODING_LATIN1, ENCODING_WINDOWS1252} {
+		text := decodeText(raw, encoding)
+		encoded, lost := encodeText(text, encoding)
+		if string(encoded) != string(raw) || lost != 0 {
+			t.Errorf("%s: round trip gave %q (%d lost), expected %q", encoding, encoded, lost, raw)
+		}
+	}
+	if got := string(decodeText(raw, ENCODING_WINDOWS1252)); got != "café € \u0081" {
+		t.Errorf("Unexpected windows-1252 decoding: %q", got)
+	}
+	if _, lost := encodeText([]byte("日本"), ENCODING_LATIN1); lost != 2 {
+		t.Errorf("Expected 2 unencodable characters, got %d", lost)
+	}
+}
+
+func TestSetEncodingKeepsSavedBytes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "latin1.txt")
+	os.WriteFile(path, []byte("gr\xfc\xdfe\n"), 0644)
+
+	e := newTestEditor(10, 80)
+	e.config = DefaultConfig()
+	if err := e.Open(path); err != nil {
+		t.Fatal(err)
+	}
+	if !e.SetEncoding(ENCODING_LATIN1) {
+		t.Fatalf("Expected latin-1 to be accepted")
+	}
+	if string(e.row[0].chars) != "grüße" {
+		t.Errorf("Expected decoded text, got %q", e.row[0].chars)
+	}
+
+	e.Save()
+	saved, _ := os.ReadFile(path)
+	if string(saved) != "gr\xfc\xdfe\n" {
+		t.Errorf("Expected latin-1 bytes to be saved, got %q", saved)
+	}
+}
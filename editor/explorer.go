@@ -3,39 +3,50 @@ package editor
 import (
 	"fmt"
 	"os"
-	"strings"
+	"path/filepath"
 )
 
 // EditorState represents the saved state of the editor
 type EditorState struct {
-	rows      []editorRow
-	totalRows int
-	cx, cy    int
-	colOffset int
-	rowOffset int
+	rows        []editorRow
+	totalRows   int
+	indexedRows int
+	cx, cy      int
+	colOffset   int
+	rowOffset   int
+	selection   selection
+	cursors     []cursor
 }
 
 // getEditorState creates a snapshot of the current editor state
 func (e *Editor) getEditorState() EditorState {
 	return EditorState{
-		rows:      e.row,
-		totalRows: e.totalRows,
-		cx:        e.cx,
-		cy:        e.cy,
-		colOffset: e.colOffset,
-		rowOffset: e.rowOffset,
+		rows:        e.row,
+		totalRows:   e.totalRows,
+		indexedRows: e.indexedRows,
+		cx:          e.cx,
+		cy:          e.cy,
+		colOffset:   e.colOffset,
+		rowOffset:   e.rowOffset,
+		selection:   e.selection,
+		cursors:     e.cursors,
 	}
 }
 
-// setEditorState restores the editor to a previously saved state
+// setEditorState restores the editor to a previously saved state. The offsets are
+// corrected by the next Scroll if the screen was resized in the meantime.
 func (e *Editor) setEditorState(state EditorState) {
 	e.row = state.rows
 	e.totalRows = state.totalRows
+	e.indexedRows = state.indexedRows
 	e.cx = state.cx
 	e.cy = state.cy
 	e.colOffset = state.colOffset
 	e.rowOffset = state.rowOffset
+	e.selection = state.selection
+	e.cursors = state.cursors
 	e.mode = EDIT_MODE
+	e.contentVersion++
 }
 
 // ExplorerScreen implements the ModalScreen interface for file exploration
@@ -43,8 +54,11 @@ type ExplorerScreen struct {
 	currentDir   string
 	files        []os.DirEntry
 	hasParentDir bool
-	content      []editorRow
+	content      []editorRow // rows are built when they are first shown, see built
+	built        []bool      // whether the row at the same index of content was built
+	selected     int         // row highlighted as the selection, 0 if none is
 	editor       *Editor
+	fileState    EditorState // the open file, which is saved from within the explorer
 }
 
 // NewExplorerScreen creates a new explorer screen
@@ -52,6 +66,7 @@ func NewExplorerScreen(editor *Editor, startDir string) *ExplorerScreen {
 	explorer := &ExplorerScreen{
 		currentDir: startDir,
 		editor:     editor,
+		fileState:  editor.getEditorState(),
 	}
 	err := explorer.refreshContent()
 	if err != nil {
@@ -61,7 +76,8 @@ func NewExplorerScreen(editor *Editor, startDir string) *ExplorerScreen {
 	return explorer
 }
 
-// refreshContent updates the explorer content for the current directory
+// refreshContent reads the current directory. The rows of its entries are only built
+// once they are shown, so that directories with many entries open quickly.
 func (ex *ExplorerScreen) refreshContent() error {
 	// Read current directory contents
 	files, err := os.ReadDir(ex.currentDir)
@@ -72,47 +88,52 @@ func (ex *ExplorerScreen) refreshContent() error {
 	ex.files = files
 	ex.hasParentDir = ex.currentDir != "." && ex.currentDir != "/"
 
-	// Create content rows
-	ex.content = ex.createExplorerRows(files, ex.currentDir)
+	ex.content = make([]editorRow, ex.firstFileRow()+len(files))
+	ex.built = make([]bool, len(ex.content))
+	ex.selected = 0
+	ex.buildRows(0, ex.editor.screenRows)
 
 	return nil
 }
 
-// createExplorerRows creates all the display rows for the file explorer
-func (ex *ExplorerScreen) createExplorerRows(files []os.DirEntry, currentDir string) []editorRow {
-	explorerRows := make([]editorRow, 0, len(files)+2)
-
-	// Add header
-	headerText := fmt.Sprintf("=== File Explorer: %s ===", currentDir)
-	headerRow := editorRow{
-		idx:   0,
-		chars: []byte(headerText),
+// firstFileRow returns the index of the row of the first entry, after the header and
+// the parent directory option
+func (ex *ExplorerScreen) firstFileRow() int {
+	if ex.hasParentDir {
+		return 2
 	}
-	headerRow.Update(ex.editor)
-	explorerRows = append(explorerRows, headerRow)
+	return 1
+}
 
-	// Add parent directory option (unless we're at root)
-	if ex.hasParentDir {
-		parentText := "📂 .. (parent directory)"
-		parentRow := editorRow{
-			idx:   1,
-			chars: []byte(parentText),
+// buildRows builds the content rows from first up to last that weren't built yet
+func (ex *ExplorerScreen) buildRows(first, last int) {
+	for i := max(first, 0); i < min(last, len(ex.content)); i++ {
+		if ex.built[i] {
+			continue
 		}
-		parentRow.Update(ex.editor)
-		explorerRows = append(explorerRows, parentRow)
+		ex.content[i] = ex.createExplorerRow(i)
+		ex.content[i].Update(ex.editor)
+		ex.built[i] = true
 	}
+}
 
-	// Add files
-	for i, file := range files {
-		fileRow := ex.createFileDisplayRow(i, file)
-		fileRow.Update(ex.editor)
-		explorerRows = append(explorerRows, fileRow)
+// createExplorerRow creates the display row at the given index: the header, the
+// parent directory option or a file
+func (ex *ExplorerScreen) createExplorerRow(index int) editorRow {
+	var text string
+	switch {
+	case index == 0:
+		text = fmt.Sprintf("=== File Explorer: %s ===", ex.currentDir)
+	case index == 1 && ex.hasParentDir:
+		text = "📂 .. (parent directory)"
+	default:
+		return ex.createFileDisplayRow(index, ex.files[index-ex.firstFileRow()])
 	}
-
-	return explorerRows
+	return editorRow{idx: index, chars: []byte(text)}
 }
 
-// createFileDisplayRow creates a formatted display row for a file or directory
+// createFileDisplayRow creates a formatted display row for a file or directory. Files
+// are only stat'ed for their size here, when their row is shown.
 func (ex *ExplorerScreen) createFileDisplayRow(index int, file os.DirEntry) editorRow {
 	var fileInfo string
 	if file.IsDir() {
@@ -127,8 +148,8 @@ func (ex *ExplorerScreen) createFileDisplayRow(index int, file os.DirEntry) edit
 	}
 
 	return editorRow{
-		idx:   index + 2, // +2 to account for header and potential parent dir option
-		chars: []byte(fileInfo),
+		idx:   index,
+		chars: []byte(expandTabs(fileInfo, ex.editor.tabStop())),
 	}
 }
 
@@ -150,11 +171,15 @@ func (ex *ExplorerScreen) GetStatusMessage() string {
 // Initialize sets up the initial cursor position for the explorer
 func (ex *ExplorerScreen) Initialize(e *Editor) {
 	// Start at first file (skip header and optionally parent dir)
-	if ex.hasParentDir {
-		e.cy = 2 // Skip header and parent dir option
-	} else {
-		e.cy = 1 // Skip only header
-	}
+	e.cy = ex.firstFileRow()
+	ex.highlightSelectedFile(e)
+}
+
+// Resize builds the shown rows again and keeps the selection highlighted. The other
+// rows are built again when they are shown.
+func (ex *ExplorerScreen) Resize(e *Editor) {
+	clear(ex.built)
+	ex.selected = 0
 	ex.highlightSelectedFile(e)
 }
 
@@ -174,15 +199,11 @@ func (ex *ExplorerScreen) HandleKey(key int, e *Editor) (bool, bool) {
 			return true, false // Close modal but keep new file state (don't restore)
 		}
 		// Directory was changed, update display with new cursor position
-		if ex.hasParentDir {
-			e.cy = 2 // Skip header and parent dir option
-		} else {
-			e.cy = 1 // Skip only header
-		}
+		e.cy = ex.firstFileRow()
 		e.rowOffset = 0
+		e.colOffset = 0
 		// Update the editor's row content with new directory content
-		e.row = ex.content
-		e.totalRows = len(ex.content)
+		ex.highlightSelectedFile(e)
 		// Update status message
 		e.SetStatusMessage("%s", ex.GetStatusMessage())
 	}
@@ -214,16 +235,21 @@ func (ex *ExplorerScreen) handleExplorerNavigation(key int, e *Editor) {
 	}
 }
 
-// highlightSelectedFile highlights the currently selected file in the explorer
+// highlightSelectedFile highlights the currently selected file in the explorer,
+// building the rows that are scrolled into view
 func (ex *ExplorerScreen) highlightSelectedFile(e *Editor) {
+	// Update the editor's content reference
+	e.showModalRows(ex.content)
+	e.Scroll()
+	ex.buildRows(e.rowOffset, e.rowOffset+e.screenRows)
 	if e.cy <= 0 || e.cy >= len(ex.content) {
 		return
 	}
 
-	// Reset all highlights first
-	for i := 1; i < len(ex.content); i++ {
-		for j := range ex.content[i].hl {
-			ex.content[i].hl[j] = HL_NORMAL
+	// Only the previous selection has to be reset
+	if ex.selected > 0 && ex.selected < len(ex.content) {
+		for j := range ex.content[ex.selected].hl {
+			ex.content[ex.selected].hl[j] = HL_NORMAL
 		}
 	}
 
@@ -231,9 +257,7 @@ func (ex *ExplorerScreen) highlightSelectedFile(e *Editor) {
 	for j := range ex.content[e.cy].hl {
 		ex.content[e.cy].hl[j] = HL_MATCH
 	}
-
-	// Update the editor's content reference
-	e.row = ex.content
+	ex.selected = e.cy
 }
 
 // openSelectedFile attempts to open the currently selected file or navigate to directory
@@ -243,18 +267,7 @@ func (ex *ExplorerScreen) openSelectedFile(e *Editor) bool {
 	// Handle parent directory navigation
 	if ex.hasParentDir && selectedIndex == 0 {
 		// Navigate to parent directory
-		parentDir := ".."
-		if ex.currentDir != "." {
-			// Get actual parent path
-			if lastSlash := strings.LastIndex(ex.currentDir, "/"); lastSlash != -1 {
-				parentDir = ex.currentDir[:lastSlash]
-				if parentDir == "" {
-					parentDir = "."
-				}
-			} else {
-				parentDir = "."
-			}
-		}
+		parentDir := filepath.Join(ex.currentDir, "..")
 		ex.currentDir = parentDir
 		err := ex.refreshContent()
 		if err != nil {
@@ -277,11 +290,7 @@ func (ex *ExplorerScreen) openSelectedFile(e *Editor) bool {
 
 	if selectedFile.IsDir() {
 		// Navigate into directory
-		newDir := selectedFile.Name()
-		if ex.currentDir != "." {
-			newDir = ex.currentDir + "/" + newDir
-		}
-		ex.currentDir = newDir
+		ex.currentDir = filepath.Join(ex.currentDir, selectedFile.Name())
 		err := ex.refreshContent()
 		if err != nil {
 			e.ShowError("Failed to read directory: %v", err)
@@ -290,18 +299,12 @@ func (ex *ExplorerScreen) openSelectedFile(e *Editor) bool {
 		return false // Directory changed, don't close explorer
 	}
 
-	if e.dirty > 0 {
-		e.SetStatusMessage("File has unsaved changes")
+	if e.dirty > 0 && !ex.confirmLeaveFile(e) {
 		return false
 	}
 
 	// Open regular file
-	filePath := selectedFile.Name()
-	if ex.currentDir != "." {
-		filePath = ex.currentDir + "/" + filePath
-	}
-
-	err := e.Open(filePath)
+	err := e.Open(filepath.Join(ex.currentDir, selectedFile.Name()))
 	if err != nil {
 		e.ShowError("Failed to open file: %v", err)
 		return false
@@ -310,12 +313,48 @@ func (ex *ExplorerScreen) openSelectedFile(e *Editor) bool {
 	return true // File opened successfully
 }
 
+// confirmLeaveFile asks what to do with the unsaved changes of the open file before
+// another one is opened: save them first, discard them or cancel opening
+func (ex *ExplorerScreen) confirmLeaveFile(e *Editor) bool {
+	switch e.PromptKey("%s has unsaved changes. (s)ave, (d)iscard or (c)ancel?", e.displayName()) {
+	case 's', 'S':
+		if !ex.saveFile(e) {
+			e.SetStatusMessage("Save failed, file not opened")
+			return false
+		}
+		return true
+	case 'd', 'D':
+		return true
+	default:
+		e.SetStatusMessage("Open cancelled")
+		return false
+	}
+}
+
+// saveFile saves the open file while the explorer is shown. It reports whether
+// all changes were saved.
+func (ex *ExplorerScreen) saveFile(e *Editor) bool {
+	explorerState, mode := e.getEditorState(), e.mode
+	e.setEditorState(ex.fileState)
+	e.Save()
+	ex.fileState = e.getEditorState()
+	e.setEditorState(explorerState)
+	e.mode = mode
+	return e.dirty == 0
+}
+
 // Explorer opens the file explorer interface using the modal system
 func (e *Editor) Explorer() {
-	explorerScreen := NewExplorerScreen(e, ".")
+	e.ExploreDirectory(".")
+}
+
+// ExploreDirectory opens the file explorer in the given directory
+func (e *Editor) ExploreDirectory(dir string) {
+	explorerScreen := NewExplorerScreen(e, filepath.Clean(dir))
 	if explorerScreen == nil {
 		return // Error already shown
 	}
 	modalManager := NewModalManager(e, explorerScreen)
 	modalManager.Show(EXPLORER_MODE)
+	e.mode = EDIT_MODE
 }
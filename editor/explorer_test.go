@@ -0,0 +1,160 @@
+package editor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExplorerFilenameWithTab(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a\tb.txt"), nil, 0644)
+
+	e := newTestEditor(10, 80)
+	ex := NewExplorerScreen(e, dir)
+	row := ex.content[len(ex.content)-1]
+	text := string(row.chars)
+	if strings.Contains(text, "\t") {
+		t.Fatalf("Expected the tab to be expanded, got %q", text)
+	}
+	// "📄 a" is four columns wide, so the tab fills up to column 8
+	if prefix := text[:strings.Index(text, "b.txt")]; displayWidth(prefix) != 8 {
+		t.Errorf("Expected the name to continue at column 8, got %d in %q", displayWidth(prefix), text)
+	}
+}
+
+func TestExplorerResizeKeepsSelection(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "one.txt"), nil, 0644)
+	os.WriteFile(filepath.Join(dir, "two.txt"), nil, 0644)
+
+	e := newTestEditor(10, 80)
+	ex := NewExplorerScreen(e, dir)
+	NewModalManager(e, ex).setupModalDisplay(ex.GetContent(), EXPLORER_MODE)
+	ex.Initialize(e)
+
+	e.tabSize = 2
+	ex.Resize(e)
+	for i, row := range ex.content {
+		selected := len(row.hl) > 0 && row.hl[0] == HL_MATCH
+		if selected != (i == e.cy) {
+			t.Errorf("Row %d: expected selected %v after resizing", i, i == e.cy)
+		}
+	}
+}
+
+func TestExplorerDirtyBufferConfirm(t *testing.T) {
+	tests := []struct {
+		key    string
+		opened bool
+		saved  string
+	}{
+		{"c", false, "old"},
+		{"d", true, "old"},
+		{"s", true, "changed"},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		current := filepath.Join(dir, "a.txt")
+		os.WriteFile(current, []byte("old\n"), 0644)
+		os.WriteFile(filepath.Join(dir, "b.txt"), []byte("other\n"), 0644)
+
+		e := newTestEditor(10, 80)
+		e.config = DefaultConfig()
+		e.output = newOutput(io.Discard)
+		e.Open(current)
+		e.row[0].chars = []byte("changed")
+		e.row[0].Update(e)
+		e.dirty++
+
+		ex := NewExplorerScreen(e, dir)
+		m := NewModalManager(e, ex)
+		m.setupModalDisplay(ex.GetContent(), EXPLORER_MODE)
+		ex.Initialize(e)
+		e.cy = 3 // b.txt, after the header, the parent directory and a.txt
+		e.input = newInput(strings.NewReader(tt.key))
+
+		if opened := ex.openSelectedFile(e); opened != tt.opened {
+			t.Errorf("%q: expected opened %v, got %v", tt.key, tt.opened, opened)
+		}
+		if data, _ := os.ReadFile(current); string(data) != tt.saved+"\n" {
+			t.Errorf("%q: expected %q on disk, got %q", tt.key, tt.saved, data)
+		}
+		if tt.opened {
+			if e.filename != filepath.Join(dir, "b.txt") || e.dirty != 0 {
+				t.Errorf("%q: expected b.txt to be opened, got %q", tt.key, e.filename)
+			}
+		} else if e.mode != EXPLORER_MODE || e.totalRows != len(ex.content) {
+			t.Errorf("%q: expected the explorer to stay open", tt.key)
+		}
+	}
+}
+
+func TestExplorerNavigatesDirectories(t *testing.T) {
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "sub"), 0755)
+	os.WriteFile(filepath.Join(dir, "sub", "file.txt"), []byte("inside\n"), 0644)
+
+	e := newTestEditor(10, 80)
+	ex := NewExplorerScreen(e, filepath.Join(dir, "sub")+"/")
+	NewModalManager(e, ex).setupModalDisplay(ex.GetContent(), EXPLORER_MODE)
+	ex.Initialize(e)
+
+	e.cy = 1 // the parent directory
+	if ex.openSelectedFile(e) || ex.currentDir != dir {
+		t.Fatalf("Expected to navigate to %q, got %q", dir, ex.currentDir)
+	}
+	e.cy = 2 // sub, after the header and the parent directory
+	if ex.openSelectedFile(e) {
+		t.Fatalf("Expected to navigate into sub")
+	}
+	e.cy = 2 // file.txt
+	if !ex.openSelectedFile(e) || e.Text() != "inside\n" {
+		t.Errorf("Expected file.txt to be opened, got %q", e.filename)
+	}
+}
+
+func TestExplorerBuildsRowsWhenShown(t *testing.T) {
+	dir := t.TempDir()
+	for i := range 50 {
+		os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%02d.txt", i)), nil, 0644)
+	}
+
+	e := newTestEditor(10, 80)
+	ex := NewExplorerScreen(e, dir)
+	NewModalManager(e, ex).setupModalDisplay(ex.GetContent(), EXPLORER_MODE)
+	ex.Initialize(e)
+	if ex.content[10].chars != nil {
+		t.Errorf("Expected rows below the screen not to be built, got %q", ex.content[10].chars)
+	}
+
+	for range 20 {
+		ex.HandleKey(ARROW_DOWN, e)
+	}
+	if row := ex.content[e.cy]; !strings.Contains(string(row.chars), "file20.txt") || row.hl[0] != HL_MATCH {
+		t.Errorf("Expected file20.txt to be built and selected, got %q", row.chars)
+	}
+	if ex.content[2].hl[0] != HL_NORMAL {
+		t.Errorf("Expected the previous selection to be reset")
+	}
+}
+
+func BenchmarkExplorerLargeDirectory(b *testing.B) {
+	dir := b.TempDir()
+	for i := range 5000 {
+		os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%05d.txt", i)), nil, 0644)
+	}
+
+	e := newTestEditor(24, 80)
+	for b.Loop() {
+		ex := NewExplorerScreen(e, dir)
+		NewModalManager(e, ex).setupModalDisplay(ex.GetContent(), EXPLORER_MODE)
+		ex.Initialize(e)
+		for range 100 {
+			ex.HandleKey(ARROW_DOWN, e)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package editor
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// cssColors maps the ANSI colors of the syntax highlighting to CSS colors
+var cssColors = map[int]string{
+	ANSI_COLOR_RED:     "#c91b00",
+	ANSI_COLOR_GREEN:   "#00a600",
+	ANSI_COLOR_YELLOW:  "#a68a00",
+	ANSI_COLOR_BLUE:    "#0225c7",
+	ANSI_COLOR_MAGENTA: "#b200b2",
+	ANSI_COLOR_CYAN:    "#00a6b2",
+	ANSI_COLOR_WHITE:   "#bfbfbf",
+}
+
+// cssStyle returns the inline style for a highlight in the theme, or "" for normal text
+func cssStyle(theme Theme, hl int) string {
+	color, style := theme(hl)
+	css := ""
+	if c, ok := cssColors[color]; ok {
+		css = "color:" + c + ";"
+	}
+	switch style {
+	case ANSI_BOLD:
+		css += "font-weight:bold;"
+	case ANSI_ITALIC:
+		css += "font-style:italic;"
+	case ANSI_UNDERLINE:
+		css += "text-decoration:underline;"
+	case ANSI_REVERSE:
+		bg, ok := cssColors[color]
+		if !ok {
+			bg = "#000"
+		}
+		css = "color:#fff;background:" + bg + ";"
+	}
+	return css
+}
+
+// ExportHTML writes the buffer as an HTML page that shows it like the editor does,
+// with tabs expanded and syntax highlighting as inline styles. With lineNumbers
+// every line starts with its number.
+func (e *Editor) ExportHTML(w io.Writer, lineNumbers bool) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n<pre>\n", html.EscapeString(e.displayName()))
+
+	numberWidth := len(fmt.Sprint(e.totalRows))
+	for i := range e.row {
+		row := &e.row[i]
+		if lineNumbers {
+			fmt.Fprintf(bw, "<span style=\"color:#888;\">%*d </span>", numberWidth, i+1)
+		}
+		render := row.renderColumns(e, 0, row.renderWidth)
+		writeHighlightedHTML(bw, render, row.hl, e.theme())
+		bw.WriteString("\n")
+	}
+
+	bw.WriteString("</pre>\n</body>\n</html>\n")
+	return bw.Flush()
+}
+
+// writeHighlightedHTML writes rendered text as escaped HTML with a span for every run of
+// the same highlighting, in its colors in the theme. A character always takes the
+// highlighting of its first byte, so that runs don't split multi-byte characters.
+// Without hl the text is written plain.
+func writeHighlightedHTML(w *bufio.Writer, render []byte, hl []int, theme Theme) {
+	style := ""
+	var run strings.Builder
+	flush := func() {
+		if run.Len() == 0 {
+			return
+		}
+		if style == "" {
+			w.WriteString(html.EscapeString(run.String()))
+		} else {
+			fmt.Fprintf(w, "<span style=\"%s\">%s</span>", style, html.EscapeString(run.String()))
+		}
+		run.Reset()
+	}
+
+	for i := 0; i < len(render); {
+		_, size := utf8.DecodeRune(render[i:])
+		next := ""
+		if i < len(hl) {
+			next = cssStyle(theme, hl[i])
+		}
+		if next != style {
+			flush()
+			style = next
+		}
+		run.Write(render[i : i+size])
+		i += size
+	}
+	flush()
+}
+
+// ExportANSI writes the buffer as text with the escape sequences the editor uses for
+// syntax highlighting, so that printing it in a terminal shows the same colors.
+// With lineNumbers every line starts with its number.
+func (e *Editor) ExportANSI(w io.Writer, lineNumbers bool) error {
+	bw := bufio.NewWriter(w)
+	numberWidth := len(fmt.Sprint(e.totalRows))
+	for i := range e.row {
+		row := &e.row[i]
+		var abuf appendBuffer
+		if lineNumbers {
+			abuf.append(fmt.Appendf(nil, "%*d ", numberWidth, i+1))
+		}
+		abuf.append(renderColoredRow(row.renderColumns(e, 0, row.renderWidth), row.hl, 0, row.renderWidth, e.theme()))
+		abuf.append([]byte("\n"))
+		bw.Write(abuf.b)
+	}
+	return bw.Flush()
+}
+
+// ExportPrompt asks for a file name and whether to number the lines, and exports
+// the buffer with its highlighting. Files ending in .html or .htm get HTML, all
+// others text with terminal escape sequences.
+func (e *Editor) ExportPrompt() {
+	path := e.Prompt("Export to file (.html for HTML, else ANSI colored text): %s (ESC to cancel)", nil)
+	if path == "" {
+		e.SetStatusMessage("Export cancelled")
+		return
+	}
+	key := e.PromptKey("Include line numbers? (y/n)")
+	lineNumbers := key == 'y' || key == 'Y'
+
+	export := e.ExportANSI
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".html" || ext == ".htm" {
+		export = e.ExportHTML
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		e.ShowError("could not export: %v", err)
+		return
+	}
+	defer file.Close()
+	if err := export(file, lineNumbers); err != nil {
+		e.ShowError("could not export: %v", err)
+		return
+	}
+	e.SetStatusMessage("Exported %d lines to %s", e.totalRows, path)
+}
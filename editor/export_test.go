@@ -0,0 +1,54 @@
+package editor
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExportHTML(t *testing.T) {
+	e := newTestEditor(10, 80)
+	e.Load(strings.NewReader("if a < \"<b>\" {\n\treturn 1 // é\n}\n"), "main.go")
+
+	var out bytes.Buffer
+	if err := e.ExportHTML(&out, true); err != nil {
+		t.Fatal(err)
+	}
+	html := out.String()
+
+	expected := []string{
+		"<title>main.go</title>",
+		"<span style=\"color:#888;\">1 </span><span style=\"color:#a68a00;\">if</span> a &lt; <span style=\"color:#b200b2;\">&#34;&lt;b&gt;&#34;</span> {\n",
+		"<span style=\"color:#888;\">2 </span>    <span style=\"color:#a68a00;\">return</span> <span style=\"color:#c91b00;\">1</span> <span style=\"color:#00a6b2;\">// é</span>\n",
+		"<span style=\"color:#888;\">3 </span>}\n",
+	}
+	for _, s := range expected {
+		if !strings.Contains(html, s) {
+			t.Errorf("Expected the HTML to contain %q, got:\n%s", s, html)
+		}
+	}
+}
+
+func TestExportHTMLWithoutLineNumbers(t *testing.T) {
+	e := newTestEditor(10, 80, "a & b")
+
+	var out bytes.Buffer
+	e.ExportHTML(&out, false)
+	if !strings.Contains(out.String(), "<pre>\na &amp; b\n</pre>") {
+		t.Errorf("Expected the plain escaped line, got:\n%s", out.String())
+	}
+}
+
+func TestExportANSI(t *testing.T) {
+	e := newTestEditor(10, 80)
+	e.Load(strings.NewReader("x = 1\n\tfoo\n"), "main.go")
+
+	var out bytes.Buffer
+	if err := e.ExportANSI(&out, true); err != nil {
+		t.Fatal(err)
+	}
+	expected := "1 x = \x1b[31m1\x1b[39m\n2     foo\x1b[39m\n"
+	if out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
@@ -0,0 +1,82 @@
+package editor
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Kinds of line numbers shown in the gutter
+const (
+	LINE_NUMBERS_OFF      = iota
+	LINE_NUMBERS_ABSOLUTE // the number of every line
+	LINE_NUMBERS_RELATIVE // the distance of every line from the cursor's line
+	LINE_NUMBERS_HYBRID   // relative numbers, with the number of the cursor's line
+)
+
+// Styles of the line numbers, the cursor's line standing out from the others
+const (
+	GUTTER_STYLE        = "\x1b[2m" // Dim
+	GUTTER_CURSOR_STYLE = "\x1b[1m" // Bold
+)
+
+// gutterWidth returns the number of screen columns taken by line numbers left of the
+// text, one more than the digits of the last line's number. Modal screens and the
+// start screen of an empty buffer have no gutter.
+func (e *Editor) gutterWidth() int {
+	if e.config.LineNumbers == LINE_NUMBERS_OFF || e.totalRows == 0 || e.isModal() {
+		return 0
+	}
+	width := len(strconv.Itoa(e.totalRows)) + 1
+	if width >= e.screenCols {
+		return 0 // No room for text next to it
+	}
+	return width
+}
+
+// gutterDependsOnCursor reports whether the line numbers change when the cursor moves
+// to another line, so that drawn rows can't be reused
+func (e *Editor) gutterDependsOnCursor() bool {
+	return e.gutterWidth() > 0 && e.config.LineNumbers != LINE_NUMBERS_ABSOLUTE
+}
+
+// drawGutter draws the line number of filerow, or blanks below the end of the file
+func (e *Editor) drawGutter(abuf *appendBuffer, filerow int) {
+	width := e.gutterWidth()
+	if width == 0 {
+		return
+	}
+	if filerow >= e.totalRows {
+		abuf.append(fmt.Appendf(nil, "%*s", width, ""))
+		return
+	}
+
+	number := filerow + 1
+	style := GUTTER_STYLE
+	switch {
+	case filerow == e.cy && e.config.LineNumbers != LINE_NUMBERS_ABSOLUTE:
+		style = GUTTER_CURSOR_STYLE
+		if e.config.LineNumbers == LINE_NUMBERS_RELATIVE {
+			number = 0
+		}
+	case e.config.LineNumbers != LINE_NUMBERS_ABSOLUTE:
+		number = max(filerow-e.cy, e.cy-filerow)
+	}
+	abuf.append(fmt.Appendf(nil, "%s%*d%s ", style, width-1, number, COLORS_RESET))
+}
+
+// CycleLineNumbers switches the gutter to the next kind of line numbers, turning it
+// off after the last
+func (e *Editor) CycleLineNumbers() {
+	e.config.LineNumbers = (e.config.LineNumbers + 1) % (LINE_NUMBERS_HYBRID + 1)
+	e.invalidateFrame()
+	switch e.config.LineNumbers {
+	case LINE_NUMBERS_ABSOLUTE:
+		e.SetStatusMessage("Line numbers: absolute")
+	case LINE_NUMBERS_RELATIVE:
+		e.SetStatusMessage("Line numbers: relative")
+	case LINE_NUMBERS_HYBRID:
+		e.SetStatusMessage("Line numbers: hybrid")
+	default:
+		e.SetStatusMessage("Line numbers off")
+	}
+}
@@ -0,0 +1,62 @@
+package editor
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLineNumberGutter(t *testing.T) {
+	e := newTestEditor(10, 40, numberedLines(12)...)
+	e.config.LineNumbers = LINE_NUMBERS_ABSOLUTE
+	e.cy = 3
+	drawn := func() []string {
+		var abuf appendBuffer
+		e.DrawRows(&abuf)
+		return strings.Split(string(abuf.b), "\r\n")
+	}
+	number := func(style string, n int) string {
+		return fmt.Sprintf("%s%2d%s ", style, n, COLORS_RESET)
+	}
+
+	if e.gutterWidth() != 3 || e.textCols() != 37 {
+		t.Errorf("Expected a gutter of 3 columns for 12 lines, got %d leaving %d", e.gutterWidth(), e.textCols())
+	}
+	lines := drawn()
+	if want := number(GUTTER_STYLE, 1) + "line 1"; !strings.HasPrefix(lines[0], want) {
+		t.Errorf("Expected %q to start with %q", lines[0], want)
+	}
+
+	e.config.LineNumbers = LINE_NUMBERS_RELATIVE
+	lines = drawn()
+	if want := number(GUTTER_STYLE, 3); !strings.HasPrefix(lines[0], want) {
+		t.Errorf("Expected relative number 3 on the first line, got %q", lines[0])
+	}
+	if want := number(GUTTER_CURSOR_STYLE, 0); !strings.HasPrefix(lines[3], want) {
+		t.Errorf("Expected 0 on the cursor's line, got %q", lines[3])
+	}
+
+	e.config.LineNumbers = LINE_NUMBERS_HYBRID
+	lines = drawn()
+	if want := number(GUTTER_CURSOR_STYLE, 4); !strings.HasPrefix(lines[3], want) {
+		t.Errorf("Expected the number of the cursor's line, got %q", lines[3])
+	}
+}
+
+func TestLineNumberGutterMovesCursor(t *testing.T) {
+	e := newTestEditor(10, 20, strings.Repeat("x", 30))
+	var out bytes.Buffer
+	e.output = newOutput(&out)
+	e.config.LineNumbers = LINE_NUMBERS_ABSOLUTE
+	e.cx = 25
+
+	// The text scrolls sideways within the columns right of the gutter
+	e.RefreshScreen()
+	if e.colOffset != 8 {
+		t.Errorf("Expected the column offset to leave room for the gutter, got %d", e.colOffset)
+	}
+	if want := fmt.Sprintf(CURSOR_POSITION_FORMAT, 1, 20); !strings.HasSuffix(out.String(), want+CURSOR_SHOW) {
+		t.Errorf("Expected the cursor in the last column, got %q", out.String())
+	}
+}
@@ -0,0 +1,60 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// C and C++ file extensions, in the order partner files are looked for
+var (
+	sourceExtensions = []string{".c", ".cpp", ".cc", ".cxx"}
+	headerExtensions = []string{".h", ".hpp", ".hh", ".hxx"}
+)
+
+// partnerFile returns the header of a source file or the source of a header file in
+// the same directory, or "" if there is none. ok is false for files that are neither.
+func partnerFile(filename string) (partner string, ok bool) {
+	ext := filepath.Ext(filename)
+	var candidates []string
+	switch {
+	case slices.Contains(sourceExtensions, ext):
+		candidates = headerExtensions
+	case slices.Contains(headerExtensions, ext):
+		candidates = sourceExtensions
+	default:
+		return "", false
+	}
+
+	base := strings.TrimSuffix(filename, ext)
+	for _, candidate := range candidates {
+		if info, err := os.Stat(base + candidate); err == nil && !info.IsDir() {
+			return base + candidate, true
+		}
+	}
+	return "", true
+}
+
+// ToggleHeaderSource shows the header of a C or C++ source file, or the source of a
+// header. The partner is opened in another buffer, or shown if it already is open,
+// so invoking it again switches back.
+func (e *Editor) ToggleHeaderSource() {
+	partner, ok := partnerFile(e.filename)
+	if !ok {
+		e.SetStatusMessage("Not a C or C++ source or header file")
+		return
+	}
+	if partner == "" {
+		e.ShowError("no matching header or source file for %s", e.filename)
+		return
+	}
+
+	if index := e.findBuffer(partner); index >= 0 {
+		e.SwitchBuffer(index)
+		return
+	}
+	if err := e.OpenBuffer(partner); err != nil {
+		e.ShowError("%v", err)
+	}
+}
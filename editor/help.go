@@ -16,23 +16,72 @@ func NewHelpScreen(editor *Editor) *HelpScreen {
 		"  Arrow Keys       - Move cursor",
 		"  Page Up/Down     - Scroll by page",
 		"  Home/End         - Move to line start/end",
+		"  Ctrl+A           - Move to the first non-blank character, again for the line start",
+		"  Ctrl+Home/End    - Go to the first/last line (Alt+<digits> first: that line)",
+		"  Ctrl+G           - Go to line number or percentage (e.g. 50%)",
 		"",
 		"EDITING:",
 		"  Ctrl+S           - Save file",
+		"  Alt+S            - Save file under another name",
+		"  Alt+L            - Reload file from disk",
 		"  Ctrl+Q           - Quit (with confirmation if unsaved)",
 		"  Delete/Backspace - Delete characters",
+		"  Ctrl+Z / Ctrl+Y  - Undo/redo the last change",
+		"  Ctrl+Delete      - Delete word forward",
+		"  Shift+Arrows     - Select text",
+		"  Ctrl+B           - Select text with the arrow keys, again to stop",
+		"  Ctrl+C / Ctrl+X  - Copy/cut the selected text",
+		"  Ctrl+U           - Paste the copied or cut text",
+		"  Alt+A            - Select the word, again for the line, then the paragraph",
+		"  Alt+Shift+C      - Crop the file to the selection, deleting everything else",
+		"  Delete/Backspace - Delete the selection (typing replaces it)",
+		"  Tab/Shift+Tab    - Indent/dedent the lines of a selection spanning lines",
+		"  Tab              - Expand the snippet trigger word before the cursor",
+		"  Ctrl+V <key>     - Insert the key literally, like a tab even with expandtab",
+		"  Alt+> / Alt+<    - Indent/dedent the current line",
+		"  Alt+/            - Toggle a block comment around the selection or line",
+		"  Alt+I            - Normalize indentation to tabs or spaces",
+		"  Alt+V            - Reverse the order of the selected lines",
+		"  Alt+U            - Remove repeated adjacent lines (in the selection if any)",
+		"  Alt+Shift+U      - Remove all repeated lines, keeping the first of each",
+		"  Alt+K            - Split the line at the cursor without indenting, cursor stays",
+		"  Alt+D            - Duplicate the current or selected lines below",
+		"  Alt+Shift+D      - Duplicate the current or selected lines above",
+		"  Alt+E            - Read the file in another encoding (utf-8, latin-1, windows-1252)",
+		"  Alt+Down         - Add a cursor on the line below",
+		"  Ctrl+D           - Add a cursor at the next occurrence of the word",
 		"",
 		"SEARCH:",
 		"  Ctrl+F           - Find text",
 		"  Arrow Up/Down    - Navigate search results",
+		"  Alt+W            - Toggle whole word matching",
+		"  Alt+R (in search prompt) - Toggle regular expression matching",
 		"  Escape           - Cancel search",
+		"  Ctrl+R           - Replace, asking at each match (y/n/a/q, Arrows to step)",
+		"  Alt+R            - Replace all (within the selection if any)",
+		"  Ctrl+N / Ctrl+P  - Repeat last search forward/backward",
+		"  Alt+* / Alt+#    - Find next/previous occurrence of word under cursor",
 		"",
 		"FILE OPERATIONS:",
 		"  Ctrl+E           - Open file explorer",
+		"  Ctrl+O           - Quick open a file by fuzzy name",
+		"  Alt+N / Alt+P    - Switch to the next/previous open file",
+		"  Alt+Q            - Close the file without quitting",
+		"  Alt+Shift+Q      - Reopen the file closed or replaced last",
+		"  Ctrl+W s / v     - Split the window stacked / side by side",
+		"  Ctrl+W w / p / c - Go to the next / previous window, close the window",
+		"  Alt+T            - Switch between a C/C++ source file and its header",
+		"  Alt+X            - Export the file with its highlighting as HTML or ANSI text",
 		"",
 		"OTHER:",
 		"  Ctrl+H           - Show this help",
-		"  Ctrl+R           - Redraw screen",
+		"  Ctrl+L           - Redraw screen",
+		"  Ctrl+T           - Toggle highlighting of the word under the cursor",
+		"  Alt+<digits>     - Repeat the next movement or edit that many times",
+		"  Alt+H            - Toggle syntax highlighting",
+		"  Alt+C            - Toggle control characters as ^X or \\xNN",
+		"  Alt+Shift+L      - Cycle line numbers: absolute, relative, hybrid, off",
+		"  Alt+G            - Show the diagnostics log of keys, errors and resizes",
 		"",
 		"About KIGO:",
 		fmt.Sprintf("  Version: %s", KIGO_VERSION),
@@ -78,6 +127,11 @@ func (h *HelpScreen) Initialize(e *Editor) {
 	e.rowOffset = 0
 }
 
+// Resize renders the help text again
+func (h *HelpScreen) Resize(e *Editor) {
+	updateRows(h.content, e)
+}
+
 // HandleKey processes key presses for the help screen
 func (h *HelpScreen) HandleKey(key int, e *Editor) (bool, bool) {
 	switch key {
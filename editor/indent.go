@@ -0,0 +1,480 @@
+package editor
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// indentStyle describes how a buffer is indented
+type indentStyle struct {
+	expandTab bool // indent with spaces instead of a tab character
+	width     int  // spaces per indentation level when expandTab is set
+}
+
+// String describes the style for the status bar, e.g. "tabs" or "spaces:4"
+func (s indentStyle) String() string {
+	if s.expandTab {
+		return fmt.Sprintf("spaces:%d", s.indentWidth())
+	}
+	return "tabs"
+}
+
+// indentWidth returns the number of spaces per indentation level
+func (s indentStyle) indentWidth() int {
+	if s.width <= 0 {
+		return TAB_STOP
+	}
+	return s.width
+}
+
+// configuredIndent returns the indentation style from the configuration
+func (e *Editor) configuredIndent() indentStyle {
+	return indentStyle{expandTab: e.config.ExpandTab, width: e.config.IndentWidth}
+}
+
+// detectIndent infers the indentation style from the leading whitespace of the
+// first indented rows. It reports false if there are too few indented rows to tell.
+func detectIndent(rows []editorRow) (indentStyle, bool) {
+	const sampleSize = 100
+
+	tabs, spaces := 0, 0
+	deltas := map[int]int{} // increase in space indentation between consecutive rows
+	prevSpaces := 0
+	for i := 0; i < len(rows) && tabs+spaces < sampleSize; i++ {
+		chars := rows[i].chars
+		indent := leadingWhitespace(chars)
+		if len(indent) == len(chars) {
+			continue // Blank rows say nothing about the indentation
+		}
+
+		switch {
+		case len(indent) == 0:
+			prevSpaces = 0
+		case indent[0] == '\t':
+			tabs++
+		default:
+			spaces++
+			if delta := len(indent) - prevSpaces; delta > 0 && delta <= 8 {
+				deltas[delta]++
+			}
+			prevSpaces = len(indent)
+		}
+	}
+
+	if tabs == 0 && spaces == 0 {
+		return indentStyle{}, false
+	}
+	if tabs >= spaces {
+		return indentStyle{expandTab: false}, true
+	}
+
+	width, count := 0, 0
+	for delta, n := range deltas {
+		if n > count || (n == count && delta < width) {
+			width, count = delta, n
+		}
+	}
+	if width == 0 {
+		return indentStyle{}, false
+	}
+	return indentStyle{expandTab: true, width: width}, true
+}
+
+// applyIndentStyle sets the indentation style of the buffer, detected from the
+// content if enabled and otherwise taken from the configuration. Filetypes that
+// require tabs always indent with tabs.
+func (e *Editor) applyIndentStyle() {
+	e.indent = e.configuredIndent()
+	if e.config.DetectIndent {
+		if style, ok := detectIndent(e.row); ok {
+			e.indent = style
+		}
+	}
+	e.requireTabs()
+}
+
+// requireTabs switches to indenting with tabs if the filetype requires them
+func (e *Editor) requireTabs() {
+	if e.syntax != nil && e.syntax.indentTabs {
+		e.indent.expandTab = false
+	}
+}
+
+// indentUnit returns the whitespace that makes up one indentation level
+func (e *Editor) indentUnit() []byte {
+	if e.indent.expandTab {
+		return []byte(strings.Repeat(" ", e.indent.indentWidth()))
+	}
+	return []byte("\t")
+}
+
+// InsertTab inserts a tab character, or with expandtab spaces up to the next
+// indentation level. QuotedInsert inserts a tab character either way.
+func (e *Editor) InsertTab() {
+	if !e.indent.expandTab {
+		e.InsertChar('\t')
+		return
+	}
+	col := 0
+	if e.cy < e.totalRows {
+		col = e.row[e.cy].cxToRx(e, e.cx)
+	}
+	width := e.indent.indentWidth()
+	for range width - col%width {
+		e.InsertChar(' ')
+	}
+}
+
+// QuotedInsert inserts the next key as it is, like a tab character when expandtab is
+// on or a control character. Keys that aren't characters can't be inserted.
+func (e *Editor) QuotedInsert() {
+	key := e.PromptKey("Insert literally: press a key")
+	e.SetStatusMessage("")
+	if key > 0xff {
+		e.Bell()
+		return
+	}
+	e.DeleteSelection() // Typing replaces the selected text
+	e.InsertChar(key)
+}
+
+// openingBrackets are the opening brackets of the closing brackets that can be dedented
+var openingBrackets = map[byte]byte{'}': '{', ')': '(', ']': '['}
+
+// dedentClosingBracket lines the cursor row up with the row of the matching opening
+// bracket before c is typed, if c is a closing bracket of the filetype and the row is
+// blank up to the cursor. The row is only ever indented less than before.
+func (e *Editor) dedentClosingBracket(c int) {
+	if !e.config.AutoIndent || e.cy >= e.totalRows || e.cx == 0 {
+		return
+	}
+	filetype := ""
+	if e.syntax != nil {
+		filetype = e.syntax.filetype
+	}
+	if c > 0x7f || !strings.ContainsRune(e.config.DedentBrackets[filetype], rune(c)) {
+		return
+	}
+	row := &e.row[e.cy]
+	if !isBlankLine(row.chars[:e.cx]) {
+		return
+	}
+	at := e.openingBracketRow(byte(c))
+	if at < 0 {
+		return
+	}
+	lead := leadingWhitespace(e.row[at].chars)
+	if e.row[at].cxToRx(e, len(lead)) < row.cxToRx(e, len(leadingWhitespace(row.chars))) {
+		e.setLeadingWhitespace(slices.Clone(lead))
+	}
+}
+
+// openingBracketRow returns the row of the opening bracket that the closing bracket c
+// typed at the cursor would match, or -1 if there is none
+func (e *Editor) openingBracketRow(c byte) int {
+	open, ok := openingBrackets[c]
+	if !ok {
+		return -1
+	}
+	depth := 0
+	for y := e.cy; y >= 0; y-- {
+		chars := e.row[y].chars
+		if y == e.cy {
+			chars = chars[:e.cx]
+		}
+		for x := len(chars) - 1; x >= 0; x-- {
+			switch chars[x] {
+			case c:
+				depth++
+			case open:
+				if depth == 0 {
+					return y
+				}
+				depth--
+			}
+		}
+	}
+	return -1
+}
+
+// softTabWidth returns how many characters Backspace deletes at the cursor. In the
+// leading spaces of a buffer indented with spaces, it goes back to the previous
+// indentation level at once. Otherwise a single character is deleted.
+func (e *Editor) softTabWidth() int {
+	if !e.config.SoftTabBackspace || !e.indent.expandTab || e.cy >= e.totalRows || e.cx == 0 {
+		return 1
+	}
+	if len(bytes.TrimLeft(e.row[e.cy].chars[:e.cx], " ")) > 0 {
+		return 1 // Not in the indentation
+	}
+	unit := e.indent.indentWidth()
+	if n := e.cx % unit; n > 0 {
+		return n
+	}
+	return unit
+}
+
+// dedentWidth returns how many leading bytes of chars make up one indentation level
+func (e *Editor) dedentWidth(chars []byte) int {
+	if len(chars) > 0 && chars[0] == '\t' {
+		return 1
+	}
+	n := 0
+	for n < len(chars) && n < e.indent.indentWidth() && chars[n] == ' ' {
+		n++
+	}
+	return n
+}
+
+// IndentSelection adds one indentation level to every selected row.
+// Empty rows are left alone.
+func (e *Editor) IndentSelection() {
+	first, last, ok := e.selectedRows()
+	if !ok {
+		return
+	}
+
+	unit := e.indentUnit()
+	changed := false
+	for at := first; at <= last; at++ {
+		row := &e.row[at]
+		if len(row.chars) == 0 {
+			continue
+		}
+		e.saveRow(row)
+		row.chars = slices.Insert(row.chars, 0, unit...)
+		row.Update(e)
+		e.shiftColumns(at, len(unit))
+		changed = true
+	}
+	if changed {
+		e.dirty++
+	}
+}
+
+// DedentSelection removes one indentation level from every selected row.
+// Rows without leading whitespace are left alone.
+func (e *Editor) DedentSelection() {
+	first, last, ok := e.selectedRows()
+	if !ok {
+		return
+	}
+
+	changed := false
+	for at := first; at <= last; at++ {
+		row := &e.row[at]
+		n := e.dedentWidth(row.chars)
+		if n == 0 {
+			continue
+		}
+		e.saveRow(row)
+		row.chars = slices.Delete(row.chars, 0, n)
+		row.Update(e)
+		e.shiftColumns(at, -n)
+		changed = true
+	}
+	if changed {
+		e.dirty++
+	}
+}
+
+// ShiftLine changes the indentation of the cursor row by the given number of levels,
+// rewriting its leading whitespace in the file's indentation style. The cursor stays
+// on the same text.
+func (e *Editor) ShiftLine(levels int) {
+	if e.cy >= e.totalRows {
+		return
+	}
+	row := &e.row[e.cy]
+	lead := leadingWhitespace(row.chars)
+	unit := e.indent.indentWidth()
+	width := row.cxToRx(e, len(lead))
+
+	newWidth := max((width/unit+levels)*unit, 0)
+	if levels < 0 && width%unit != 0 {
+		newWidth = (width/unit + levels + 1) * unit // Dedent to the previous level first
+	}
+
+	var newLead []byte
+	if e.indent.expandTab {
+		newLead = []byte(strings.Repeat(" ", newWidth))
+	} else {
+		newLead = []byte(strings.Repeat("\t", newWidth/e.tabStop()) + strings.Repeat(" ", newWidth%e.tabStop()))
+	}
+	e.setLeadingWhitespace(newLead)
+}
+
+// setLeadingWhitespace replaces the leading whitespace of the cursor row with newLead.
+// The cursor stays on the same text.
+func (e *Editor) setLeadingWhitespace(newLead []byte) {
+	row := &e.row[e.cy]
+	lead := leadingWhitespace(row.chars)
+	if string(newLead) == string(lead) {
+		return
+	}
+
+	oldLen := len(lead)
+	e.saveRow(row)
+	row.chars = slices.Concat(newLead, row.chars[oldLen:])
+	row.Update(e)
+	if e.cx >= oldLen {
+		e.cx += len(newLead) - oldLen
+	} else {
+		e.cx = min(e.cx, len(newLead))
+	}
+	e.dirty++
+}
+
+// shiftColumns moves the cursor and selection anchor by delta bytes if they are
+// on the given row, so they stay on the same text after the leading whitespace changed
+func (e *Editor) shiftColumns(at int, delta int) {
+	rowlen := len(e.row[at].chars)
+	if e.cy == at && e.cx > 0 {
+		e.cx = min(max(e.cx+delta, 0), rowlen)
+	}
+	if e.selection.anchorY == at && e.selection.anchorX > 0 {
+		e.selection.anchorX = min(max(e.selection.anchorX+delta, 0), rowlen)
+	}
+}
+
+// leadingWhitespace returns the run of spaces and tabs at the start of chars
+func leadingWhitespace(chars []byte) []byte {
+	n := 0
+	for n < len(chars) && (chars[n] == ' ' || chars[n] == '\t') {
+		n++
+	}
+	return chars[:n]
+}
+
+// linePrefixes returns the list and comment markers that are continued on Enter
+// for the current filetype
+func (e *Editor) linePrefixes() []string {
+	filetype := ""
+	if e.syntax != nil {
+		filetype = e.syntax.filetype
+	}
+	prefixes := e.config.ContinuePrefixes[filetype]
+	if e.config.ContinueComments && e.syntax != nil && e.syntax.singlelineCommentStart != "" {
+		prefixes = append([]string{e.syntax.singlelineCommentStart}, prefixes...)
+	}
+	return prefixes
+}
+
+// continuationPrefix returns the start of line that a new line split off after it
+// should begin with: its indentation (if auto-indent is on) followed by any list or
+// comment marker and the whitespace after it. isMarker reports whether a marker was found.
+func (e *Editor) continuationPrefix(line []byte) (prefix []byte, isMarker bool) {
+	indent := len(leadingWhitespace(line))
+	for _, p := range e.linePrefixes() {
+		marker := strings.TrimRight(p, " ")
+		if marker == "" || !bytes.HasPrefix(line[indent:], []byte(marker)) {
+			continue
+		}
+		end := indent + len(marker)
+		// Markers configured with a trailing space, like "- ", must be followed by one
+		if len(marker) < len(p) && (end == len(line) || line[end] != ' ') {
+			continue
+		}
+		end += len(leadingWhitespace(line[end:]))
+		return line[:end], true
+	}
+
+	if e.config.AutoIndent {
+		return line[:indent], false
+	}
+	return nil, false
+}
+
+// countIndentation returns how many non-blank rows indent with tabs and how many with spaces.
+// A row counts by the first character of its indentation, so that spaces aligning text
+// after tabs, like in "\t * comment", don't count as indenting with spaces.
+func (e *Editor) countIndentation() (tabs, spaces int) {
+	for i := range e.row {
+		chars := e.row[i].chars
+		indent := leadingWhitespace(chars)
+		if len(indent) == 0 || len(indent) == len(chars) {
+			continue // Blank rows have no indentation to speak of
+		}
+		if indent[0] == '\t' {
+			tabs++
+		} else {
+			spaces++
+		}
+	}
+	return tabs, spaces
+}
+
+// CheckMixedIndentation warns in the status bar if the file indents with both tabs and spaces
+func (e *Editor) CheckMixedIndentation() bool {
+	tabs, spaces := e.countIndentation()
+	if tabs == 0 || spaces == 0 {
+		return false
+	}
+	e.ShowWarning("mixed indentation: %d lines use tabs, %d use spaces (Alt-I to normalize)", tabs, spaces)
+	return true
+}
+
+// NormalizeIndentation rewrites the indentation of every row to use only tabs or only
+// spaces, keeping its width. It returns the number of rows changed.
+func (e *Editor) NormalizeIndentation(useTabs bool) int {
+	changed := 0
+	for i := range e.row {
+		row := &e.row[i]
+		indent := leadingWhitespace(row.chars)
+		if len(indent) == len(row.chars) {
+			continue
+		}
+
+		tabStop := e.tabStop()
+		width := 0
+		for _, c := range indent {
+			if c == '\t' {
+				width += tabStop - width%tabStop
+			} else {
+				width++
+			}
+		}
+
+		var normalized []byte
+		if useTabs {
+			normalized = append(bytes.Repeat([]byte("\t"), width/tabStop), bytes.Repeat([]byte(" "), width%tabStop)...)
+		} else {
+			normalized = bytes.Repeat([]byte(" "), width)
+		}
+		if bytes.Equal(indent, normalized) {
+			continue
+		}
+
+		if e.cy == i {
+			e.cx = max(e.cx+len(normalized)-len(indent), 0)
+		}
+		e.saveRow(row)
+		row.chars = append(normalized, row.chars[len(indent):]...)
+		row.Update(e)
+		changed++
+	}
+	if changed > 0 {
+		e.dirty++
+	}
+	return changed
+}
+
+// NormalizeIndentationPrompt asks whether to normalize the indentation to tabs or spaces
+func (e *Editor) NormalizeIndentationPrompt() {
+	tabs, spaces := e.countIndentation()
+	key := e.PromptKey("Indentation: %d lines use tabs, %d use spaces. Normalize to (t)abs or (s)paces? (ESC to cancel)", tabs, spaces)
+
+	var changed int
+	switch key {
+	case 't', 'T':
+		changed = e.NormalizeIndentation(true)
+	case 's', 'S':
+		changed = e.NormalizeIndentation(false)
+	default:
+		e.SetStatusMessage("")
+		return
+	}
+	e.SetStatusMessage("Normalized indentation of %d lines", changed)
+}
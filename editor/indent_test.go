@@ -0,0 +1,287 @@
+package editor
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestShiftLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		expandTab bool
+		line      string
+		levels    int
+		expected  string
+	}{
+		{"indent with spaces", true, "  x", 1, "    x"},
+		{"indent normalizes tab", true, "\tx", 1, "        x"},
+		{"dedent to previous level", true, "      x", -1, "    x"},
+		{"dedent at column zero", true, "x", -1, "x"},
+		{"indent with tabs", false, "    x", 1, "\t\tx"},
+		{"dedent with tabs", false, "\t  x", -1, "\tx"},
+	}
+	for _, tt := range tests {
+		e := newTestEditor(10, 80, tt.line)
+		e.indent = indentStyle{expandTab: tt.expandTab, width: 4}
+		e.cx = len(tt.line) - 1 // on the "x"
+		e.ShiftLine(tt.levels)
+		if string(e.row[0].chars) != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, e.row[0].chars)
+		}
+		if e.row[0].chars[e.cx] != 'x' {
+			t.Errorf("%s: expected cursor to stay on x, got cx=%d", tt.name, e.cx)
+		}
+	}
+}
+
+func TestSoftTabBackspace(t *testing.T) {
+	tests := []struct {
+		name      string
+		expandTab bool
+		line      string
+		cx        int
+		expected  string
+	}{
+		{"whole level", true, "        x", 8, "    x"},
+		{"back to the previous level", true, "      x", 6, "    x"},
+		{"inside the indentation", true, "        x", 4, "    x"},
+		{"after text", true, "    x   y", 8, "    x  y"},
+		{"with tabs", false, "        x", 8, "       x"},
+	}
+	for _, tt := range tests {
+		e := newTestEditor(10, 80, tt.line)
+		e.config = DefaultConfig()
+		e.indent = indentStyle{expandTab: tt.expandTab, width: 4}
+		e.cx = tt.cx
+		e.DeleteChar()
+		if string(e.row[0].chars) != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, e.row[0].chars)
+		}
+	}
+
+	e := newTestEditor(10, 80, "        x")
+	e.indent = indentStyle{expandTab: true, width: 4}
+	e.cx = 8
+	e.DeleteChar()
+	if string(e.row[0].chars) != "       x" {
+		t.Errorf("Expected a single space deleted with SoftTabBackspace off, got %q", e.row[0].chars)
+	}
+
+	// Delete removes a single space, also inside the indentation
+	e = newTestEditor(10, 80, "        x")
+	e.config = DefaultConfig()
+	e.output = newOutput(io.Discard)
+	e.indent = indentStyle{expandTab: true, width: 4}
+	e.cx = 3
+	pressKeys(e, "\x1b[3~")
+	if string(e.row[0].chars) != "       x" || e.cx != 3 {
+		t.Errorf("Expected Delete to remove a single space, got %q at %d", e.row[0].chars, e.cx)
+	}
+}
+
+func TestSplitLine(t *testing.T) {
+	e := newTestEditor(10, 80, "\tfoo(a, b)")
+	e.config = DefaultConfig()
+	e.cx = len("\tfoo(a,")
+
+	// Unlike Enter, the indentation isn't copied and the cursor stays
+	e.SplitLine()
+	if got := e.Lines(); !slices.Equal(got, []string{"\tfoo(a,", " b)"}) {
+		t.Errorf("Expected the line to be split without indentation, got %q", got)
+	}
+	if e.cy != 0 || e.cx != len("\tfoo(a,") {
+		t.Errorf("Expected the cursor to stay at (0, 7), got (%d, %d)", e.cy, e.cx)
+	}
+}
+
+func TestInsertTab(t *testing.T) {
+	e := newTestEditor(10, 80, "ab")
+	e.output = newOutput(io.Discard)
+	e.config = DefaultConfig()
+	e.indent = indentStyle{expandTab: true, width: 4}
+	e.cx = 2
+
+	// With expandtab, Tab inserts spaces up to the next indentation level
+	pressKeys(e, "\t")
+	if got := string(e.row[0].chars); got != "ab  " {
+		t.Errorf("Expected spaces up to column 4, got %q", got)
+	}
+
+	// Ctrl-V inserts the tab character itself
+	pressKeys(e, "\x16\t") // read by one keypress
+	if got := string(e.row[0].chars); got != "ab  \t" {
+		t.Errorf("Expected a literal tab, got %q", got)
+	}
+
+	e.indent = indentStyle{expandTab: false}
+	pressKeys(e, "\t")
+	if got := string(e.row[0].chars); got != "ab  \t\t" {
+		t.Errorf("Expected a tab character without expandtab, got %q", got)
+	}
+}
+
+func TestDedentClosingBracket(t *testing.T) {
+	e := newTestEditor(10, 80, "func f() {", "\t\tx", "\t\t")
+	e.output = newOutput(io.Discard)
+	e.config = DefaultConfig()
+	e.filename = "f.go"
+	e.SelectSyntaxHighlight()
+	e.cx, e.cy = 2, 2
+
+	// The bracket lines up with the line of the opening bracket
+	pressKeys(e, "}")
+	if got := string(e.row[2].chars); got != "}" {
+		t.Errorf("Expected the line to be dedented, got %q", got)
+	}
+	if e.cx != 1 {
+		t.Errorf("Expected the cursor after the bracket, got cx=%d", e.cx)
+	}
+
+	// Brackets after other text are typed as they are
+	e.cx, e.cy = 3, 1
+	pressKeys(e, ")")
+	if got := string(e.row[1].chars); got != "\t\tx)" {
+		t.Errorf("Expected no dedent after text, got %q", got)
+	}
+
+	// Files without a filetype aren't dedented by default
+	e = newTestEditor(10, 80, "\t")
+	e.output = newOutput(io.Discard)
+	e.config = DefaultConfig()
+	e.cx = 1
+	pressKeys(e, "}")
+	if got := string(e.row[0].chars); got != "\t}" {
+		t.Errorf("Expected no dedent without a filetype, got %q", got)
+	}
+}
+
+func TestDedentClosingBracketToOpeningLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		key      string
+		expected string
+	}{
+		{"block at the same level", []string{"\tif x {", "\t"}, "}", "\t}"},
+		{"block one level deeper", []string{"\tif x {", "\t\ty()", "\t\t"}, "}", "\t}"},
+		{"composite literal", []string{"\tx := []int{", "1,", "\t"}, "}", "\t}"},
+		{"nested brackets", []string{"f(a, {", "\t}, b,", "\t\t"}, ")", ")"},
+		{"never deeper", []string{"\t\tif x {", "\t"}, "}", "\t}"},
+		{"no opening bracket", []string{"\t\t"}, "}", "\t\t}"},
+	}
+	for _, tt := range tests {
+		e := newTestEditor(10, 80, tt.lines...)
+		e.output = newOutput(io.Discard)
+		e.config = DefaultConfig()
+		e.filename = "f.go"
+		e.SelectSyntaxHighlight()
+		e.cy = len(tt.lines) - 1
+		e.cx = len(e.row[e.cy].chars)
+		pressKeys(e, tt.key)
+		if got := string(e.row[e.cy].chars); got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestInsertNewlineContinuesMarkers(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		line     string
+		expected []string
+	}{
+		{"list item", "", "- item", []string{"- item", "- "}},
+		{"indented list item", "", "  * item", []string{"  * item", "  * "}},
+		{"quote", "", "> quote", []string{"> quote", "> "}},
+		{"comment", "f.go", "\t// note", []string{"\t// note", "\t// "}},
+		{"empty list item ends the list", "", "  - ", []string{"  "}},
+		{"empty quote ends the quote", "", "> ", []string{""}},
+		{"empty comment ends the comment", "f.go", "// ", []string{""}},
+		{"dash alone is text", "", "-", []string{"-", ""}},
+		{"quote marker alone is text", "", ">", []string{">", ""}},
+		{"dash before a word is text", "", "-item", []string{"-item", ""}},
+		{"comment marker alone continues", "f.go", "//", []string{"//", "//"}},
+	}
+	for _, tt := range tests {
+		e := newTestEditor(10, 80, tt.line)
+		e.config = DefaultConfig()
+		e.filename = tt.filename
+		e.SelectSyntaxHighlight()
+		e.cx = len(tt.line)
+		e.InsertNewline()
+		if got := e.Lines(); !slices.Equal(got, tt.expected) {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, got)
+		}
+		last := tt.expected[len(tt.expected)-1]
+		if e.cy != len(tt.expected)-1 || e.cx != len(last) {
+			t.Errorf("%s: expected the cursor at %d,%d, got %d,%d", tt.name, len(last), len(tt.expected)-1, e.cx, e.cy)
+		}
+	}
+}
+
+func TestCheckMixedIndentation(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		expected bool
+	}{
+		{"tabs", []string{"func f() {", "\tx()", "\t\ty()", "}"}, false},
+		{"tab-indented block comment", []string{"func f() {", "\t/*", "\t * text", "\t */", "\tx()", "}"}, false},
+		{"spaces", []string{"def f():", "    x()", "        y()"}, false},
+		{"tabs and spaces", []string{"if x {", "\ty()", "    z()", "}"}, true},
+	}
+	for _, tt := range tests {
+		e := newTestEditor(10, 80, tt.lines...)
+		if got := e.CheckMixedIndentation(); got != tt.expected {
+			t.Errorf("%s: expected a warning %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestDetectIndent(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		expected indentStyle
+		ok       bool
+	}{
+		{"tabs", []string{"func f() {", "\tif x {", "\t\ty()", "\t}", "}"}, indentStyle{expandTab: false}, true},
+		{"tabs with aligned comment", []string{"/*", " * doc", " */", "func f() {", "\tx()", "\ty()", "}"}, indentStyle{expandTab: false}, true},
+		{"2 spaces", []string{"a:", "  b:", "    c: 1", "  d: 2", "", "e:", "  f: 3"}, indentStyle{expandTab: true, width: 2}, true},
+		{"4 spaces", []string{"def f():", "    if x:", "        y()", "    return z", "", "def g():", "    pass"}, indentStyle{expandTab: true, width: 4}, true},
+		{"no indentation", []string{"a", "b", "", "c"}, indentStyle{}, false},
+	}
+	for _, tt := range tests {
+		e := newTestEditor(10, 80, tt.lines...)
+		style, ok := detectIndent(e.row)
+		if ok != tt.ok || style != tt.expected {
+			t.Errorf("%s: expected %+v %v, got %+v %v", tt.name, tt.expected, tt.ok, style, ok)
+		}
+	}
+}
+
+func TestApplyIndentStyle(t *testing.T) {
+	e := newTestEditor(10, 80)
+	e.config = DefaultConfig()
+	e.config.ExpandTab, e.config.IndentWidth = false, 8
+
+	e.Load(strings.NewReader("def f():\n  return 1\n"), "f.py")
+	if e.indent != (indentStyle{expandTab: true, width: 2}) || e.indent.String() != "spaces:2" {
+		t.Errorf("Expected 2 spaces to be detected, got %v", e.indent)
+	}
+
+	// Detection can be switched off, and Makefiles always indent with tabs
+	e.config.DetectIndent = false
+	e.Load(strings.NewReader("def f():\n  return 1\n"), "f.py")
+	if e.indent != e.configuredIndent() {
+		t.Errorf("Expected the configured indentation, got %v", e.indent)
+	}
+	e.config.DetectIndent = true
+	e.Load(strings.NewReader("all:\n    echo\n"), "Makefile")
+	if e.indent.expandTab || e.indent.String() != "tabs" {
+		t.Errorf("Expected tabs in a Makefile, got %v", e.indent)
+	}
+}
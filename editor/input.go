@@ -0,0 +1,87 @@
+package editor
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+var errReadTimeout = errors.New("read timed out")
+
+// input delivers raw bytes from the terminal.
+// The blocking reads happen on a background goroutine so that callers can wait with a timeout.
+type input struct {
+	bytes    chan byte
+	err      error  // set before bytes is closed
+	consumed []byte // bytes read since consumed was last cleared, for the diagnostics log
+}
+
+// newInput starts reading from r in the background
+func newInput(r io.Reader) *input {
+	in := &input{bytes: make(chan byte, 64)}
+	go in.pump(r)
+	return in
+}
+
+func (in *input) pump(r io.Reader) {
+	defer close(in.bytes)
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		for _, b := range buf[:n] {
+			in.bytes <- b
+		}
+		if err != nil {
+			in.err = err
+			return
+		}
+	}
+}
+
+// SetInput makes the editor read its keys from r instead of the terminal, for
+// example to replay keystrokes recorded in a file with ReplayInput
+func (e *Editor) SetInput(r io.Reader) {
+	e.input = newInput(r)
+}
+
+// ReplayInput processes the keys from the input until it ends, drawing the screen
+// before each like the main loop does. A prompt or modal screen that is still open
+// when the input ends is cancelled.
+func (e *Editor) ReplayInput() error {
+	for {
+		e.RefreshScreen()
+		key, err := e.readKey()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		e.processKey(key)
+	}
+}
+
+// readByte waits for the next input byte. A timeout <= 0 waits indefinitely.
+func (in *input) readByte(timeout time.Duration) (byte, error) {
+	if timeout <= 0 {
+		b, ok := <-in.bytes
+		if !ok {
+			return 0, in.err
+		}
+		in.consumed = append(in.consumed, b)
+		return b, nil
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case b, ok := <-in.bytes:
+		if !ok {
+			return 0, in.err
+		}
+		in.consumed = append(in.consumed, b)
+		return b, nil
+	case <-timer.C:
+		return 0, errReadTimeout
+	}
+}
@@ -0,0 +1,77 @@
+package editor
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplayInput(t *testing.T) {
+	// Keystrokes as recorded from a terminal: type two lines, search the first
+	// one from the end of the file and insert a word where the search ended
+	recording := filepath.Join(t.TempDir(), "session.keys")
+	keys := "hello world\rsecond line" + "\x06hello\r" + "big "
+	os.WriteFile(recording, []byte(keys), 0644)
+	file, err := os.Open(recording)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	e := newTestEditor(10, 80)
+	e.config = DefaultConfig()
+	e.SetOutput(io.Discard)
+	e.SetInput(file)
+	if err := e.ReplayInput(); err != nil {
+		t.Fatalf("Expected the input to be replayed, got %v", err)
+	}
+
+	if got := e.Lines(); !slices.Equal(got, []string{"big hello world", "second line"}) {
+		t.Errorf("Unexpected lines after replaying: %q", got)
+	}
+	if e.cx != 4 || e.cy != 0 {
+		t.Errorf("Expected the cursor after the inserted word, got (%d,%d)", e.cy, e.cx)
+	}
+}
+
+func TestReplayInputCancelsOpenPrompt(t *testing.T) {
+	e := newTestEditor(10, 80, "text")
+	e.config = DefaultConfig()
+	e.SetOutput(io.Discard)
+	e.SetInput(strings.NewReader("\x06tex"))
+	if err := e.ReplayInput(); err != nil {
+		t.Fatalf("Expected the input to be replayed, got %v", err)
+	}
+	if e.cy != 0 || e.cx != 0 {
+		t.Errorf("Expected the cancelled search to restore the cursor, got (%d,%d)", e.cy, e.cx)
+	}
+}
+
+func TestLoneEscapeWithoutTimeout(t *testing.T) {
+	for _, timeout := range []time.Duration{0, -time.Second} {
+		e := newTestEditor(10, 80)
+		e.config.EscapeTimeout = timeout
+		r, w := io.Pipe()
+		defer w.Close()
+		e.input = newInput(r)
+		go w.Write([]byte("\x1b"))
+
+		keys := make(chan int, 1)
+		go func() {
+			key, _ := e.readKey()
+			keys <- key
+		}()
+		select {
+		case key := <-keys:
+			if key != '\x1b' {
+				t.Errorf("Timeout %v: expected ESC, got %d", timeout, key)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timeout %v: expected a lone ESC not to wait for the next key", timeout)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package editor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// commandKeys maps the names of commands that can be bound in Config.Keymap to
+// the key that runs them by default
+var commandKeys = map[string]int{
+	"line-start":  HOME_KEY,
+	"line-end":    END_KEY,
+	"smart-home":  SMART_HOME_KEY,
+	"file-start":  CTRL_HOME_KEY,
+	"file-end":    CTRL_END_KEY,
+	"page-up":     PAGE_UP,
+	"page-down":   PAGE_DOWN,
+	"explorer":    withControlKey('e'),
+	"quick-open":  withControlKey('o'),
+	"find":        withControlKey('f'),
+	"goto":        withControlKey('g'),
+	"help":        withControlKey('h'),
+	"save":        withControlKey('s'),
+	"quit":        withControlKey('q'),
+	"undo":        withControlKey('z'),
+	"redo":        withControlKey('y'),
+	"select":      withControlKey('b'),
+	"copy":        withControlKey('c'),
+	"cut":         withControlKey('x'),
+	"paste":       withControlKey('u'),
+	"window":      withControlKey('w'),
+	"diagnostics": withAltKey('g'),
+}
+
+// keyNames are the keys besides "ctrl-<letter>" and "alt-<char>" that can be bound
+var keyNames = map[string]int{
+	"home":      HOME_KEY,
+	"end":       END_KEY,
+	"ctrl-home": CTRL_HOME_KEY,
+	"ctrl-end":  CTRL_END_KEY,
+	"pageup":    PAGE_UP,
+	"pagedown":  PAGE_DOWN,
+}
+
+// normalizeKeyName returns the way parseKeyName spells a key name like "Ctrl-A", in
+// lowercase except for the character of an Alt key, since Alt+Shift+D differs from Alt+D
+func normalizeKeyName(name string) string {
+	lower := strings.ToLower(name)
+	if c, ok := strings.CutPrefix(lower, "alt-"); ok && len(c) == 1 {
+		return "alt-" + name[len(name)-1:]
+	}
+	return lower
+}
+
+// parseKeyName returns the key for a name like "ctrl-a", "alt-x" or "home"
+func parseKeyName(name string) (int, error) {
+	name = normalizeKeyName(name)
+	if key, ok := keyNames[name]; ok {
+		return key, nil
+	}
+	if c, ok := strings.CutPrefix(name, "ctrl-"); ok && len(c) == 1 && c[0] >= 'a' && c[0] <= 'z' {
+		return withControlKey(int(c[0])), nil
+	}
+	if c, ok := strings.CutPrefix(name, "alt-"); ok && len(c) == 1 && c[0] > ' ' && c[0] < BACKSPACE {
+		return withAltKey(int(c[0])), nil
+	}
+	return 0, fmt.Errorf("unknown key %q", name)
+}
+
+// parseKeymap returns which key each bound key stands in for. Invalid bindings are
+// left out and reported in the error.
+func parseKeymap(bindings map[string]string) (map[int]int, error) {
+	keymap := make(map[int]int, len(bindings))
+	var errs []string
+	for name, command := range bindings {
+		key, err := parseKeyName(name)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		target, ok := commandKeys[command]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("unknown command %q for %s", command, name))
+			continue
+		}
+		keymap[key] = target
+	}
+	if len(errs) > 0 {
+		return keymap, fmt.Errorf("keymap: %s", strings.Join(errs, ", "))
+	}
+	return keymap, nil
+}
+
+// mapKey returns the key that runs the command bound to key, or key itself if it
+// isn't rebound. Keys are mapped once, so keys can be swapped.
+func (e *Editor) mapKey(key int) int {
+	if target, ok := e.keymap[key]; ok {
+		return target
+	}
+	return key
+}
@@ -0,0 +1,61 @@
+package editor
+
+import (
+	"io"
+	"testing"
+)
+
+func TestParseKeyName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected int
+	}{
+		{"ctrl-a", withControlKey('a')},
+		{"Ctrl-E", withControlKey('e')},
+		{"alt-D", withAltKey('D')},
+		{"home", HOME_KEY},
+		{"ctrl-end", CTRL_END_KEY},
+	}
+	for _, tt := range tests {
+		if key, err := parseKeyName(tt.name); err != nil || key != tt.expected {
+			t.Errorf("parseKeyName(%q) = %d, %v, expected %d", tt.name, key, err, tt.expected)
+		}
+	}
+	for _, name := range []string{"ctrl-1", "alt-", "hyper-x", ""} {
+		if _, err := parseKeyName(name); err == nil {
+			t.Errorf("parseKeyName(%q) should fail", name)
+		}
+	}
+}
+
+func TestKeymapRebindsKeys(t *testing.T) {
+	e := newTestEditor(10, 80, "    indented line")
+	e.output = newOutput(io.Discard)
+	config := DefaultConfig()
+	config.Keymap = map[string]string{
+		"ctrl-a": "smart-home",
+		"ctrl-e": "line-end", // takes the place of the explorer
+		"alt-f":  "explorer",
+		"ctrl-b": "no-such-command",
+	}
+	e.SetConfig(config)
+	if _, ok := e.keymap[withControlKey('b')]; ok || e.statusMessage == "" {
+		t.Errorf("Expected the unknown command to be skipped with a warning, got %q", e.statusMessage)
+	}
+	if e.mapKey(withAltKey('f')) != withControlKey('e') {
+		t.Errorf("Expected Alt-F to open the explorer")
+	}
+
+	pressKeys(e, "\x05")
+	if e.cx != len("    indented line") || e.mode != EDIT_MODE {
+		t.Errorf("Expected Ctrl-E to move to the line end, got cx %d in mode %d", e.cx, e.mode)
+	}
+	pressKeys(e, "\x01")
+	if e.cx != 4 {
+		t.Errorf("Expected Ctrl-A to move to the first non-blank character, got %d", e.cx)
+	}
+	pressKeys(e, "\x01")
+	if e.cx != 0 {
+		t.Errorf("Expected a second Ctrl-A to move to the line start, got %d", e.cx)
+	}
+}
@@ -0,0 +1,115 @@
+package editor
+
+import "bytes"
+
+// ReverseLines reverses the order of the selected rows. The selection keeps
+// covering the same rows, which now hold the reversed lines.
+func (e *Editor) ReverseLines() {
+	first, last, ok := e.selectedRows()
+	if !ok || first == last {
+		e.SetStatusMessage("Select several lines to reverse")
+		return
+	}
+
+	for i, j := first, last; i < j; i, j = i+1, j-1 {
+		e.saveRow(&e.row[i])
+		e.saveRow(&e.row[j])
+		e.row[i].chars, e.row[j].chars = e.row[j].chars, e.row[i].chars
+	}
+	for at := first; at <= last; at++ {
+		e.row[at].Update(e)
+	}
+	e.clampSelection()
+	e.dirty++
+	e.SetStatusMessage("Reversed %d lines", last-first+1)
+}
+
+// RemoveDuplicateLines deletes the selected rows, or all rows without a selection,
+// that repeat an earlier line. With adjacentOnly only a line equal to the one right
+// before it is removed, like uniq does. Otherwise every line is kept only where it
+// occurs first. It returns the number of removed lines.
+func (e *Editor) RemoveDuplicateLines(adjacentOnly bool) int {
+	first, last, ok := e.selectedRows()
+	if !ok {
+		first, last = 0, e.totalRows-1
+	}
+
+	seen := make(map[string]bool)
+	removed := 0
+	for at := first; at <= last; {
+		chars := e.row[at].chars
+		duplicate := seen[string(chars)]
+		if adjacentOnly {
+			duplicate = at > first && bytes.Equal(e.row[at-1].chars, chars)
+		}
+		if !duplicate {
+			seen[string(chars)] = true
+			at++
+			continue
+		}
+
+		e.DeleteRow(at)
+		last--
+		removed++
+		// Keep the cursor and the selection anchor on the same lines, or on the line
+		// before if theirs was removed
+		if e.cy >= at {
+			e.cy--
+		}
+		if e.selection.anchorY >= at {
+			e.selection.anchorY--
+		}
+	}
+	e.clampSelection()
+
+	e.SetStatusMessage("Removed %d duplicate lines", removed)
+	return removed
+}
+
+// DuplicateLines copies the selected rows, or the cursor's row without a selection,
+// below themselves, or above themselves for a negative direction. The cursor and the
+// selection stay on the original lines.
+func (e *Editor) DuplicateLines(direction int) {
+	first, last, ok := e.selectedRows()
+	if !ok {
+		if e.cy >= e.totalRows {
+			return
+		}
+		first, last = e.cy, e.cy
+	}
+
+	count := last - first + 1
+	at := last + 1
+	if direction < 0 {
+		at = first
+	}
+	copies := make([][]byte, count)
+	for i := range count {
+		copies[i] = e.row[first+i].chars
+	}
+	for i, chars := range copies {
+		e.InsertRow(at+i, chars, len(chars))
+	}
+
+	// Copies above push the original lines down
+	if direction < 0 {
+		e.cy += count
+		if e.selection.active {
+			e.selection.anchorY += count
+		}
+	}
+	e.SetStatusMessage("Duplicated %d lines", count)
+}
+
+// clampSelection keeps the cursor and the selection anchor within the buffer
+// after rows were replaced or removed
+func (e *Editor) clampSelection() {
+	e.cy = min(e.cy, e.totalRows)
+	e.selection.anchorY = min(e.selection.anchorY, e.totalRows)
+	if e.cy < e.totalRows {
+		e.cx = min(e.cx, len(e.row[e.cy].chars))
+	}
+	if e.selection.anchorY < e.totalRows {
+		e.selection.anchorX = min(e.selection.anchorX, len(e.row[e.selection.anchorY].chars))
+	}
+}
@@ -0,0 +1,108 @@
+package editor
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestReverseLines(t *testing.T) {
+	e := newTestEditor(10, 80, "before", "a", "bb", "ccc", "after")
+	e.selection = selection{active: true, anchorX: 0, anchorY: 1}
+	e.cy, e.cx = 4, 0 // Ending at column 0 leaves the last row out
+
+	e.ReverseLines()
+	if got := e.Lines(); !slices.Equal(got, []string{"before", "ccc", "bb", "a", "after"}) {
+		t.Errorf("Unexpected lines after reversing: %q", got)
+	}
+	if e.dirty == 0 || !e.selection.active || e.cy != 4 {
+		t.Errorf("Expected a modified buffer with the selection kept, got dirty %d at row %d", e.dirty, e.cy)
+	}
+	for i := range e.row {
+		if e.rowIndex(&e.row[i]) != i || e.row[i].renderWidth != len(e.row[i].chars) {
+			t.Errorf("Row %d is not up to date after reversing", i)
+		}
+	}
+}
+
+func TestReverseLinesNeedsSeveralLines(t *testing.T) {
+	e := newTestEditor(10, 80, "one", "two")
+	e.selection = selection{active: true, anchorX: 0, anchorY: 0}
+	e.cx = 2
+
+	e.ReverseLines()
+	if got := e.Lines(); !slices.Equal(got, []string{"one", "two"}) || e.dirty != 0 {
+		t.Errorf("Expected nothing to change for a single line, got %q", got)
+	}
+}
+
+func TestRemoveDuplicateLines(t *testing.T) {
+	lines := []string{"a", "a", "b", "a", "b", "b", "c"}
+	tests := []struct {
+		adjacentOnly bool
+		expected     []string
+	}{
+		{true, []string{"a", "b", "a", "b", "c"}},
+		{false, []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		e := newTestEditor(10, 80, lines...)
+		e.cy = 6 // On "c"
+
+		removed := e.RemoveDuplicateLines(tt.adjacentOnly)
+		if got := e.Lines(); !slices.Equal(got, tt.expected) || removed != len(lines)-len(tt.expected) {
+			t.Errorf("adjacentOnly=%v: got %q with %d removed", tt.adjacentOnly, got, removed)
+		}
+		if e.totalRows != len(tt.expected) || string(e.row[e.cy].chars) != "c" {
+			t.Errorf("adjacentOnly=%v: expected the cursor to stay on \"c\", got row %d", tt.adjacentOnly, e.cy)
+		}
+	}
+}
+
+func TestRemoveDuplicateLinesInSelection(t *testing.T) {
+	e := newTestEditor(10, 80, "x", "x", "y", "y", "z", "z")
+	e.selection = selection{active: true, anchorX: 0, anchorY: 1}
+	e.cy, e.cx = 3, 1
+
+	e.RemoveDuplicateLines(true)
+	if got := e.Lines(); !slices.Equal(got, []string{"x", "x", "y", "z", "z"}) {
+		t.Errorf("Expected only the selected rows to be deduplicated, got %q", got)
+	}
+	if e.selection.anchorY != 1 || e.cy != 2 || e.cx != 1 {
+		t.Errorf("Expected the selection to cover rows 1-2, got %d-%d", e.selection.anchorY, e.cy)
+	}
+}
+
+func TestDuplicateLines(t *testing.T) {
+	tests := []struct {
+		direction int
+		selected  bool
+		want      []string
+		cy        int
+	}{
+		{1, false, []string{"a", "b", "b", "c"}, 1},
+		{-1, false, []string{"a", "b", "b", "c"}, 2},
+		{1, true, []string{"a", "b", "c", "b", "c"}, 2},
+		{-1, true, []string{"a", "b", "c", "b", "c"}, 4},
+	}
+	for _, tt := range tests {
+		e := newTestEditor(10, 80, "a", "b", "c")
+		e.cy, e.cx = 1, 1
+		if tt.selected {
+			e.selection = selection{active: true, anchorX: 0, anchorY: 1}
+			e.cy = 2
+		}
+
+		e.DuplicateLines(tt.direction)
+		if got := e.Lines(); !slices.Equal(got, tt.want) {
+			t.Errorf("direction %d, selected %v: got lines %q, expected %q", tt.direction, tt.selected, got, tt.want)
+		}
+		if e.cy != tt.cy || e.dirty == 0 {
+			t.Errorf("direction %d, selected %v: expected the cursor on row %d of a modified buffer, got row %d", tt.direction, tt.selected, tt.cy, e.cy)
+		}
+		if tt.selected {
+			if first, last, _ := e.selectedRows(); last-first != 1 || first != tt.cy-1 {
+				t.Errorf("direction %d: expected the selection on the original lines, got rows %d-%d", tt.direction, first, last)
+			}
+		}
+	}
+}
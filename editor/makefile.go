@@ -0,0 +1,75 @@
+package editor
+
+import "bytes"
+
+// makeTargetLength returns the length of the targets of a make rule like
+// "all: build", up to the colon, or 0 if the line isn't a rule
+func makeTargetLength(line []byte) int {
+	if len(line) == 0 || line[0] == ' ' || line[0] == '\t' || line[0] == '#' {
+		return 0
+	}
+	colon := bytes.IndexByte(line, ':')
+	if colon <= 0 || bytes.IndexByte(line[:colon], '=') >= 0 {
+		return 0
+	}
+	// Variable assignments with := and ::= aren't rules
+	rest := line[colon+1:]
+	if bytes.HasPrefix(rest, []byte("=")) || bytes.HasPrefix(rest, []byte(":=")) {
+		return 0
+	}
+	return colon
+}
+
+// makeVariableLength returns the length of a variable reference like $(CC) or
+// ${FLAGS} at the start of s, including nested references, or 0 if there is none
+func makeVariableLength(s []byte) int {
+	if len(s) < 2 || s[0] != '$' || (s[1] != '(' && s[1] != '{') {
+		return 0
+	}
+	open, close := s[1], byte(')')
+	if open == '{' {
+		close = '}'
+	}
+	depth := 0
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return 0 // Not closed on this line
+}
+
+// CheckRecipeIndentation warns about recipe lines of make rules that start with
+// spaces, since make only accepts a tab there. It reports whether there were any.
+func (e *Editor) CheckRecipeIndentation() bool {
+	count, first := 0, 0
+	inRule, continued := false, false
+	for i := range e.row {
+		chars := e.row[i].chars
+		switch {
+		case continued || isBlankLine(chars):
+			// Continuation lines may be indented in any way
+		case makeTargetLength(chars) > 0:
+			inRule = true
+		case chars[0] == ' ' && inRule:
+			if count == 0 {
+				first = i + 1
+			}
+			count++
+		case chars[0] != '\t' && chars[0] != '#':
+			inRule = false
+		}
+		continued = bytes.HasSuffix(chars, []byte("\\"))
+	}
+	if count == 0 {
+		return false
+	}
+	e.ShowWarning("%d recipe lines start with spaces instead of a tab, first on line %d", count, first)
+	return true
+}
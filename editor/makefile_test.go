@@ -0,0 +1,49 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMakefileHighlighting(t *testing.T) {
+	e := newTestEditor(10, 80)
+	e.config = DefaultConfig()
+	e.Load(strings.NewReader("CC := gcc\nbuild: main.c # compile\n\t$(CC) -o $@ main.c"), "Makefile")
+
+	checks := []struct {
+		row, col, expected int
+		what               string
+	}{
+		{0, 0, HL_NORMAL, "an assignment with := isn't a target"},
+		{1, 0, HL_KEYWORD1, "the target"},
+		{1, 4, HL_KEYWORD1, "the end of the target"},
+		{1, 5, HL_NORMAL, "the colon"},
+		{1, 15, HL_COMMENT, "the comment"},
+		{2, TAB_STOP, HL_KEYWORD2, "the variable reference"},
+		{2, TAB_STOP + 4, HL_KEYWORD2, "the end of the variable reference"},
+	}
+	for _, c := range checks {
+		if got := e.row[c.row].hl[c.col]; got != c.expected {
+			t.Errorf("Expected %s to be highlighted as %d, got %d", c.what, c.expected, got)
+		}
+	}
+}
+
+func TestMakefileRequiresTabs(t *testing.T) {
+	e := newTestEditor(10, 80)
+	e.config = DefaultConfig()
+	e.config.ExpandTab = true
+	e.Load(strings.NewReader("all:\n    echo spaces\n"), "build.mk")
+
+	if e.indent.expandTab {
+		t.Errorf("Expected Makefiles to indent with tabs despite expandtab")
+	}
+	if !e.CheckRecipeIndentation() || !strings.Contains(e.statusMessage, "line 2") {
+		t.Errorf("Expected a warning about the recipe indented with spaces, got %q", e.statusMessage)
+	}
+
+	e.Load(strings.NewReader("SRCS = a.c \\\n    b.c\nall:\n\techo tab\n"), "Makefile")
+	if e.CheckRecipeIndentation() {
+		t.Errorf("Expected no warning for continuation lines and recipes indented with tabs")
+	}
+}
@@ -0,0 +1,55 @@
+package editor
+
+import "bytes"
+
+// markdownHeaderLevel returns the level of a Markdown header like "## Usage", the
+// number of '#' it starts with, or 0 if the line isn't a header
+func markdownHeaderLevel(line []byte) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(line) && line[level] != ' ') {
+		return 0
+	}
+	return level
+}
+
+// markdownFenceLength returns the length of the fence that opens or closes a Markdown
+// code block, like "```" or "~~~", with the indentation before it, or 0 if the line
+// isn't a fence. The info string after it, like a language name, isn't included.
+func markdownFenceLength(line []byte) int {
+	indent := len(line) - len(bytes.TrimLeft(line, " "))
+	if indent > 3 || indent == len(line) || (line[indent] != '`' && line[indent] != '~') {
+		return 0
+	}
+	end := indent
+	for end < len(line) && line[end] == line[indent] {
+		end++
+	}
+	if end-indent < 3 {
+		return 0
+	}
+	return end
+}
+
+// yamlKeyRange returns the columns of the key of a YAML mapping entry like
+// "name: value" or "- name:", or ok false if the line has none. Quoted keys and
+// flow collections are left to the string highlighting.
+func yamlKeyRange(line []byte) (start, end int, ok bool) {
+	start = len(line) - len(bytes.TrimLeft(line, " "))
+	if bytes.HasPrefix(line[start:], []byte("- ")) {
+		start += 2
+	}
+	for end = start; end < len(line); end++ {
+		switch line[end] {
+		case ':':
+			if end > start && (end+1 == len(line) || line[end+1] == ' ') {
+				return start, end, true
+			}
+		case '#', '"', '\'', '{', '[':
+			return 0, 0, false
+		}
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,93 @@
+package editor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuiltinLanguageHighlighting(t *testing.T) {
+	tests := []struct {
+		filename, text string
+		row, col       int
+		expected       int
+		what           string
+	}{
+		{"app.py", "def f():\n    \"\"\"Docs\"\"\"\n    return None", 0, 0, HL_KEYWORD1, "a Python keyword"},
+		{"app.py", "def f():\n    \"\"\"Docs\"\"\"\n    return None", 1, 6, HL_MLCOMMENT, "a docstring"},
+		{"app.py", "def f():\n    \"\"\"Docs\"\"\"\n    return None", 2, 11, HL_KEYWORD2, "None"},
+		{"app.js", "const s = `id ${x}`", 0, 10, HL_STRING, "a template literal"},
+		{"lib.rs", "fn f<'a>(s: &'a str) -> u8 { 1 }", 0, 5, HL_NORMAL, "a lifetime"},
+		{"lib.rs", "fn f<'a>(s: &'a str) -> u8 { 1 }", 0, 16, HL_KEYWORD2, "a type"},
+		{"data.json", `  "n": 12, "ok": true,`, 0, 3, HL_STRING, "a key"},
+		{"data.json", `  "n": 12, "ok": true,`, 0, 7, HL_NUMBER, "a number"},
+		{"data.json", `  "n": 12, "ok": true,`, 0, 17, HL_KEYWORD2, "a literal"},
+		{"ci.yml", "on: push # trigger", 0, 1, HL_KEYWORD1, "a key that is also a literal"},
+		{"ci.yml", "on: push # trigger", 0, 9, HL_COMMENT, "a comment"},
+		{"run.sh", "echo \"$HOME\" # home", 0, 0, HL_KEYWORD2, "a builtin"},
+		{"run.sh", "echo \"$HOME\" # home", 0, 14, HL_COMMENT, "a comment"},
+		{"run.sh", "# setup\necho $# args", 0, 2, HL_COMMENT, "a comment at the line start"},
+		{"run.sh", "# setup\necho $# args", 1, 7, HL_NORMAL, "the argument count $#"},
+		{"run.sh", "# setup\necho $# args", 1, 9, HL_NORMAL, "the text after $#"},
+		{"run.sh", "n=${#x} # length", 0, 4, HL_NORMAL, "a length expansion ${#x}"},
+		{"run.sh", "n=${#x} # length", 0, 10, HL_COMMENT, "a comment after an expansion"},
+		{"ci.yml", "color: a#b", 0, 9, HL_NORMAL, "a # inside a value"},
+	}
+	for _, tt := range tests {
+		e := newTestEditor(10, 80)
+		e.config = DefaultConfig()
+		e.Load(strings.NewReader(tt.text), tt.filename)
+		if got := e.row[tt.row].hl[tt.col]; got != tt.expected {
+			t.Errorf("%s: expected %s to be highlighted as %d, got %d", tt.filename, tt.what, tt.expected, got)
+		}
+	}
+}
+
+func TestMarkdownHighlighting(t *testing.T) {
+	e := newTestEditor(10, 80)
+	e.config = DefaultConfig()
+	e.Load(strings.NewReader("## Step 2\nRun `make 3` in 10 s, don't wait\n#hashtag\n```go\nx := 1\n```"), "README.md")
+
+	checks := []struct {
+		row, col, expected int
+		what               string
+	}{
+		{0, 0, HL_KEYWORD1, "the header marker"},
+		{0, 8, HL_KEYWORD1, "the header text"},
+		{1, 4, HL_STRING, "a code span"},
+		{1, 15, HL_NORMAL, "a number in prose"},
+		{1, 27, HL_NORMAL, "an apostrophe"},
+		{2, 0, HL_NORMAL, "a # without a space"},
+		{3, 2, HL_STRING, "a code fence"},
+		{3, 3, HL_NORMAL, "the language of a code fence"},
+		{5, 2, HL_STRING, "a closing code fence"},
+	}
+	for _, c := range checks {
+		if got := e.row[c.row].hl[c.col]; got != c.expected {
+			t.Errorf("Expected %s to be highlighted as %d, got %d", c.what, c.expected, got)
+		}
+	}
+}
+
+func TestYAMLKeyRange(t *testing.T) {
+	tests := []struct {
+		line       string
+		start, end int
+		ok         bool
+	}{
+		{"name: kigo", 0, 4, true},
+		{"  build:", 2, 7, true},
+		{"- run: make", 2, 5, true},
+		{"on: push", 0, 2, true},
+		{"url: http://example.com", 0, 3, true},
+		{"- item", 0, 0, false},
+		{"# key: value", 0, 0, false},
+		{"\"quoted\": 1", 0, 0, false},
+		{"time: 12:30", 0, 4, true},
+	}
+	for _, tt := range tests {
+		start, end, ok := yamlKeyRange([]byte(tt.line))
+		if start != tt.start || end != tt.end || ok != tt.ok {
+			t.Errorf("yamlKeyRange(%q) = %d, %d, %v, expected %d, %d, %v", tt.line, start, end, ok, tt.start, tt.end, tt.ok)
+		}
+	}
+}
@@ -1,5 +1,13 @@
 package editor
 
+import (
+	"errors"
+	"io"
+)
+
+// MODAL_SCROLL_STEP is the number of columns Left/Right scroll modal content by
+const MODAL_SCROLL_STEP = 8
+
 // ModalScreen represents a modal screen interface that can be displayed in the editor
 type ModalScreen interface {
 	// GetContent returns the content rows to display
@@ -17,6 +25,9 @@ type ModalScreen interface {
 
 	// Initialize sets up the initial cursor position and any other screen-specific setup
 	Initialize(e *Editor)
+
+	// Resize renders the content rows again after the screen size changed
+	Resize(e *Editor)
 }
 
 // handles the common logic for modal screens
@@ -37,6 +48,10 @@ func NewModalManager(editor *Editor, screen ModalScreen) *ModalManager {
 
 // displays the modal screen and handles the interaction loop
 func (m *ModalManager) Show(mode int) {
+	defer m.editor.useWholeScreen()()
+	defer func(modal *ModalManager) { m.editor.modal = modal }(m.editor.modal)
+	m.editor.modal = m
+
 	content := m.screen.GetContent()
 	m.setupModalDisplay(content, mode)
 
@@ -47,11 +62,21 @@ func (m *ModalManager) Show(mode int) {
 	for {
 		m.editor.RefreshScreen()
 
-		key, err := readKey()
-		if err != nil {
+		key, err := m.editor.readKey()
+		if errors.Is(err, io.EOF) {
+			key = '\x1b' // Input that ended closes the screen
+		} else if err != nil {
 			m.editor.ShowError("%v", err)
 			continue
 		}
+		if key == withControlKey('l') {
+			m.editor.Redraw()
+			continue
+		}
+		if key == ARROW_LEFT || key == ARROW_RIGHT {
+			m.scrollHorizontally(key)
+			continue
+		}
 
 		shouldClose, shouldRestore := m.screen.HandleKey(key, m.editor)
 		if shouldClose {
@@ -66,8 +91,10 @@ func (m *ModalManager) Show(mode int) {
 // configures the editor for modal display
 func (m *ModalManager) setupModalDisplay(content []editorRow, mode int) {
 	m.editor.mode = mode
-	m.editor.row = content
-	m.editor.totalRows = len(content)
+	m.editor.showModalRows(content)
+	// The selection and additional cursors belong to the file's rows
+	m.editor.clearSelection()
+	m.editor.clearCursors()
 	m.editor.cx = 0
 	m.editor.cy = 0
 	m.editor.colOffset = 0
@@ -80,3 +107,48 @@ func (m *ModalManager) restoreState() {
 	m.editor.setEditorState(m.savedState)
 	m.editor.SetStatusMessage("Returned to editor")
 }
+
+// resized lays out the modal again for a changed screen size: the content is rendered
+// again and the view is kept within the content. The saved editor state is left as it
+// is and fitted to the screen when it is restored.
+func (m *ModalManager) resized() {
+	m.screen.Resize(m.editor)
+	m.editor.colOffset = min(m.editor.colOffset, m.maxColOffset())
+	m.editor.invalidateFrame()
+}
+
+// maxColOffset returns the column offset at which the end of the widest row is visible
+func (m *ModalManager) maxColOffset() int {
+	e := m.editor
+	widest := 0
+	for i := range e.row {
+		widest = max(widest, e.row[i].renderWidth)
+	}
+	return max(widest-e.textCols(), 0)
+}
+
+// scrollHorizontally moves the view over the content sideways, so that long lines
+// can be read. The view stops when the end of the widest row is visible.
+func (m *ModalManager) scrollHorizontally(key int) {
+	e := m.editor
+	if key == ARROW_LEFT {
+		e.colOffset = max(e.colOffset-MODAL_SCROLL_STEP, 0)
+	} else {
+		e.colOffset = min(e.colOffset+MODAL_SCROLL_STEP, m.maxColOffset())
+	}
+}
+
+// updateRows renders the given modal content rows again
+func updateRows(rows []editorRow, e *Editor) {
+	for i := range rows {
+		rows[i].Update(e)
+	}
+}
+
+// showModalRows puts the rows of a modal screen into the editor in place of the file's rows
+func (e *Editor) showModalRows(rows []editorRow) {
+	e.row = rows
+	e.totalRows = len(rows)
+	e.indexedRows = 0
+	e.contentVersion++
+}
@@ -0,0 +1,133 @@
+package editor
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestModalScrollHorizontally(t *testing.T) {
+	e := newTestEditor(5, 20, "file content")
+	e.config = DefaultConfig()
+	help := NewHelpScreen(e)
+	m := NewModalManager(e, help)
+	m.setupModalDisplay(help.GetContent(), HELP_MODE)
+	help.Initialize(e)
+
+	widest := 0
+	for _, row := range help.content {
+		widest = max(widest, row.renderWidth)
+	}
+
+	m.scrollHorizontally(ARROW_RIGHT)
+	e.Scroll()
+	if e.colOffset != MODAL_SCROLL_STEP {
+		t.Fatalf("Expected the view to scroll right by %d, got %d", MODAL_SCROLL_STEP, e.colOffset)
+	}
+	for range widest {
+		m.scrollHorizontally(ARROW_RIGHT)
+	}
+	if e.colOffset != widest-e.textCols() {
+		t.Errorf("Expected the view to stop at %d, got %d", widest-e.textCols(), e.colOffset)
+	}
+
+	var abuf appendBuffer
+	e.DrawRows(&abuf)
+	if strings.Contains(string(abuf.b), "=== KIGO HELP ===") {
+		t.Errorf("Expected the start of the rows to be scrolled out of view")
+	}
+
+	m.scrollHorizontally(ARROW_LEFT)
+	m.restoreState()
+	if e.colOffset != 0 || e.mode != EDIT_MODE {
+		t.Errorf("Expected the editor's view to be restored, got column offset %d", e.colOffset)
+	}
+}
+
+func TestModalResizeRestoresLayout(t *testing.T) {
+	lines := numberedLines(60)
+	lines[40] = strings.Repeat("x", 100)
+	e := newTestEditor(30, 40, lines...)
+	e.config = DefaultConfig()
+	e.output = newOutput(io.Discard)
+	e.cy, e.cx = 40, 90
+	e.selection = selection{active: true, anchorX: 0, anchorY: 38}
+	e.RefreshScreen()
+	// A row inserted at the top leaves the numbering of the rows below out of date
+	e.InsertRow(0, []byte("new"), 3)
+	e.cy++
+	e.selection.anchorY++
+
+	help := NewHelpScreen(e)
+	m := NewModalManager(e, help)
+	m.setupModalDisplay(help.GetContent(), HELP_MODE)
+	help.Initialize(e)
+	if e.selection.active {
+		t.Errorf("Expected no selection over the help rows")
+	}
+	for range 20 {
+		m.scrollHorizontally(ARROW_RIGHT)
+	}
+	e.RefreshScreen()
+
+	// The terminal gets wider but less tall while the help is shown
+	e.setScreenSize(12, 200)
+	m.resized()
+	if e.colOffset != 0 {
+		t.Errorf("Expected the help view to stay within its content, got column offset %d", e.colOffset)
+	}
+	e.RefreshScreen()
+
+	m.restoreState()
+	e.RefreshScreen()
+	if e.cy < e.rowOffset || e.cy >= e.rowOffset+e.screenRows {
+		t.Errorf("Expected the cursor row %d on screen, got rows %d-%d", e.cy, e.rowOffset, e.rowOffset+e.screenRows-1)
+	}
+	if e.rx < e.colOffset || e.rx >= e.colOffset+e.textCols() {
+		t.Errorf("Expected the cursor column %d on screen, got columns from %d", e.rx, e.colOffset)
+	}
+	if len(e.frame.lines) != e.screenRows+2 {
+		t.Errorf("Expected %d lines drawn, got %d", e.screenRows+2, len(e.frame.lines))
+	}
+	if !e.selection.active || e.selection.anchorY != 39 {
+		t.Errorf("Expected the selection to be restored, got %+v", e.selection)
+	}
+	for i := range e.row {
+		if got := e.rowIndex(&e.row[i]); got != i {
+			t.Fatalf("Expected row %d to have index %d after closing the help, got %d", i, i, got)
+		}
+	}
+}
+
+// resizeCountingScreen is the help screen, counting how often it is resized
+type resizeCountingScreen struct {
+	*HelpScreen
+	resizes int
+}
+
+func (s *resizeCountingScreen) Resize(e *Editor) {
+	s.resizes++
+	s.HelpScreen.Resize(e)
+}
+
+func TestModalRedrawResizes(t *testing.T) {
+	e := newTestEditor(10, 40, "file content")
+	e.config = DefaultConfig()
+	e.output = newOutput(io.Discard)
+	screen := &resizeCountingScreen{HelpScreen: NewHelpScreen(e)}
+
+	// Ctrl-L takes the size of the terminal again, like in the editor, and Esc closes
+	e.input = newInput(strings.NewReader("\x0c\x1b"))
+	NewModalManager(e, screen).Show(HELP_MODE)
+	if screen.resizes != 1 {
+		t.Errorf("Expected the help to be resized once, got %d", screen.resizes)
+	}
+	if e.modal != nil {
+		t.Errorf("Expected no modal screen after closing the help")
+	}
+
+	e.Redraw()
+	if screen.resizes != 1 {
+		t.Errorf("Expected a closed help not to be resized, got %d", screen.resizes)
+	}
+}
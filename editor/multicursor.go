@@ -0,0 +1,158 @@
+package editor
+
+import (
+	"bytes"
+	"slices"
+)
+
+// cursor is an additional cursor position used for simultaneous editing
+type cursor struct {
+	x, y int
+}
+
+// hasExtraCursors reports whether edits apply at more than the primary cursor
+func (e *Editor) hasExtraCursors() bool {
+	return len(e.cursors) > 0
+}
+
+// clearCursors removes all additional cursors
+func (e *Editor) clearCursors() {
+	e.cursors = nil
+}
+
+// hasCursorAt reports whether the primary or an additional cursor is at the given position
+func (e *Editor) hasCursorAt(x, y int) bool {
+	return (e.cx == x && e.cy == y) || slices.Contains(e.cursors, cursor{x, y})
+}
+
+// lastCursor returns the additional cursor added last, or the primary cursor
+func (e *Editor) lastCursor() cursor {
+	if len(e.cursors) == 0 {
+		return cursor{e.cx, e.cy}
+	}
+	return e.cursors[len(e.cursors)-1]
+}
+
+// AddCursorBelow adds a cursor on the line below the last added cursor,
+// in the same render column as the primary cursor
+func (e *Editor) AddCursorBelow() {
+	last := e.lastCursor()
+	if e.cy >= e.totalRows || last.y+1 >= e.totalRows {
+		e.SetStatusMessage("No line below to add a cursor")
+		return
+	}
+
+	rx := e.row[e.cy].cxToRx(e, e.cx)
+	y := last.y + 1
+	e.cursors = append(e.cursors, cursor{e.row[y].rxToCx(e, rx), y})
+	e.SetStatusMessage("%d cursors", len(e.cursors)+1)
+}
+
+// AddCursorAtNextOccurrence adds a cursor at the next whole-word occurrence of the word
+// under the primary cursor, after the last added cursor and wrapping around the file
+func (e *Editor) AddCursorAtNextOccurrence() {
+	word := e.wordAtCursor()
+	if word == nil {
+		e.SetStatusMessage("No word under cursor")
+		return
+	}
+
+	// Place new cursors at the same offset within the word as the primary cursor
+	chars := e.row[e.cy].chars
+	wordStart := e.cx
+	for wordStart > 0 && !isSeparator(int(chars[wordStart-1])) {
+		wordStart--
+	}
+	offset := e.cx - wordStart
+
+	last := e.lastCursor()
+	y, from := last.y, last.x-offset+1
+	for i := 0; i <= e.totalRows; i++ {
+		row := e.row[y].chars
+		for at := max(from, 0); at+len(word) <= len(row); {
+			match := bytes.Index(row[at:], word)
+			if match == -1 {
+				break
+			}
+			match += at
+			at = match + 1
+			if !isWholeWord(row, match, match+len(word)) || e.hasCursorAt(match+offset, y) {
+				continue
+			}
+			e.cursors = append(e.cursors, cursor{match + offset, y})
+			e.SetStatusMessage("%d cursors", len(e.cursors)+1)
+			return
+		}
+		y = (y + 1) % e.totalRows
+		from = 0
+	}
+	e.SetStatusMessage("No more occurrences of '%s'", word)
+}
+
+// forEachCursor runs edit once at every cursor, with e.cx/e.cy set to it.
+// Cursors are processed from the bottom of the file to the top, and right to left
+// within a line, and cursors on the same line are shifted by what the edit inserted
+// or deleted. The edit must stay within the cursor's line.
+func (e *Editor) forEachCursor(edit func()) {
+	all := append([]cursor{{e.cx, e.cy}}, e.cursors...)
+	order := make([]int, len(all))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		if all[a].y != all[b].y {
+			return all[b].y - all[a].y
+		}
+		return all[b].x - all[a].x
+	})
+
+	for _, i := range order {
+		c := all[i]
+		rowLen := 0
+		if c.y < e.totalRows {
+			rowLen = len(e.row[c.y].chars)
+		}
+
+		e.cx, e.cy = c.x, c.y
+		edit()
+		all[i] = cursor{e.cx, e.cy}
+
+		delta := len(e.row[c.y].chars) - rowLen
+		for j := range all {
+			if j != i && all[j].y == c.y && all[j].x > c.x {
+				all[j].x += delta
+			}
+		}
+	}
+
+	e.cx, e.cy = all[0].x, all[0].y
+	e.cursors = e.cursors[:0]
+	for _, c := range all[1:] {
+		if !e.hasCursorAt(c.x, c.y) {
+			e.cursors = append(e.cursors, c)
+		}
+	}
+}
+
+// MoveCursors moves every cursor left or right within its line
+func (e *Editor) MoveCursors(key int) {
+	e.forEachCursor(func() {
+		if key == ARROW_LEFT && e.cx > 0 {
+			e.cx--
+		}
+		if key == ARROW_RIGHT && e.cy < e.totalRows && e.cx < len(e.row[e.cy].chars) {
+			e.cx++
+		}
+	})
+}
+
+// extraCursorColumns returns the render columns of the additional cursors on the given row
+func (e *Editor) extraCursorColumns(filerow int) []int {
+	var columns []int
+	for _, c := range e.cursors {
+		if c.y == filerow {
+			columns = append(columns, e.row[filerow].cxToRx(e, min(c.x, len(e.row[filerow].chars))))
+		}
+	}
+	return columns
+}
@@ -0,0 +1,35 @@
+package editor
+
+import "testing"
+
+func TestForEachCursorInsertAndDelete(t *testing.T) {
+	e := newTestEditor(10, 80, "foo bar foo", "foo")
+	e.cx, e.cy = 0, 0
+	e.AddCursorAtNextOccurrence()
+	e.AddCursorAtNextOccurrence()
+	if len(e.cursors) != 2 {
+		t.Fatalf("Expected 2 additional cursors, got %d", len(e.cursors))
+	}
+
+	e.forEachCursor(func() { e.InsertChar('x') })
+	expected := []string{"xfoo bar xfoo", "xfoo"}
+	for i, want := range expected {
+		if got := string(e.row[i].chars); got != want {
+			t.Errorf("Row %d: expected %q, got %q", i, want, got)
+		}
+	}
+	if e.cx != 1 || e.cy != 0 {
+		t.Errorf("Expected primary cursor at (1,0), got (%d,%d)", e.cx, e.cy)
+	}
+
+	e.forEachCursor(func() { e.DeleteChar() })
+	expected = []string{"foo bar foo", "foo"}
+	for i, want := range expected {
+		if got := string(e.row[i].chars); got != want {
+			t.Errorf("Row %d: expected %q, got %q", i, want, got)
+		}
+	}
+	if e.cursors[0] != (cursor{8, 0}) || e.cursors[1] != (cursor{0, 1}) {
+		t.Errorf("Unexpected cursor positions %v", e.cursors)
+	}
+}
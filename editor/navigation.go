@@ -0,0 +1,77 @@
+package editor
+
+import (
+	"strconv"
+	"strings"
+)
+
+// EnsureCursorVisible scrolls the view to the cursor after it was moved by a jump. The view
+// stays put if the cursor is already on screen, otherwise the cursor's line is centered.
+func (e *Editor) EnsureCursorVisible() {
+	if e.cy < e.rowOffset || e.cy >= e.rowOffset+e.screenRows {
+		e.rowOffset = min(max(e.cy-e.screenRows/2, 0), max(e.totalRows-e.screenRows, 0))
+	}
+	e.Scroll()
+}
+
+// GotoLine moves the cursor to the start of the given 1-based line, clamped to the file
+func (e *Editor) GotoLine(line int) {
+	e.cy = min(max(line-1, 0), max(e.totalRows-1, 0))
+	e.cx = 0
+	e.EnsureCursorVisible()
+}
+
+// SmartHome moves the cursor to the first non-blank character of the line, or to
+// the start of the line if it is already there
+func (e *Editor) SmartHome() {
+	if e.cy >= e.totalRows {
+		e.cx = 0
+		return
+	}
+	indent := len(leadingWhitespace(e.row[e.cy].chars))
+	if e.cx == indent {
+		e.cx = 0
+	} else {
+		e.cx = indent
+	}
+}
+
+// GotoFileStart moves the cursor to the start of the first line
+func (e *Editor) GotoFileStart() {
+	e.GotoLine(1)
+}
+
+// GotoFileEnd moves the cursor to the start of the last line
+func (e *Editor) GotoFileEnd() {
+	e.GotoLine(e.totalRows)
+}
+
+// GotoPercent moves the cursor to the start of the line the given percentage through the file
+func (e *Editor) GotoPercent(pct int) {
+	pct = min(max(pct, 0), 100)
+	e.cy = min(pct*e.totalRows/100, max(e.totalRows-1, 0))
+	e.cx = 0
+	e.EnsureCursorVisible()
+}
+
+// Goto prompts for a line number, or a percentage of the file like "50%", and jumps there
+func (e *Editor) Goto() {
+	input := e.Prompt("Go to line or percentage: %s (ESC to cancel)", nil)
+	if input == "" {
+		return
+	}
+
+	input = strings.TrimSpace(input)
+	pct, isPercent := strings.CutSuffix(input, "%")
+	n, err := strconv.Atoi(pct)
+	if err != nil {
+		e.ShowError("not a line number or percentage: %s", input)
+		return
+	}
+
+	if isPercent {
+		e.GotoPercent(n)
+	} else {
+		e.GotoLine(n)
+	}
+}
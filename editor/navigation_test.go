@@ -0,0 +1,127 @@
+package editor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func numberedLines(n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	return lines
+}
+
+func TestEnsureCursorVisible(t *testing.T) {
+	tests := []struct {
+		name      string
+		rowOffset int
+		cy        int
+		expected  int
+	}{
+		{"already visible", 10, 15, 10},
+		{"far below is centered", 0, 50, 45},
+		{"far above is centered", 80, 20, 15},
+		{"near the top", 50, 2, 0},
+		{"near the bottom", 0, 98, 90},
+	}
+	for _, tt := range tests {
+		e := newTestEditor(10, 80, numberedLines(100)...)
+		e.rowOffset = tt.rowOffset
+		e.cy = tt.cy
+		e.EnsureCursorVisible()
+		if e.rowOffset != tt.expected {
+			t.Errorf("%s: expected row offset %d, got %d", tt.name, tt.expected, e.rowOffset)
+		}
+	}
+}
+
+func TestJumpsScrollToCursor(t *testing.T) {
+	e := newTestEditor(10, 80, numberedLines(100)...)
+
+	e.GotoLine(60)
+	if e.cy != 59 || e.rowOffset != 54 {
+		t.Errorf("Expected line 60 centered, got row %d at offset %d", e.cy, e.rowOffset)
+	}
+
+	e.FindCallback([]byte("line 3"), '3')
+	if e.cy != 2 || e.rowOffset != 0 {
+		t.Errorf("Expected the match on row 2 to be shown, got row %d at offset %d", e.cy, e.rowOffset)
+	}
+	e.FindCallback([]byte("line 3"), ARROW_DOWN)
+	if e.cy != 29 || e.rowOffset != 24 {
+		t.Errorf("Expected the match on row 29 centered, got row %d at offset %d", e.cy, e.rowOffset)
+	}
+}
+
+func TestGotoFileStartAndEnd(t *testing.T) {
+	e := newTestEditor(10, 80, numberedLines(50)...)
+	e.config = DefaultConfig()
+	e.output = newOutput(io.Discard)
+	e.cx = 3
+
+	pressKeys(e, "\x1b[1;5F")
+	if e.cy != 49 || e.cx != 0 || e.rowOffset+e.screenRows <= e.cy {
+		t.Errorf("Expected the cursor on the last line in view, got row %d col %d offset %d", e.cy, e.cx, e.rowOffset)
+	}
+	pressKeys(e, "\x1b[1;5H")
+	if e.cy != 0 || e.rowOffset != 0 {
+		t.Errorf("Expected the cursor on the first line, got row %d offset %d", e.cy, e.rowOffset)
+	}
+
+	// A count goes to that line with either key
+	pressKeys(e, "\x1b1", "\x1b2", "\x1b[1;5H")
+	if e.cy != 11 {
+		t.Errorf("Expected a count of 12 to go to line 12, got row %d", e.cy)
+	}
+
+	empty := newTestEditor(10, 80)
+	empty.GotoFileEnd()
+	if empty.cy != 0 {
+		t.Errorf("Expected an empty buffer to keep the cursor at row 0, got %d", empty.cy)
+	}
+}
+
+func TestGotoPrompt(t *testing.T) {
+	tests := []struct {
+		input      string
+		expectedCy int
+		invalid    bool
+	}{
+		{"15", 14, false},
+		{" 7 ", 6, false},
+		{"50%", 50, false},
+		{"0%", 0, false},
+		{"100%", 99, false},
+		{"250%", 99, false},
+		{"0", 0, false},
+		{"1000", 99, false},
+		{"-5", 0, false},
+		{"abc", 30, true},
+		{"12x%", 30, true},
+		{"\x1b", 30, false},
+	}
+	for _, tt := range tests {
+		e := newTestEditor(10, 80, numberedLines(100)...)
+		e.config = DefaultConfig()
+		e.output = newOutput(io.Discard)
+		e.cy, e.cx = 30, 2
+		e.input = newInput(strings.NewReader(tt.input + "\r"))
+		e.Goto()
+		if e.cy != tt.expectedCy {
+			t.Errorf("Goto %q: expected row %d, got %d", tt.input, tt.expectedCy, e.cy)
+		}
+		if invalid := strings.Contains(e.statusMessage, "not a line number"); invalid != tt.invalid {
+			t.Errorf("Goto %q: expected an error %v, got %q", tt.input, tt.invalid, e.statusMessage)
+		}
+		if e.cy != 30 && e.cx != 0 {
+			t.Errorf("Goto %q: expected the start of the line, got column %d", tt.input, e.cx)
+		}
+		if e.cy < e.rowOffset || e.cy >= e.rowOffset+e.screenRows {
+			t.Errorf("Goto %q: expected row %d on screen, got offset %d", tt.input, e.cy, e.rowOffset)
+		}
+	}
+}
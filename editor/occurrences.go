@@ -0,0 +1,96 @@
+package editor
+
+import "bytes"
+
+// ToggleWordHighlight switches highlighting of the other occurrences of the word under the cursor
+func (e *Editor) ToggleWordHighlight() {
+	e.config.HighlightWord = !e.config.HighlightWord
+	if e.config.HighlightWord {
+		e.SetStatusMessage("Word highlighting on")
+	} else {
+		e.SetStatusMessage("Word highlighting off")
+	}
+}
+
+// wordAtCursor returns the word the cursor is on or directly behind.
+// It returns nil if the cursor is on whitespace or punctuation.
+func (e *Editor) wordAtCursor() []byte {
+	if e.cy >= e.totalRows {
+		return nil
+	}
+	chars := e.row[e.cy].chars
+	start, end, ok := wordBounds(chars, e.cx)
+	if !ok {
+		return nil
+	}
+	return chars[start:end]
+}
+
+// wordBounds returns the start and end of the word in chars that column at is on
+// or directly behind, or ok false if there is none
+func wordBounds(chars []byte, at int) (start, end int, ok bool) {
+	if at >= len(chars) || isSeparator(int(chars[at])) {
+		at-- // Cursor may be directly behind the word
+	}
+	if at < 0 || at >= len(chars) || isSeparator(int(chars[at])) {
+		return 0, 0, false
+	}
+
+	start, end = at, at
+	for start > 0 && !isSeparator(int(chars[start-1])) {
+		start--
+	}
+	for end < len(chars) && !isSeparator(int(chars[end])) {
+		end++
+	}
+	return start, end, true
+}
+
+// updateHighlightedWord remembers the word whose occurrences are highlighted in the next frame
+func (e *Editor) updateHighlightedWord() {
+	e.highlightedWord = nil
+	if !e.config.HighlightWord || e.mode != EDIT_MODE {
+		return
+	}
+	e.highlightedWord = e.wordAtCursor()
+}
+
+// wordOccurrences returns the render columns where the highlighted word occurs
+// as a whole word in the given row, skipping the occurrence under the cursor
+func (e *Editor) wordOccurrences(filerow int) []int {
+	word := e.highlightedWord
+	if len(word) == 0 {
+		return nil
+	}
+
+	row := &e.row[filerow]
+	if row.render == nil {
+		return nil // Long rows are not scanned
+	}
+	cursorRx := -1
+	if filerow == e.cy {
+		cursorRx = row.cxToRx(e, e.cx)
+	}
+
+	var occurrences []int
+	for i := 0; i+len(word) <= len(row.render); {
+		match := bytes.Index(row.render[i:], word)
+		if match == -1 {
+			break
+		}
+		start, end := i+match, i+match+len(word)
+		i = end
+
+		if start > 0 && !isSeparator(int(row.render[start-1])) {
+			continue
+		}
+		if end < len(row.render) && !isSeparator(int(row.render[end])) {
+			continue
+		}
+		if cursorRx >= start && cursorRx <= end {
+			continue
+		}
+		occurrences = append(occurrences, start)
+	}
+	return occurrences
+}
@@ -0,0 +1,47 @@
+package editor
+
+import "testing"
+
+func TestWordOccurrencesHighlight(t *testing.T) {
+	e := newTestEditor(10, 80, "foo bar foo", "foobar foo")
+	e.config = DefaultConfig()
+	e.config.HighlightWord = true
+	e.cx = 1 // on the first "foo"
+	e.updateHighlightedWord()
+
+	first := e.displayHighlight(0, 0, e.row[0].renderWidth)
+	for k := range 3 {
+		if first[k] == HL_WORD {
+			t.Errorf("Expected the word under the cursor not to be highlighted at %d", k)
+		}
+		if first[8+k] != HL_WORD {
+			t.Errorf("Expected the other occurrence to be highlighted at %d, got %d", 8+k, first[8+k])
+		}
+	}
+	if first[4] == HL_WORD {
+		t.Errorf("Expected other words not to be highlighted")
+	}
+	second := e.displayHighlight(1, 0, e.row[1].renderWidth)
+	if second[0] == HL_WORD || second[7] != HL_WORD {
+		t.Errorf("Expected only the whole word to be highlighted in %q, got %v", e.row[1].chars, second)
+	}
+
+	e.ToggleWordHighlight()
+	e.updateHighlightedWord()
+	if e.highlightedWord != nil || len(e.wordOccurrences(0)) != 0 {
+		t.Errorf("Expected no occurrences with the highlighting switched off")
+	}
+}
+
+func TestWordOccurrencesOnWhitespace(t *testing.T) {
+	e := newTestEditor(10, 80, "foo  foo", "(foo)")
+	e.config = DefaultConfig()
+	e.config.HighlightWord = true
+	for _, at := range []struct{ cx, cy int }{{4, 0}, {0, 1}} {
+		e.cx, e.cy = at.cx, at.cy
+		e.updateHighlightedWord()
+		if e.highlightedWord != nil || len(e.wordOccurrences(0)) != 0 {
+			t.Errorf("Expected nothing highlighted at (%d,%d), got %q", at.cy, at.cx, e.highlightedWord)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package editor
+
+import (
+	"errors"
+	"io"
+)
+
+// OUTPUT_MAX_RETRIES limits how often a write that makes no progress is retried
+const OUTPUT_MAX_RETRIES = 3
+
+// output collects terminal output and writes it in one go when flushed,
+// so that a frame reaches the terminal with as few writes as possible
+type output struct {
+	w   io.Writer
+	buf []byte
+}
+
+// newOutput creates an output that writes to w
+func newOutput(w io.Writer) *output {
+	return &output{w: w}
+}
+
+// Write appends p to the pending output. It never fails.
+func (out *output) Write(p []byte) (int, error) {
+	out.buf = append(out.buf, p...)
+	return len(p), nil
+}
+
+// WriteString appends s to the pending output
+func (out *output) WriteString(s string) {
+	out.buf = append(out.buf, s...)
+}
+
+// Flush writes the pending output, continuing after partial writes until everything
+// is written or the writer fails. Output that could not be written is dropped.
+func (out *output) Flush() error {
+	defer func() { out.buf = out.buf[:0] }()
+
+	retries := 0
+	for written := 0; written < len(out.buf); {
+		n, err := out.w.Write(out.buf[written:])
+		written += n
+		if err != nil && !errors.Is(err, io.ErrShortWrite) {
+			return err
+		}
+		if n == 0 {
+			retries++
+			if retries > OUTPUT_MAX_RETRIES {
+				return io.ErrShortWrite
+			}
+			continue
+		}
+		retries = 0
+	}
+	return nil
+}
+
+// SetOutput makes the editor draw to w instead of the terminal
+func (e *Editor) SetOutput(w io.Writer) {
+	e.output = newOutput(w)
+}
+
+// writer returns the editor's terminal output, creating it on first use
+func (e *Editor) writer() *output {
+	if e.output == nil {
+		e.output = newOutput(e.terminal.outputFile())
+	}
+	return e.output
+}
+
+// clearScreen clears the terminal and moves the cursor to the top-left corner
+func (e *Editor) clearScreen() {
+	out := e.writer()
+	out.WriteString(CLEAR_SCREEN)
+	out.WriteString(CURSOR_HOME)
+	out.Flush()
+	e.invalidateFrame()
+}
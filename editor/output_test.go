@@ -0,0 +1,60 @@
+package editor
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// shortWriter accepts at most n bytes per call
+type shortWriter struct {
+	bytes.Buffer
+	n int
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		w.Buffer.Write(p[:w.n])
+		return w.n, io.ErrShortWrite
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestOutputFlushRetriesPartialWrites(t *testing.T) {
+	w := &shortWriter{n: 3}
+	out := newOutput(w)
+	out.WriteString("hello, ")
+	out.Write([]byte("world"))
+
+	if err := out.Flush(); err != nil {
+		t.Fatalf("Flush returned %v", err)
+	}
+	if w.String() != "hello, world" {
+		t.Errorf("Expected %q, got %q", "hello, world", w.String())
+	}
+	if len(out.buf) != 0 {
+		t.Errorf("Expected buffer to be empty after flush")
+	}
+}
+
+func TestOutputFlushGivesUpWithoutProgress(t *testing.T) {
+	out := newOutput(&shortWriter{n: 0})
+	out.WriteString("x")
+	if err := out.Flush(); err != io.ErrShortWrite {
+		t.Errorf("Expected io.ErrShortWrite, got %v", err)
+	}
+}
+
+func TestRestoreTerminalLeavesAlternateScreen(t *testing.T) {
+	var buf bytes.Buffer
+	e := newTestEditor(10, 80)
+	e.terminal = &Terminal{alternateScreen: true}
+	e.output = newOutput(&buf)
+
+	// Restoring again, like the deferred restore after Quit, writes nothing more
+	e.RestoreTerminal()
+	e.RestoreTerminal()
+	if got := buf.String(); got != ALTERNATE_SCREEN_OFF {
+		t.Errorf("Expected the alternate screen to be left once, got %q", got)
+	}
+}
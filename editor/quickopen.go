@@ -0,0 +1,247 @@
+package editor
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// QUICK_OPEN_MAX_FILES caps the number of files collected for quick open,
+// so that huge trees stay responsive
+const QUICK_OPEN_MAX_FILES = 20000
+
+// QuickOpenScreen implements the ModalScreen interface for fuzzy-finding files to open
+type QuickOpenScreen struct {
+	root      string
+	files     []string
+	truncated bool
+	query     []byte
+	matches   []string
+	content   []editorRow
+	editor    *Editor
+}
+
+// NewQuickOpenScreen lists the files below root, skipping ignored directories
+func NewQuickOpenScreen(editor *Editor, root string) *QuickOpenScreen {
+	q := &QuickOpenScreen{root: root, editor: editor}
+	q.files, q.truncated = listFiles(root, editor.config.QuickOpenIgnore)
+	q.refreshContent()
+	return q
+}
+
+// listFiles walks root and returns the relative paths of all regular files, skipping
+// entries whose name is in ignore. It stops after QUICK_OPEN_MAX_FILES files.
+func listFiles(root string, ignore []string) ([]string, bool) {
+	var files []string
+	truncated := false
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip unreadable entries instead of aborting the walk
+		}
+		if path != root && slices.Contains(ignore, d.Name()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if len(files) >= QUICK_OPEN_MAX_FILES {
+			truncated = true
+			return filepath.SkipAll
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			rel = path
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	return files, truncated
+}
+
+// fuzzyScore scores how well query matches candidate as a case-insensitive subsequence.
+// It returns false if query is not a subsequence of candidate. Consecutive matches and
+// matches at the start of a path segment or word score higher, gaps score lower.
+func fuzzyScore(query, candidate string) (int, bool) {
+	query = strings.ToLower(query)
+	lower := strings.ToLower(candidate)
+
+	score := 0
+	qi := 0
+	prevMatch := -2
+	for ci := 0; ci < len(lower) && qi < len(query); ci++ {
+		if lower[ci] != query[qi] {
+			continue
+		}
+		score += 1
+		if ci == prevMatch+1 {
+			score += 5 // Consecutive characters
+		}
+		if ci == 0 || strings.ContainsRune("/_-. ", rune(lower[ci-1])) {
+			score += 8 // Start of a path segment or word
+		}
+		if prevMatch >= 0 {
+			score -= min(ci-prevMatch-1, 3) // Gap since the previous match
+		}
+		prevMatch = ci
+		qi++
+	}
+	if qi < len(query) {
+		return 0, false
+	}
+
+	// Prefer matches in the file name over matches in the directory
+	if strings.Contains(strings.ToLower(filepath.Base(candidate)), query) {
+		score += 10
+	}
+	return score, true
+}
+
+// filter ranks the files against the current query
+func (q *QuickOpenScreen) filter() {
+	type scored struct {
+		path  string
+		score int
+	}
+	var ranked []scored
+	for _, f := range q.files {
+		if score, ok := fuzzyScore(string(q.query), f); ok {
+			ranked = append(ranked, scored{f, score})
+		}
+	}
+	slices.SortStableFunc(ranked, func(a, b scored) int {
+		if a.score != b.score {
+			return b.score - a.score
+		}
+		return len(a.path) - len(b.path)
+	})
+
+	q.matches = q.matches[:0]
+	for _, r := range ranked {
+		q.matches = append(q.matches, r.path)
+	}
+}
+
+// refreshContent rebuilds the display rows for the current query
+func (q *QuickOpenScreen) refreshContent() {
+	q.filter()
+
+	header := editorRow{idx: 0, chars: []byte("=== Quick Open: " + string(q.query) + " ===")}
+	header.Update(q.editor)
+	q.content = []editorRow{header}
+	for i, match := range q.matches {
+		row := editorRow{idx: i + 1, chars: []byte(expandTabs(match, q.editor.tabStop()))}
+		row.Update(q.editor)
+		q.content = append(q.content, row)
+	}
+}
+
+// GetContent returns the quick open content rows
+func (q *QuickOpenScreen) GetContent() []editorRow {
+	return q.content
+}
+
+// GetTitle returns the quick open screen title
+func (q *QuickOpenScreen) GetTitle() string {
+	return "Quick Open"
+}
+
+// GetStatusMessage returns the status message for the quick open screen
+func (q *QuickOpenScreen) GetStatusMessage() string {
+	more := ""
+	if q.truncated {
+		more = "+"
+	}
+	return fmt.Sprintf("Quick Open: %s - %d/%d%s files (type to filter, Enter=open, ESC=quit)",
+		q.query, len(q.matches), len(q.files), more)
+}
+
+// Initialize selects the best match
+func (q *QuickOpenScreen) Initialize(e *Editor) {
+	e.cy = 1
+	q.showContent(e)
+}
+
+// showContent puts the current content into the editor and highlights the selection
+func (q *QuickOpenScreen) showContent(e *Editor) {
+	e.showModalRows(q.content)
+	e.cy = min(max(e.cy, 1), max(len(q.content)-1, 1))
+	for i := 1; i < len(q.content); i++ {
+		for j := range q.content[i].hl {
+			q.content[i].hl[j] = HL_NORMAL
+		}
+	}
+	if e.cy < len(q.content) {
+		for j := range q.content[e.cy].hl {
+			q.content[e.cy].hl[j] = HL_MATCH
+		}
+	}
+	e.SetStatusMessage("%s", q.GetStatusMessage())
+}
+
+// Resize renders the matches again and keeps the selection highlighted
+func (q *QuickOpenScreen) Resize(e *Editor) {
+	updateRows(q.content, e)
+	q.showContent(e)
+}
+
+// HandleKey processes key presses for the quick open screen
+func (q *QuickOpenScreen) HandleKey(key int, e *Editor) (bool, bool) {
+	switch key {
+	case '\x1b':
+		return true, true // Close modal and restore previous state
+
+	case ARROW_UP:
+		if e.cy > 1 {
+			e.cy--
+		}
+
+	case ARROW_DOWN:
+		if e.cy < len(q.content)-1 {
+			e.cy++
+		}
+
+	case BACKSPACE, DELETE_KEY, withControlKey('h'):
+		if len(q.query) > 0 {
+			q.query = q.query[:len(q.query)-1]
+			q.refreshContent()
+			e.cy = 1
+		}
+
+	case '\r':
+		if e.cy < 1 || e.cy >= len(q.content) {
+			return false, false
+		}
+		if e.dirty > 0 {
+			e.SetStatusMessage("File has unsaved changes")
+			return false, false
+		}
+		path := filepath.Join(q.root, q.matches[e.cy-1])
+		if err := e.Open(path); err != nil {
+			e.ShowError("Failed to open file: %v", err)
+			return false, false
+		}
+		return true, false // Close modal but keep the opened file
+
+	default:
+		if key < 128 && !isControl(byte(key)) {
+			q.query = append(q.query, byte(key))
+			q.refreshContent()
+			e.cy = 1
+		}
+	}
+
+	q.showContent(e)
+	return false, false // Don't close modal
+}
+
+// QuickOpen opens the fuzzy file finder for the current directory
+func (e *Editor) QuickOpen() {
+	screen := NewQuickOpenScreen(e, ".")
+	modalManager := NewModalManager(e, screen)
+	modalManager.Show(QUICK_OPEN_MODE)
+}
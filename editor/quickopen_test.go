@@ -0,0 +1,42 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	if _, ok := fuzzyScore("edgo", "editor/editor.go"); !ok {
+		t.Errorf("expected 'edgo' to match 'editor/editor.go'")
+	}
+	if _, ok := fuzzyScore("xyz", "editor/editor.go"); ok {
+		t.Errorf("expected 'xyz' not to match 'editor/editor.go'")
+	}
+
+	contiguous, _ := fuzzyScore("main", "main.go")
+	scattered, _ := fuzzyScore("main", "modal_animation.go")
+	if contiguous <= scattered {
+		t.Errorf("expected contiguous match to score higher: %d <= %d", contiguous, scattered)
+	}
+}
+
+func TestListFilesSkipsIgnored(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.go", "sub/b.go", ".git/config", "node_modules/x/y.js"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, truncated := listFiles(root, []string{".git", "node_modules"})
+	slices.Sort(files)
+	if truncated || !slices.Equal(files, []string{"a.go", "sub/b.go"}) {
+		t.Errorf("listFiles = %v, %v", files, truncated)
+	}
+}
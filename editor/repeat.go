@@ -0,0 +1,28 @@
+package editor
+
+// MAX_REPEAT_COUNT caps the repeat count so that a mistyped count can't hang the editor
+const MAX_REPEAT_COUNT = 10000
+
+// repeatDigit returns the digit of an Alt+digit key, which builds up a repeat count
+func repeatDigit(key int) (int, bool) {
+	if key >= withAltKey('0') && key <= withAltKey('9') {
+		return key - withAltKey('0'), true
+	}
+	return 0, false
+}
+
+// isRepeatable reports whether the command of a key is repeated by a repeat count.
+// Commands that open a prompt or a screen, or toggle a setting, run once.
+func isRepeatable(key int) bool {
+	switch key {
+	case ARROW_UP, ARROW_DOWN, ARROW_LEFT, ARROW_RIGHT, PAGE_UP, PAGE_DOWN,
+		SHIFT_ARROW_UP, SHIFT_ARROW_DOWN, SHIFT_ARROW_LEFT, SHIFT_ARROW_RIGHT,
+		BACKSPACE, DELETE_KEY, CTRL_DELETE_KEY, '\r', '\t',
+		withAltKey('>'), withAltKey('<'), withAltKey('*'), withAltKey('#'),
+		withAltKey('d'), withAltKey('D'), withAltKey('k'),
+		withControlKey('n'), withControlKey('p'), withControlKey('d'), withAltKey(ARROW_DOWN):
+		return true
+	}
+	// Typed characters are inserted repeatedly
+	return key <= 0xff && !isControl(byte(key))
+}
@@ -0,0 +1,59 @@
+package editor
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// pressKeys feeds the raw input of each key to the editor and processes the keys
+func pressKeys(e *Editor, keys ...string) {
+	e.input = newInput(strings.NewReader(strings.Join(keys, "")))
+	for range keys {
+		e.ProcessKeypress()
+	}
+}
+
+func TestRepeatCount(t *testing.T) {
+	e := newTestEditor(10, 80, numberedLines(20)...)
+	e.output = newOutput(io.Discard)
+	e.config = DefaultConfig()
+
+	pressKeys(e, "\x1b1", "\x1b2", "\x1b[B")
+	if e.cy != 12 {
+		t.Errorf("Expected Alt-1 Alt-2 Down to move 12 lines, got row %d", e.cy)
+	}
+
+	pressKeys(e, "\x1b3", "x")
+	if got := string(e.row[12].chars); got != "xxxline 13" {
+		t.Errorf("Expected three inserted characters, got %q", got)
+	}
+
+	// The count only applies to the next command
+	pressKeys(e, "\x1b[B")
+	if e.cy != 13 {
+		t.Errorf("Expected a single move after the repeated command, got row %d", e.cy)
+	}
+}
+
+func TestRepeatCountIgnoredByOtherCommands(t *testing.T) {
+	e := newTestEditor(10, 80, "package main")
+	e.output = newOutput(io.Discard)
+	e.filename = "main.go"
+	e.SelectSyntaxHighlight()
+
+	pressKeys(e, "\x1b2", "\x1bh")
+	if !e.highlightOff || e.repeatCount != 0 {
+		t.Errorf("Expected the toggle to run once and the count to be reset")
+	}
+}
+
+func TestRepeatCountIsCapped(t *testing.T) {
+	e := newTestEditor(10, 80)
+	e.output = newOutput(io.Discard)
+	pressKeys(e, slices.Repeat([]string{"\x1b9"}, 10)...)
+	if e.repeatCount != MAX_REPEAT_COUNT {
+		t.Errorf("Expected the count to be capped at %d, got %d", MAX_REPEAT_COUNT, e.repeatCount)
+	}
+}
@@ -0,0 +1,218 @@
+package editor
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// replaceInRow replaces the matches of query in chars[from:to] and returns the new
+// chars, the number of replacements and by how many bytes the row grew
+func replaceInRow(chars, query, replacement []byte, from, to int, wholeWord bool) ([]byte, int, int) {
+	var result []byte
+	count := 0
+	last := 0
+	for at := from; at+len(query) <= to; {
+		match := bytes.Index(chars[at:to], query)
+		if match == -1 {
+			break
+		}
+		match += at
+		end := match + len(query)
+		if wholeWord && !isWholeWord(chars, match, end) {
+			at = match + 1
+			continue
+		}
+		result = append(result, chars[last:match]...)
+		result = append(result, replacement...)
+		last = end
+		at = end
+		count++
+	}
+	if count == 0 {
+		return chars, 0, 0
+	}
+	result = append(result, chars[last:]...)
+	return result, count, len(result) - len(chars)
+}
+
+// ReplaceAll replaces every match of query with replacement and returns the number of
+// replacements. With an active selection only matches fully inside it are replaced.
+func (e *Editor) ReplaceAll(query, replacement []byte, wholeWord bool) int {
+	if len(query) == 0 || e.totalRows == 0 {
+		return 0
+	}
+
+	startY, startX, endY, endX, inSelection := e.selectionBounds()
+	if !inSelection {
+		startY, startX, endY, endX = 0, 0, e.totalRows-1, len(e.row[e.totalRows-1].chars)
+	}
+	endY = min(endY, e.totalRows-1)
+
+	total := 0
+	for y := startY; y <= endY; y++ {
+		row := &e.row[y]
+		from, to := 0, len(row.chars)
+		if y == startY {
+			from = min(startX, len(row.chars))
+		}
+		if y == endY {
+			to = min(endX, len(row.chars))
+		}
+
+		chars, count, delta := replaceInRow(row.chars, query, replacement, from, to, wholeWord)
+		if count == 0 {
+			continue
+		}
+		e.saveRow(row)
+		row.chars = chars
+		row.Update(e)
+		total += count
+
+		// Keep the end of the selection behind the replaced text
+		if inSelection && y == endY {
+			if e.cy == endY && e.cx == endX {
+				e.cx += delta
+			} else {
+				e.selection.anchorX += delta
+			}
+		}
+	}
+
+	if e.cy < e.totalRows {
+		e.cx = min(e.cx, len(e.row[e.cy].chars))
+	}
+	if total > 0 {
+		e.dirty++
+	}
+	return total
+}
+
+// promptReplace prompts for a search text and its replacement, with Alt-W toggling
+// whole word matching. The replacement may be empty to delete the matches. ok is false
+// if either prompt was cancelled.
+func (e *Editor) promptReplace(scope string) (query, replacement string, wholeWord, ok bool) {
+	toggleWholeWord := func(_ []byte, key int) {
+		if key == withAltKey('w') {
+			wholeWord = !wholeWord
+		}
+	}
+	withOptions := func(label string) func(string) string {
+		return func(input string) string {
+			options := ""
+			if wholeWord {
+				options = "[word] "
+			}
+			return fmt.Sprintf("%s%s: %s%s (ESC to cancel, Alt-W whole word)", label, scope, options, input)
+		}
+	}
+
+	query = e.PromptFunc(withOptions("Replace"), toggleWholeWord)
+	if query == "" {
+		e.SetStatusMessage("Replace aborted")
+		return "", "", false, false
+	}
+	replacement, ok = e.prompt(withOptions(fmt.Sprintf("Replace '%s' with", query)), toggleWholeWord, true)
+	if !ok {
+		e.SetStatusMessage("Replace aborted")
+		return "", "", false, false
+	}
+	return query, replacement, wholeWord, true
+}
+
+// Replace prompts for a search text and its replacement and replaces all matches,
+// limited to the selection if one is active
+func (e *Editor) Replace() {
+	_, _, _, _, inSelection := e.selectionBounds()
+	scope := ""
+	if inSelection {
+		scope = " in selection"
+	}
+
+	query, replacement, wholeWord, ok := e.promptReplace(scope)
+	if !ok {
+		return
+	}
+	count := e.ReplaceAll([]byte(query), []byte(replacement), wholeWord)
+	e.SetStatusMessage("Replaced %d occurrences%s", count, scope)
+}
+
+// replaceMatch replaces the match the search is showing and continues the search
+// after the replacement, so that it is not matched again
+func (e *Editor) replaceMatch(query, replacement []byte) {
+	e.restoreSearchHighlight()
+	row := &e.row[e.cy]
+	row.replaceBytes(e, e.cx, min(e.cx+len(query), len(row.chars)), replacement)
+	e.cx += len(replacement)
+	e.find.lastRx = row.cxToRx(e, e.cx) - 1
+}
+
+// stepMatch moves to the next match with FindCallback. It reports false once there
+// is none or the search wrapped around, so that every match is offered once.
+func (e *Editor) stepMatch(query []byte) bool {
+	lastMatch, lastRx := e.find.lastMatch, e.find.lastRx
+	e.FindCallback(query, ARROW_DOWN)
+	if !e.find.hasMatch() {
+		return false
+	}
+	return e.find.lastMatch > lastMatch || (e.find.lastMatch == lastMatch && e.find.lastRx > lastRx)
+}
+
+// ReplaceInteractive prompts for a search text and its replacement, then steps through
+// the matches from the top of the file, asking at each whether to replace it. The
+// arrows move between the matches like in the search prompt.
+func (e *Editor) ReplaceInteractive() {
+	query, replacement, wholeWord, ok := e.promptReplace("")
+	if !ok {
+		return
+	}
+	q, r := []byte(query), []byte(replacement)
+
+	e.mode = SEARCH_MODE
+	e.searchWholeWord = wholeWord
+	e.searchRegex = false
+	e.restoreSearchHighlight()
+	e.find.reset()
+	e.FindCallback(q, 0)
+	defer func() {
+		e.FindCallback(q, '\x1b')
+		e.mode = EDIT_MODE
+	}()
+	if !e.find.hasMatch() {
+		e.SetStatusMessage("Pattern not found: %s", query)
+		return
+	}
+
+	count := 0
+	for {
+		key := e.PromptKey("Replace '%s' with '%s'? (y)es, (n)o, (a)ll, (q)uit, Arrows to step", query, replacement)
+		switch key {
+		case 'y', ' ':
+			e.replaceMatch(q, r)
+			count++
+			if !e.stepMatch(q) {
+				e.SetStatusMessage("Replaced %d occurrences", count)
+				return
+			}
+		case 'n':
+			if !e.stepMatch(q) {
+				e.SetStatusMessage("Replaced %d occurrences", count)
+				return
+			}
+		case 'a':
+			for {
+				e.replaceMatch(q, r)
+				count++
+				if !e.stepMatch(q) {
+					break
+				}
+			}
+			e.SetStatusMessage("Replaced %d occurrences", count)
+			return
+		case ARROW_UP, ARROW_DOWN, ARROW_LEFT, ARROW_RIGHT:
+			e.FindCallback(q, key)
+		case 'q', '\r', '\x1b':
+			e.SetStatusMessage("Replaced %d occurrences", count)
+			return
+		}
+	}
+}
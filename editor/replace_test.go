@@ -0,0 +1,120 @@
+package editor
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReplaceAllInSelection(t *testing.T) {
+	e := newTestEditor(10, 80, "foo foo", "foo bar foo", "foo")
+	// Select from the middle of the first "foo" on row 0 to the middle of the last "foo" on row 1
+	e.selection = selection{active: true, anchorX: 1, anchorY: 0}
+	e.cy, e.cx = 1, 9
+
+	count := e.ReplaceAll([]byte("foo"), []byte("baz"), false)
+	if count != 2 {
+		t.Errorf("Expected 2 replacements, got %d", count)
+	}
+
+	expected := []string{"foo baz", "baz bar foo", "foo"}
+	for i, want := range expected {
+		if got := string(e.row[i].chars); got != want {
+			t.Errorf("Row %d: expected %q, got %q", i, want, got)
+		}
+	}
+}
+
+func TestReplaceAllWholeWordMovesSelectionEnd(t *testing.T) {
+	e := newTestEditor(10, 80, "log catalog log")
+	e.selection = selection{active: true, anchorX: 0, anchorY: 0}
+	e.cy, e.cx = 0, 15
+
+	count := e.ReplaceAll([]byte("log"), []byte("entry"), true)
+	if count != 2 {
+		t.Errorf("Expected 2 replacements, got %d", count)
+	}
+	if got := string(e.row[0].chars); got != "entry catalog entry" {
+		t.Errorf("Expected %q, got %q", "entry catalog entry", got)
+	}
+	if e.cx != 19 {
+		t.Errorf("Expected selection end to move to 19, got %d", e.cx)
+	}
+}
+
+func TestReplaceWithEmptyText(t *testing.T) {
+	e := newTestEditor(10, 80, "foo bar foo", "foofoo")
+	e.config = DefaultConfig()
+	e.SetOutput(io.Discard)
+
+	// ESC cancels the replacement prompt, while Enter accepts an empty replacement
+	e.SetInput(strings.NewReader("\x1brfoo\r\x1b"))
+	e.ReplayInput()
+	if got := e.Lines(); !slices.Equal(got, []string{"foo bar foo", "foofoo"}) || e.dirty != 0 {
+		t.Errorf("Expected a cancelled replace to change nothing, got %q", got)
+	}
+
+	e.SetInput(strings.NewReader("\x1brfoo\r\r"))
+	e.ReplayInput()
+	if got := e.Lines(); !slices.Equal(got, []string{" bar ", ""}) {
+		t.Errorf("Expected the matches to be deleted, got %q", got)
+	}
+	if e.statusMessage != "Replaced 4 occurrences" {
+		t.Errorf("Unexpected status message %q", e.statusMessage)
+	}
+}
+
+func TestReplaceInteractive(t *testing.T) {
+	e := newTestEditor(10, 80, "foo bar foo", "baz foo")
+	e.config = DefaultConfig()
+	e.SetOutput(io.Discard)
+
+	// Replace the first match, skip the second, go back to it and replace it after all
+	e.SetInput(strings.NewReader("\x12foo\rqux\r" + "y" + "n" + "\x1b[A" + "y" + "q"))
+	e.ReplayInput()
+	if got := e.Lines(); !slices.Equal(got, []string{"qux bar qux", "baz foo"}) {
+		t.Errorf("Unexpected lines after replacing: %q", got)
+	}
+	if e.dirty == 0 || e.mode != EDIT_MODE || e.find.hasMatch() {
+		t.Errorf("Expected a modified buffer with the search ended")
+	}
+	if e.statusMessage != "Replaced 2 occurrences" {
+		t.Errorf("Unexpected status message %q", e.statusMessage)
+	}
+}
+
+func TestReplaceInteractiveAll(t *testing.T) {
+	e := newTestEditor(10, 80, "foo", "o")
+	e.config = DefaultConfig()
+	e.SetOutput(io.Discard)
+
+	// Replacements that contain the search text are not matched again
+	e.SetInput(strings.NewReader("\x12o\roo\r" + "a"))
+	e.ReplayInput()
+	if got := e.Lines(); !slices.Equal(got, []string{"foooo", "oo"}) {
+		t.Errorf("Unexpected lines after replacing all: %q", got)
+	}
+
+	e.SetInput(strings.NewReader("\x1a"))
+	e.ReplayInput()
+	if got := e.Lines(); !slices.Equal(got, []string{"foo", "o"}) {
+		t.Errorf("Expected a single undo to revert the replacements, got %q", got)
+	}
+}
+
+func TestReplaceInteractiveWithEmptyText(t *testing.T) {
+	e := newTestEditor(10, 80, "foofoo bar", "foo")
+	e.config = DefaultConfig()
+	e.SetOutput(io.Discard)
+
+	// Deleting a match continues with the text right behind it
+	e.SetInput(strings.NewReader("\x12foo\r\r" + "y" + "y" + "n"))
+	e.ReplayInput()
+	if got := e.Lines(); !slices.Equal(got, []string{" bar", "foo"}) {
+		t.Errorf("Unexpected lines after deleting matches: %q", got)
+	}
+	if e.statusMessage != "Replaced 2 occurrences" {
+		t.Errorf("Unexpected status message %q", e.statusMessage)
+	}
+}
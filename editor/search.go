@@ -0,0 +1,266 @@
+package editor
+
+import (
+	"bytes"
+	"regexp"
+	"slices"
+)
+
+// isWholeWord reports whether render[start:end] is delimited by separators or the line boundaries
+func isWholeWord(render []byte, start, end int) bool {
+	if start > 0 && !isSeparator(int(render[start-1])) {
+		return false
+	}
+	if end < len(render) && !isSeparator(int(render[end])) {
+		return false
+	}
+	return true
+}
+
+// findInRow returns the render column of the first match of query in the row
+// starting at or after from, or -1 if there is none
+func findInRow(row *editorRow, query []byte, from int, wholeWord bool) int {
+	for from >= 0 && from+len(query) <= len(row.render) {
+		match := bytes.Index(row.render[from:], query)
+		if match == -1 {
+			return -1
+		}
+		match += from
+		if !wholeWord || isWholeWord(row.render, match, match+len(query)) {
+			return match
+		}
+		from = match + 1
+	}
+	return -1
+}
+
+// findLastInRow returns the render column of the last match of query in the row
+// starting before the given column, or -1 if there is none
+func findLastInRow(row *editorRow, query []byte, before int, wholeWord bool) int {
+	last := -1
+	for match := findInRow(row, query, 0, wholeWord); match != -1 && match < before; {
+		last = match
+		match = findInRow(row, query, match+1, wholeWord)
+	}
+	return last
+}
+
+// searchPattern is what a search looks for, the query as literal text or, in regex
+// mode, as a regular expression. Matches span columns of the rendered row.
+type searchPattern struct {
+	query     []byte
+	wholeWord bool
+	re        *regexp.Regexp // the compiled query in regex mode, nil otherwise
+}
+
+// patternFor returns the pattern the search options make of query, or an error if it
+// is not a valid regular expression in regex mode
+func (e *Editor) patternFor(query []byte) (searchPattern, error) {
+	p := searchPattern{query: query, wholeWord: e.searchWholeWord}
+	if e.searchRegex {
+		re, err := regexp.Compile(string(query))
+		if err != nil {
+			return p, err
+		}
+		p.re = re
+	}
+	return p, nil
+}
+
+// matches returns the start and end render columns of the matches in the row. Empty
+// matches of a regular expression are left out, as there is nothing to show.
+func (p searchPattern) matches(row *editorRow) [][]int {
+	if p.re == nil {
+		var found [][]int
+		for start := findInRow(row, p.query, 0, p.wholeWord); start != -1; start = findInRow(row, p.query, start+1, p.wholeWord) {
+			found = append(found, []int{start, start + len(p.query)})
+		}
+		return found
+	}
+	// The matches are found in the whole row, so that anchors and \b see the
+	// characters before the column a search continues from
+	var found [][]int
+	for _, m := range p.re.FindAllIndex(row.render, -1) {
+		if m[0] < m[1] && (!p.wholeWord || isWholeWord(row.render, m[0], m[1])) {
+			found = append(found, m)
+		}
+	}
+	return found
+}
+
+// next returns the render columns of the first match in the row starting at or after
+// from, or start -1 if there is none
+func (p searchPattern) next(row *editorRow, from int) (start, end int) {
+	if p.re == nil {
+		if start = findInRow(row, p.query, from, p.wholeWord); start == -1 {
+			return -1, -1
+		}
+		return start, start + len(p.query)
+	}
+	for _, m := range p.matches(row) {
+		if m[0] >= from {
+			return m[0], m[1]
+		}
+	}
+	return -1, -1
+}
+
+// last returns the render columns of the last match in the row starting before the
+// given column, or start -1 if there is none
+func (p searchPattern) last(row *editorRow, before int) (start, end int) {
+	if p.re == nil {
+		if start = findLastInRow(row, p.query, before, p.wholeWord); start == -1 {
+			return -1, -1
+		}
+		return start, start + len(p.query)
+	}
+	start, end = -1, -1
+	for _, m := range p.matches(row) {
+		if m[0] < before {
+			start, end = m[0], m[1]
+		}
+	}
+	return start, end
+}
+
+// jumpToMatch moves the cursor to the next match of the pattern after the cursor, or the
+// previous one before it for a negative direction, wrapping around the file.
+// It returns false if there is no match at all.
+func (e *Editor) jumpToMatch(p searchPattern, direction int) bool {
+	if e.totalRows == 0 || len(p.query) == 0 {
+		return false
+	}
+
+	y := min(e.cy, e.totalRows-1)
+	rx := e.row[y].cxToRx(e, min(e.cx, len(e.row[y].chars)))
+	if e.cy >= e.totalRows {
+		rx = e.row[y].renderWidth
+	}
+
+	// The last iteration revisits the starting row as a whole to wrap around
+	for i := 0; i <= e.totalRows; i++ {
+		row := &e.row[y]
+		row.ensureRender(e)
+		match := -1
+		if direction > 0 {
+			from := 0
+			if i == 0 {
+				from = rx + 1
+			}
+			match, _ = p.next(row, from)
+		} else {
+			before := row.renderWidth + 1
+			if i == 0 {
+				before = rx
+			}
+			match, _ = p.last(row, before)
+		}
+
+		if match != -1 {
+			e.cy = y
+			e.cx = row.rxToCx(e, match)
+			e.EnsureCursorVisible()
+			return true
+		}
+		y = (y + direction + e.totalRows) % e.totalRows
+	}
+	return false
+}
+
+// FindWordUnderCursor searches forward (direction 1) or backward (direction -1) for the
+// whole word under the cursor, or the next word on the line if the cursor is on whitespace.
+// The word becomes the search query so that repeated searches continue it.
+func (e *Editor) FindWordUnderCursor(direction int) {
+	word := e.wordAtCursor()
+	if word == nil && e.cy < e.totalRows {
+		cx := e.cx
+		chars := e.row[e.cy].chars
+		for e.cx < len(chars) && isSeparator(int(chars[e.cx])) {
+			e.cx++
+		}
+		word = e.wordAtCursor()
+		if word == nil {
+			e.cx = cx // There is no word to move to
+		}
+	}
+	if word == nil {
+		e.SetStatusMessage("No word under cursor")
+		return
+	}
+
+	// Start from the beginning of the word so it doesn't match itself
+	chars := e.row[e.cy].chars
+	for e.cx > 0 && !isSeparator(int(chars[e.cx-1])) {
+		e.cx--
+	}
+	startX, startY := e.cx, e.cy
+
+	e.searchQuery = slices.Clone(word)
+	e.searchWholeWord = true
+	e.searchRegex = false
+	e.searchDirection = direction
+	e.jumpToMatch(searchPattern{query: e.searchQuery, wholeWord: true}, direction)
+	if e.cx == startX && e.cy == startY {
+		e.SetStatusMessage("No other matches for '%s'", word)
+	}
+}
+
+// FindNext repeats the last search in its original direction, or in the opposite
+// direction if reverse is set, without reopening the search prompt
+func (e *Editor) FindNext(reverse bool) {
+	if len(e.searchQuery) == 0 {
+		e.SetStatusMessage("No previous search")
+		return
+	}
+
+	direction := e.searchDirection
+	if direction == 0 {
+		direction = 1
+	}
+	if reverse {
+		direction = -direction
+	}
+
+	p, err := e.patternFor(e.searchQuery)
+	if err != nil {
+		e.ShowError("Invalid pattern: %v", err)
+		return
+	}
+	startY, startX := e.cy, e.cx
+	if !e.jumpToMatch(p, direction) {
+		e.SetStatusMessage("Pattern not found: %s", e.searchQuery)
+		return
+	}
+
+	switch {
+	case direction > 0 && (e.cy < startY || (e.cy == startY && e.cx <= startX)):
+		e.SetStatusMessage("Search hit BOTTOM, continuing at TOP")
+	case direction < 0 && (e.cy > startY || (e.cy == startY && e.cx >= startX)):
+		e.SetStatusMessage("Search hit TOP, continuing at BOTTOM")
+	default:
+		e.SetStatusMessage("")
+	}
+}
+
+// Search moves the cursor to the first match of query at or after the cursor,
+// wrapping around the file, and remembers it for FindNext
+func (e *Editor) Search(query string) bool {
+	e.searchQuery = []byte(query)
+	e.searchWholeWord = false
+	e.searchRegex = false
+	e.searchDirection = 1
+
+	if e.cy < e.totalRows {
+		row := &e.row[e.cy]
+		row.ensureRender(e)
+		rx := row.cxToRx(e, min(e.cx, len(row.chars)))
+		if findInRow(row, e.searchQuery, rx, false) == rx {
+			return true // Already on a match
+		}
+	}
+	if !e.jumpToMatch(searchPattern{query: e.searchQuery}, 1) {
+		e.SetStatusMessage("Pattern not found: %s", query)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,204 @@
+package editor
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFindInRowWholeWord(t *testing.T) {
+	tests := []struct {
+		line      string
+		query     string
+		wholeWord bool
+		expected  int
+	}{
+		{"catalog log", "log", false, 4},
+		{"catalog log", "log", true, 8},
+		{"log catalog", "log", true, 0},
+		{"catalog", "log", true, -1},
+		{"logger", "log", true, -1},
+		{"log", "log", true, 0},
+		{"(log)", "log", true, 1},
+		{"x.log", "log", true, 2},
+		{"\tlog", "log", true, 4},
+	}
+
+	for _, tt := range tests {
+		row := &editorRow{chars: []byte(tt.line)}
+		row.Update(&Editor{})
+		actual := findInRow(row, []byte(tt.query), 0, tt.wholeWord)
+		if actual != tt.expected {
+			t.Errorf("findInRow(%q, %q, wholeWord=%v) = %d, expected %d", tt.line, tt.query, tt.wholeWord, actual, tt.expected)
+		}
+	}
+}
+
+func TestFindCallbackWholeWord(t *testing.T) {
+	e := newTestEditor(10, 80, "catalog", "blog log")
+	e.searchWholeWord = true
+
+	e.FindCallback([]byte("log"), 'g')
+	if e.cy != 1 || e.cx != 5 {
+		t.Errorf("Expected whole word match at (1,5), got (%d,%d)", e.cy, e.cx)
+	}
+	e.FindCallback([]byte("log"), '\x1b')
+}
+
+func TestFindCallbackRestoresHighlight(t *testing.T) {
+	e := newTestEditor(10, 80, "x := 1 // foo", "foo(2)", "\"foo\" + 3", "bar")
+	e.filename = "test.go"
+	e.SelectSyntaxHighlight()
+	before := make([][]int, len(e.row))
+	for i := range e.row {
+		before[i] = slices.Clone(e.row[i].hl)
+	}
+
+	e.FindCallback([]byte("foo"), 'o')
+	e.FindCallback([]byte("foo"), ARROW_DOWN)
+	e.FindCallback([]byte("foo"), ARROW_DOWN)
+	e.FindCallback([]byte("foo"), ARROW_UP)
+	e.FindCallback([]byte("foo"), '\x1b')
+
+	for i := range e.row {
+		if !slices.Equal(e.row[i].hl, before[i]) {
+			t.Errorf("Row %d highlight not restored: got %v, expected %v", i, e.row[i].hl, before[i])
+		}
+	}
+}
+
+func TestEditorsDoNotShareState(t *testing.T) {
+	a, b := NewEditor(), NewEditor()
+	for _, e := range []*Editor{&a, &b} {
+		e.screenRows, e.screenCols = 10, 80
+		e.InsertRow(0, []byte("one"), 3)
+		e.InsertRow(1, []byte("two"), 3)
+	}
+
+	a.input = newInput(strings.NewReader("\x11"))
+	a.ProcessKeypress()
+	if a.quitTimes != QUIT_TIMES-1 || b.quitTimes != QUIT_TIMES {
+		t.Errorf("Expected only the first editor to count the Ctrl-Q, got %d and %d", a.quitTimes, b.quitTimes)
+	}
+
+	a.FindCallback([]byte("two"), 't')
+	if a.find.lastMatch != 1 || b.find.lastMatch != -1 || len(b.find.savedHl) != 0 {
+		t.Errorf("Expected only the first editor to have a match, got %d and %d", a.find.lastMatch, b.find.lastMatch)
+	}
+}
+
+func TestFindStartsWithFreshState(t *testing.T) {
+	e := newTestEditor(10, 80, "one", "two", "three")
+	e.output = newOutput(io.Discard)
+	before := slices.Clone(e.row[2].hl)
+
+	// Leave the state of an earlier search behind, with a match highlighted
+	e.FindCallback([]byte("three"), 't')
+	e.find.direction = -1
+
+	e.input = newInput(strings.NewReader("two\r"))
+	e.Find()
+	if e.cy != 1 || string(e.searchQuery) != "two" {
+		t.Errorf("Expected the search to find row 1, got row %d", e.cy)
+	}
+	if e.find.lastMatch != -1 || e.find.direction != 1 || len(e.find.savedHl) != 0 {
+		t.Errorf("Expected the search state to be reset, got %+v", e.find)
+	}
+	if !slices.Equal(e.row[2].hl, before) {
+		t.Errorf("Expected the earlier match highlight to be restored, got %v", e.row[2].hl)
+	}
+}
+
+func TestSearchMatchRowHighlight(t *testing.T) {
+	e := newTestEditor(10, 80, "foo", "bar", "foo")
+	e.mode = SEARCH_MODE
+	e.find.reset()
+
+	drawn := func() []string {
+		var abuf appendBuffer
+		e.DrawRows(&abuf)
+		return strings.Split(string(abuf.b), "\r\n")
+	}
+	highlighted := func(lines []string) []int {
+		var rows []int
+		for y, line := range lines {
+			if strings.HasPrefix(line, BACKGROUND_HIGHLIGHT) {
+				rows = append(rows, y)
+			}
+		}
+		return rows
+	}
+
+	if rows := highlighted(drawn()); len(rows) != 0 {
+		t.Errorf("Expected no highlighted line before a match, got %v", rows)
+	}
+	e.FindCallback([]byte("foo"), 'o')
+	e.FindCallback([]byte("foo"), ARROW_DOWN)
+	if rows := highlighted(drawn()); !slices.Equal(rows, []int{2}) {
+		t.Errorf("Expected the line of the current match highlighted, got %v", rows)
+	}
+
+	e.FindCallback([]byte("foo"), '\r')
+	e.mode = EDIT_MODE
+	if rows := highlighted(drawn()); len(rows) != 0 {
+		t.Errorf("Expected no highlighted line after the search ended, got %v", rows)
+	}
+}
+
+func TestFindCallbackRegex(t *testing.T) {
+	e := newTestEditor(10, 80, "var f = 1", "func main() {", "\tfunc helper(x int) {")
+	e.searchRegex = true
+
+	query := []byte(`func \w+\(`)
+	e.FindCallback(query, '(')
+	if e.cy != 1 || e.cx != 0 {
+		t.Fatalf("Expected the first match at (1,0), got (%d,%d)", e.cy, e.cx)
+	}
+	if got := e.row[1].hl[:10]; slices.ContainsFunc(got, func(hl int) bool { return hl != HL_MATCH }) || e.row[1].hl[10] == HL_MATCH {
+		t.Errorf("Expected the whole match to be highlighted, got %v", e.row[1].hl)
+	}
+
+	// Matches are in render columns, after the tab is expanded
+	e.FindCallback(query, ARROW_DOWN)
+	if e.cy != 2 || e.cx != 1 || e.find.lastRx != TAB_STOP {
+		t.Errorf("Expected the second match at (2,1), got (%d,%d)", e.cy, e.cx)
+	}
+
+	// An incomplete pattern matches nothing until it is valid
+	e.FindCallback([]byte(`func \w+(`), '(')
+	if e.find.hasMatch() {
+		t.Errorf("Expected no match for an invalid pattern")
+	}
+	e.FindCallback(query, '\r')
+}
+
+func TestFindNextRegex(t *testing.T) {
+	e := newTestEditor(10, 80, "a1 b22", "c333")
+	e.searchQuery = []byte(`[0-9]{2,}`)
+	e.searchRegex = true
+
+	e.FindNext(false)
+	if e.cy != 0 || e.cx != 4 {
+		t.Errorf("Expected the first match at (0,4), got (%d,%d)", e.cy, e.cx)
+	}
+	e.FindNext(false)
+	if e.cy != 1 || e.cx != 1 {
+		t.Errorf("Expected the second match at (1,1), got (%d,%d)", e.cy, e.cx)
+	}
+}
+
+func TestFindWordUnderCursor(t *testing.T) {
+	e := newTestEditor(10, 80, "foo bar", "x  ", "bar foo")
+	e.FindWordUnderCursor(1)
+	if e.cy != 2 || e.cx != 4 {
+		t.Errorf("Expected the next foo at (2,4), got (%d,%d)", e.cy, e.cx)
+	}
+
+	// Without a word after the cursor, the cursor stays where it was
+	e.cy, e.cx = 1, 2
+	e.FindWordUnderCursor(1)
+	if e.cy != 1 || e.cx != 2 || e.statusMessage != "No word under cursor" {
+		t.Errorf("Expected the cursor to stay at (1,2), got (%d,%d) with %q", e.cy, e.cx, e.statusMessage)
+	}
+}
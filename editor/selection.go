@@ -0,0 +1,235 @@
+package editor
+
+import "slices"
+
+// selection is a region of text between an anchor and the cursor
+type selection struct {
+	active           bool
+	anchorX, anchorY int // cx/cy where the selection was started
+}
+
+// extendSelection anchors a selection at the cursor if none is active
+// and then moves the cursor, extending the selected region
+func (e *Editor) extendSelection(key int) {
+	if !e.selection.active {
+		e.selection = selection{active: true, anchorX: e.cx, anchorY: e.cy}
+	}
+
+	switch key {
+	case SHIFT_ARROW_LEFT:
+		e.MoveCursor(ARROW_LEFT)
+	case SHIFT_ARROW_RIGHT:
+		e.MoveCursor(ARROW_RIGHT)
+	case SHIFT_ARROW_UP:
+		e.MoveCursor(ARROW_UP)
+	case SHIFT_ARROW_DOWN:
+		e.MoveCursor(ARROW_DOWN)
+	}
+}
+
+// clearSelection drops the active selection
+func (e *Editor) clearSelection() {
+	e.selection.active = false
+}
+
+// selectionBounds returns the start and end of the selection in (cy, cx) order,
+// with the start never after the end
+func (e *Editor) selectionBounds() (startY, startX, endY, endX int, ok bool) {
+	if !e.selection.active {
+		return 0, 0, 0, 0, false
+	}
+	startY, startX = e.selection.anchorY, e.selection.anchorX
+	endY, endX = e.cy, e.cx
+	if startY > endY || (startY == endY && startX > endX) {
+		startY, startX, endY, endX = endY, endX, startY, startX
+	}
+	return startY, startX, endY, endX, true
+}
+
+// selectedRows returns the first and last row touched by the selection.
+// A selection ending at column zero does not include that last row.
+func (e *Editor) selectedRows() (first, last int, ok bool) {
+	startY, _, endY, endX, ok := e.selectionBounds()
+	if !ok {
+		return 0, 0, false
+	}
+	if endX == 0 && endY > startY {
+		endY--
+	}
+	endY = min(endY, e.totalRows-1)
+	if startY > endY {
+		return 0, 0, false
+	}
+	return startY, endY, true
+}
+
+// selectionRenderRange returns the render columns [from, to) of the given
+// row that are inside the selection
+func (e *Editor) selectionRenderRange(filerow int) (from, to int, ok bool) {
+	startY, startX, endY, endX, ok := e.selectionBounds()
+	if !ok || filerow < startY || filerow > endY {
+		return 0, 0, false
+	}
+
+	row := &e.row[filerow]
+	from, to = 0, row.renderWidth
+	if filerow == startY {
+		from = row.cxToRx(e, min(startX, len(row.chars)))
+	}
+	if filerow == endY {
+		to = row.cxToRx(e, min(endX, len(row.chars)))
+	}
+	return from, to, from < to
+}
+
+// selectionRange returns the selected text, limited to the rows of the file. ok is
+// false if no text is selected.
+func (e *Editor) selectionRange() (r textRange, ok bool) {
+	startY, startX, endY, endX, ok := e.selectionBounds()
+	if !ok || startY >= e.totalRows || (startY == endY && startX == endX) {
+		return textRange{}, false
+	}
+	if endY >= e.totalRows {
+		// The cursor is on the line past the end of the file
+		endY = e.totalRows - 1
+		endX = len(e.row[endY].chars)
+	}
+	startX = min(startX, len(e.row[startY].chars))
+	endX = min(endX, len(e.row[endY].chars))
+	return textRange{startY, startX, endY, endX}, true
+}
+
+// DeleteSelection removes the selected text, joining what is left of its first and
+// last row, and moves the cursor to where the selection started. It returns false
+// if there was no selected text.
+func (e *Editor) DeleteSelection() bool {
+	r, ok := e.selectionRange()
+	e.clearSelection()
+	if !ok {
+		return false
+	}
+	startY, startX, endY, endX := r.startY, r.startX, r.endY, r.endX
+
+	tail := slices.Clone(e.row[endY].chars[endX:])
+	for range endY - startY {
+		e.DeleteRow(startY + 1)
+	}
+	row := &e.row[startY]
+	e.saveRow(row)
+	row.chars = append(row.chars[:startX], tail...)
+	row.Update(e)
+	e.dirty++
+
+	e.cy, e.cx = startY, startX
+	return true
+}
+
+// CropToSelection deletes everything outside the selection, so that the selected
+// text is all that is left of the buffer. The cursor moves to its start.
+func (e *Editor) CropToSelection() {
+	r, ok := e.selectionRange()
+	if !ok {
+		e.SetStatusMessage("Nothing selected to crop to")
+		return
+	}
+	e.clearSelection()
+	startY, startX, endY, endX := r.startY, r.startX, r.endY, r.endX
+	if endX == 0 && endY > startY {
+		// Whole selected lines keep their last line, not the empty start of the next one
+		endY--
+		endX = len(e.row[endY].chars)
+	}
+
+	row := &e.row[endY]
+	e.saveRow(row)
+	row.chars = row.chars[:endX]
+	row.Update(e)
+	row = &e.row[startY]
+	e.saveRow(row)
+	row.chars = slices.Clone(row.chars[startX:])
+	row.Update(e)
+
+	// Rows are deleted from the end, so fewer rows have to be moved
+	for e.totalRows > endY+1 {
+		e.DeleteRow(e.totalRows - 1)
+	}
+	for range startY {
+		e.DeleteRow(0)
+	}
+	e.dirty++
+
+	e.cy, e.cx = 0, 0
+	e.rowOffset, e.colOffset = 0, 0
+	e.SetStatusMessage("Cropped to %d lines", e.totalRows)
+}
+
+// textRange is a region of text from (startY, startX) up to (endY, endX)
+type textRange struct {
+	startY, startX, endY, endX int
+}
+
+// contains reports whether r covers all of other
+func (r textRange) contains(other textRange) bool {
+	startsBefore := r.startY < other.startY || (r.startY == other.startY && r.startX <= other.startX)
+	endsAfter := r.endY > other.endY || (r.endY == other.endY && r.endX >= other.endX)
+	return startsBefore && endsAfter
+}
+
+// lineRange returns the rows first to last including the line ending after last,
+// which the last row of the file doesn't have
+func (e *Editor) lineRange(first, last int) textRange {
+	if last+1 < e.totalRows {
+		return textRange{first, 0, last + 1, 0}
+	}
+	return textRange{first, 0, last, len(e.row[last].chars)}
+}
+
+// selectionScopes returns the word, the line and the paragraph around the position,
+// from the smallest to the largest. A paragraph is a run of non-blank lines.
+func (e *Editor) selectionScopes(y, x int) []textRange {
+	var scopes []textRange
+	chars := e.row[y].chars
+	if start, end, ok := wordBounds(chars, x); ok {
+		scopes = append(scopes, textRange{y, start, y, end})
+	}
+	scopes = append(scopes, e.lineRange(y, y))
+
+	if !isBlankLine(chars) {
+		first, last := y, y
+		for first > 0 && !isBlankLine(e.row[first-1].chars) {
+			first--
+		}
+		for last+1 < e.totalRows && !isBlankLine(e.row[last+1].chars) {
+			last++
+		}
+		scopes = append(scopes, e.lineRange(first, last))
+	}
+	return scopes
+}
+
+// isBlankLine reports whether chars holds nothing but whitespace
+func isBlankLine(chars []byte) bool {
+	return len(leadingWhitespace(chars)) == len(chars)
+}
+
+// ExpandSelection selects the word under the cursor. Invoked again, it expands the
+// selection to the whole line and then to the paragraph around it.
+func (e *Editor) ExpandSelection() {
+	current := textRange{e.cy, e.cx, e.cy, e.cx}
+	if startY, startX, endY, endX, ok := e.selectionBounds(); ok {
+		current = textRange{startY, startX, endY, endX}
+	}
+	if current.startY >= e.totalRows {
+		e.Bell()
+		return
+	}
+
+	for _, scope := range e.selectionScopes(current.startY, current.startX) {
+		if scope.contains(current) && scope != current {
+			e.selection = selection{active: true, anchorX: scope.startX, anchorY: scope.startY}
+			e.cx, e.cy = scope.endX, scope.endY
+			return
+		}
+	}
+	e.Bell() // Nothing larger to select
+}
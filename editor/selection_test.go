@@ -0,0 +1,185 @@
+package editor
+
+import (
+	"io"
+	"slices"
+	"testing"
+)
+
+func TestDeleteSelectionAcrossRows(t *testing.T) {
+	e := newTestEditor(10, 80, "first line", "middle", "last line", "after")
+	e.selection = selection{active: true, anchorX: 8, anchorY: 2}
+	e.cy, e.cx = 0, 6
+
+	if !e.DeleteSelection() {
+		t.Fatalf("Expected selection to be deleted")
+	}
+	if e.totalRows != 2 || string(e.row[0].chars) != "first e" || string(e.row[1].chars) != "after" {
+		t.Errorf("Unexpected rows after delete: %q, total %d", e.row[0].chars, e.totalRows)
+	}
+	if e.cy != 0 || e.cx != 6 {
+		t.Errorf("Expected cursor at (0,6), got (%d,%d)", e.cy, e.cx)
+	}
+	if e.selection.active {
+		t.Errorf("Expected selection to be cleared")
+	}
+}
+
+func TestDeleteSelectionEmpty(t *testing.T) {
+	e := newTestEditor(10, 80, "text")
+	if e.DeleteSelection() {
+		t.Errorf("Expected nothing to delete without a selection")
+	}
+	e.selection = selection{active: true, anchorX: 2, anchorY: 0}
+	e.cx = 2
+	if e.DeleteSelection() || string(e.row[0].chars) != "text" {
+		t.Errorf("Expected an empty selection to delete nothing")
+	}
+}
+
+func TestCropToSelection(t *testing.T) {
+	e := newTestEditor(10, 80, "first line", "middle", "last line", "after")
+	e.selection = selection{active: true, anchorX: 6, anchorY: 0}
+	e.cy, e.cx = 2, 4
+
+	e.CropToSelection()
+	if got := e.Lines(); !slices.Equal(got, []string{"line", "middle", "last"}) {
+		t.Errorf("Expected only the selection to be left, got %q", got)
+	}
+	if e.cy != 0 || e.cx != 0 || e.selection.active {
+		t.Errorf("Expected the cursor at the start without a selection, got (%d,%d)", e.cy, e.cx)
+	}
+
+	// Selected whole lines don't keep the empty start of the following line
+	e = newTestEditor(10, 80, "a", "b", "c", "d")
+	e.selection = selection{active: true, anchorX: 0, anchorY: 1}
+	e.cy, e.cx = 3, 0
+	e.CropToSelection()
+	if got := e.Lines(); !slices.Equal(got, []string{"b", "c"}) {
+		t.Errorf("Expected the selected lines, got %q", got)
+	}
+
+	e.CropToSelection()
+	if e.totalRows != 2 || e.statusMessage != "Nothing selected to crop to" {
+		t.Errorf("Expected nothing to happen without a selection, got %q", e.statusMessage)
+	}
+}
+
+func TestExpandSelection(t *testing.T) {
+	e := newTestEditor(10, 80, "first line", "foo bar baz", "last", "", "other")
+	e.cy, e.cx = 1, 5
+
+	expected := []textRange{
+		{1, 4, 1, 7}, // the word
+		{1, 0, 2, 0}, // the line with its line ending
+		{0, 0, 3, 0}, // the paragraph
+	}
+	for _, want := range expected {
+		e.ExpandSelection()
+		startY, startX, endY, endX, ok := e.selectionBounds()
+		if got := (textRange{startY, startX, endY, endX}); !ok || got != want {
+			t.Fatalf("Expected the selection %v, got %v", want, got)
+		}
+	}
+
+	// The paragraph is the largest scope
+	e.ExpandSelection()
+	if e.cy != 3 || e.selection.anchorY != 0 {
+		t.Errorf("Expected the selection to stay the paragraph")
+	}
+	e.DeleteSelection()
+	if got := e.Lines(); !slices.Equal(got, []string{"", "other"}) {
+		t.Errorf("Expected the paragraph to be deleted, got %q", got)
+	}
+}
+
+func TestExpandSelectionOnLastLine(t *testing.T) {
+	// Without a word at the cursor the line is selected first, and the last line
+	// of the file ends without a line ending
+	e := newTestEditor(10, 80, "a", "b  c")
+	e.cy, e.cx = 1, 2
+
+	e.ExpandSelection()
+	startY, startX, endY, endX, _ := e.selectionBounds()
+	if got := (textRange{startY, startX, endY, endX}); got != (textRange{1, 0, 1, 4}) {
+		t.Errorf("Expected the last line to be selected, got %v", got)
+	}
+}
+
+func TestIndentAndDedentSelection(t *testing.T) {
+	e := newTestEditor(10, 80, "one", "", "  two", "three")
+	e.config = DefaultConfig()
+	e.indent = indentStyle{expandTab: true, width: 2}
+	e.selection = selection{active: true, anchorX: 1, anchorY: 0}
+	e.cy, e.cx = 2, 3
+
+	e.IndentSelection()
+	if got := e.Lines(); !slices.Equal(got, []string{"  one", "", "    two", "three"}) {
+		t.Errorf("Expected the selected non-empty rows indented, got %q", got)
+	}
+	if e.selection.anchorX != 3 || e.cx != 5 {
+		t.Errorf("Expected the selection to stay on the same text, got anchor %d, cursor %d", e.selection.anchorX, e.cx)
+	}
+
+	e.DedentSelection()
+	e.DedentSelection()
+	if got := e.Lines(); !slices.Equal(got, []string{"one", "", "two", "three"}) {
+		t.Errorf("Expected the selected rows dedented, got %q", got)
+	}
+}
+
+func TestDedentSelectionWithoutIndentation(t *testing.T) {
+	e := newTestEditor(10, 80, "one", "two")
+	e.config = DefaultConfig()
+	e.output = newOutput(io.Discard)
+	pressKeys(e, "x")
+	e.dirty = 0
+
+	e.cx = 0
+	pressKeys(e, "\x1b[1;2B", "\x1b[1;2C", "\x1b[Z")
+	if got := e.Lines(); !slices.Equal(got, []string{"xone", "two"}) || e.dirty != 0 {
+		t.Errorf("Expected nothing to change, got %q with dirty %d", got, e.dirty)
+	}
+
+	// The dedent that did nothing is not an undo step of its own
+	pressKeys(e, "\x1a")
+	if got := e.Lines(); !slices.Equal(got, []string{"one", "two"}) {
+		t.Errorf("Expected undo to revert the typed character, got %q", got)
+	}
+}
+
+func TestShiftArrowKeepsGoalColumn(t *testing.T) {
+	e := newTestEditor(10, 80, "a long line", "ab", "another long line")
+	e.config = DefaultConfig()
+	e.output = newOutput(io.Discard)
+	e.cx = 8
+
+	pressKeys(e, "\x1b[1;2B", "\x1b[1;2B")
+	if e.cy != 2 || e.cx != 8 {
+		t.Errorf("Expected the cursor back in column 8, got %d,%d", e.cx, e.cy)
+	}
+	if !e.selection.active || e.selection.anchorX != 8 || e.selection.anchorY != 0 {
+		t.Errorf("Expected the selection to start at the goal column, got %+v", e.selection)
+	}
+}
+
+func TestTabWithSelection(t *testing.T) {
+	e := newTestEditor(10, 80, "one two", "three")
+	e.config = DefaultConfig()
+	e.output = newOutput(io.Discard)
+	e.indent = indentStyle{expandTab: false}
+
+	// A selection within a row is replaced by the tab
+	e.cx = 3
+	pressKeys(e, "\x1b[1;2C", "\x1b[1;2C", "\t")
+	if got := e.Lines(); !slices.Equal(got, []string{"one\two", "three"}) || e.selection.active {
+		t.Errorf("Expected the selected text replaced by a tab, got %q", got)
+	}
+
+	// A selection spanning rows indents them
+	e.cx = 0
+	pressKeys(e, "\x1b[1;2B", "\t")
+	if got := e.Lines(); !slices.Equal(got, []string{"\tone\two", "three"}) || !e.selection.active {
+		t.Errorf("Expected the first row indented with the selection kept, got %q", got)
+	}
+}
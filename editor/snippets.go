@@ -0,0 +1,78 @@
+package editor
+
+import (
+	"slices"
+	"strings"
+)
+
+// SNIPPET_CURSOR marks where the cursor is put in the expansion of a snippet
+const SNIPPET_CURSOR = "$0"
+
+// snippetTrigger returns the start of the word before the cursor and the expansion
+// configured for it, or ok false if the word isn't a snippet trigger
+func (e *Editor) snippetTrigger() (start int, expansion string, ok bool) {
+	if e.cy >= e.totalRows || e.cx == 0 {
+		return 0, "", false
+	}
+	filetype := ""
+	if e.syntax != nil {
+		filetype = e.syntax.filetype
+	}
+	snippets := e.config.Snippets[filetype]
+	if len(snippets) == 0 {
+		return 0, "", false
+	}
+
+	chars := e.row[e.cy].chars
+	start = e.cx
+	for start > 0 && !isSeparator(int(chars[start-1])) {
+		start--
+	}
+	expansion, ok = snippets[string(chars[start:e.cx])]
+	return start, expansion, ok && start < e.cx
+}
+
+// ExpandSnippet replaces the snippet trigger before the cursor with its expansion,
+// typing it like the user would: new lines start with the indentation of the
+// trigger's line and tabs insert one indentation level. The cursor ends up at SNIPPET_CURSOR,
+// or after the expansion if it has none. It reports whether there was a trigger.
+func (e *Editor) ExpandSnippet() bool {
+	start, expansion, ok := e.snippetTrigger()
+	if !ok {
+		return false
+	}
+	for e.cx > start {
+		e.row[e.cy].deleteChar(e, e.cx-1)
+		e.cx--
+	}
+	indent := slices.Clone(leadingWhitespace(e.row[e.cy].chars))
+
+	cursorX, cursorY := -1, -1
+	for i := 0; i < len(expansion); i++ {
+		switch {
+		case strings.HasPrefix(expansion[i:], SNIPPET_CURSOR):
+			cursorX, cursorY = e.cx, e.cy
+			i += len(SNIPPET_CURSOR) - 1
+		case expansion[i] == '\n':
+			// The lines of the expansion are indented relative to the trigger's line
+			e.InsertNewline()
+			for e.cx > 0 {
+				e.row[e.cy].deleteChar(e, e.cx-1)
+				e.cx--
+			}
+			for _, c := range indent {
+				e.InsertChar(int(c))
+			}
+		case expansion[i] == '\t':
+			for _, c := range e.indentUnit() {
+				e.InsertChar(int(c))
+			}
+		default:
+			e.InsertChar(int(expansion[i]))
+		}
+	}
+	if cursorY >= 0 {
+		e.cx, e.cy = cursorX, cursorY
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package editor
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExpandSnippet(t *testing.T) {
+	e := newTestEditor(10, 80)
+	e.output = newOutput(io.Discard)
+	e.config = DefaultConfig()
+	e.Load(strings.NewReader("func f() error {\n\tx := iferr\n}"), "main.go")
+	e.cy, e.cx = 1, len("\tx := iferr")
+
+	pressKeys(e, "\t")
+	want := []string{"func f() error {", "\tx := if err != nil {", "\t\treturn ", "\t}", "}"}
+	if got := e.Lines(); !slices.Equal(got, want) {
+		t.Fatalf("Expected the snippet to be expanded, got %q", got)
+	}
+	if e.cy != 2 || e.cx != len("\t\treturn ") {
+		t.Errorf("Expected the cursor at the placeholder (2, 9), got (%d, %d)", e.cy, e.cx)
+	}
+
+	// Everything the expansion typed is undone together
+	e.revertLastGroup()
+	if got := e.Lines(); len(got) != 3 {
+		t.Errorf("Expected the expanded lines to be undone, got %q", got)
+	}
+}
+
+func TestTabWithoutSnippetTrigger(t *testing.T) {
+	e := newTestEditor(10, 80)
+	e.output = newOutput(io.Discard)
+	e.config = DefaultConfig()
+	e.Load(strings.NewReader("iferr"), "notes.txt")
+	e.cx = len("iferr")
+
+	// Snippets for Go files don't apply to other files
+	pressKeys(e, "\t")
+	if got := e.Lines(); !slices.Equal(got, []string{"iferr\t"}) {
+		t.Errorf("Expected a tab to be inserted, got %q", got)
+	}
+}
@@ -0,0 +1,161 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// RECENT_FILES_MAX is the number of recently opened files that are remembered
+const RECENT_FILES_MAX = 10
+
+// startScreen is the launcher shown in place of an empty, unnamed buffer
+type startScreen struct {
+	files     []string // recently opened files, most recent first
+	selected  int      // index of the selected file
+	dismissed bool     // set once a key other than the navigation keys was pressed
+}
+
+// DefaultRecentFilesPath returns where recently opened files are remembered,
+// or "" if there is no cache directory
+func DefaultRecentFilesPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "kigo", "recent_files")
+}
+
+// readRecentFiles returns the files listed in the recent files list at path
+func readRecentFiles(path string) []string {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for line := range strings.Lines(string(data)) {
+		if line = strings.TrimRight(line, "\r\n"); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files
+}
+
+// rememberRecentFile puts filename at the top of the recent files list. Failing to
+// update the list is not worth bothering the user with, so errors are ignored.
+func (e *Editor) rememberRecentFile(filename string) {
+	path := e.config.RecentFilesPath
+	if path == "" || filename == "" || filename == STDIO_FILENAME {
+		return
+	}
+	if abs, err := filepath.Abs(filename); err == nil {
+		filename = abs
+	}
+
+	files := readRecentFiles(path)
+	files = slices.DeleteFunc(files, func(f string) bool { return f == filename })
+	files = slices.Insert(files, 0, filename)
+	files = files[:min(len(files), RECENT_FILES_MAX)]
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	os.WriteFile(path, []byte(strings.Join(files, "\n")+"\n"), 0644)
+}
+
+// startScreenActive reports whether the start screen is shown instead of the buffer
+func (e *Editor) startScreenActive() bool {
+	return e.totalRows == 0 && e.filename == "" && e.mode == EDIT_MODE && !e.start.dismissed
+}
+
+// startScreenKey handles a key on the start screen: Up and Down select a recent file
+// and Enter opens it. Any other key hides the start screen and is processed as usual.
+// It reports whether the key was handled.
+func (e *Editor) startScreenKey(key int) bool {
+	switch key {
+	case ARROW_UP, ARROW_DOWN:
+		if len(e.start.files) == 0 {
+			return true
+		}
+		if key == ARROW_UP {
+			e.start.selected = max(e.start.selected-1, 0)
+		} else {
+			e.start.selected = min(e.start.selected+1, len(e.start.files)-1)
+		}
+		e.contentVersion++
+		return true
+
+	case '\r':
+		if len(e.start.files) == 0 {
+			break
+		}
+		filename := e.start.files[e.start.selected]
+		if err := e.Open(filename); err != nil {
+			e.filename = ""
+			e.ShowError("%v", err)
+		}
+		return true
+	}
+	e.start.dismissed = true
+	e.contentVersion++
+	return false
+}
+
+// startScreenLines returns the lines of the start screen, and the index of the
+// line of the selected recent file or -1 if there is none
+func (e *Editor) startScreenLines() ([]string, int) {
+	welcome := "KIGO editor -- version " + KIGO_VERSION
+	if !e.startScreenActive() {
+		return []string{welcome}, -1
+	}
+
+	lines := []string{
+		welcome,
+		"",
+		"Ctrl-O open | Ctrl-E explorer | Ctrl-H help | Ctrl-Q quit",
+		"",
+	}
+	if len(e.start.files) == 0 {
+		return append(lines, "No recent files"), -1
+	}
+	lines = append(lines, "Recent files (Up/Down, Enter to open):")
+	first := len(lines)
+	for _, file := range e.start.files {
+		lines = append(lines, "  "+file)
+	}
+	return lines, first + e.start.selected
+}
+
+// drawStartScreenRow draws screen row y of an empty buffer: the welcome message,
+// or the start screen while it is shown. It reports whether the row had any text.
+func (e *Editor) drawStartScreenRow(abuf *appendBuffer, y int) bool {
+	lines, selected := e.startScreenLines()
+	top := max(min(e.screenRows/3, e.screenRows-len(lines)), 0)
+	if y < top || y-top >= len(lines) {
+		return false
+	}
+
+	// The lines are aligned as a block in the middle of the screen
+	width := 0
+	for _, line := range lines {
+		width = max(width, displayWidth(line))
+	}
+	width = min(width, e.textCols())
+	padding := (e.textCols() - width) / 2
+	line := truncateToWidth(lines[y-top], e.textCols())
+	if padding > 0 {
+		abuf.append([]byte("~"))
+		padding--
+	}
+	abuf.append([]byte(strings.Repeat(" ", padding)))
+	if y-top == selected {
+		abuf.append([]byte(COLORS_INVERT + line + COLORS_RESET))
+	} else {
+		abuf.append([]byte(line))
+	}
+	return true
+}
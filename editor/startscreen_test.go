@@ -0,0 +1,74 @@
+package editor
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRememberRecentFile(t *testing.T) {
+	dir := t.TempDir()
+	e := newTestEditor(10, 80)
+	e.config.RecentFilesPath = filepath.Join(dir, "cache", "recent_files")
+
+	for i := range RECENT_FILES_MAX + 2 {
+		e.rememberRecentFile(filepath.Join(dir, string(rune('a'+i))))
+	}
+	e.rememberRecentFile(filepath.Join(dir, "c"))
+
+	files := readRecentFiles(e.config.RecentFilesPath)
+	if len(files) != RECENT_FILES_MAX {
+		t.Fatalf("Expected %d recent files, got %d", RECENT_FILES_MAX, len(files))
+	}
+	if files[0] != filepath.Join(dir, "c") || files[1] != filepath.Join(dir, "l") {
+		t.Errorf("Expected the reopened file first and no duplicates, got %q", files[:2])
+	}
+	if slices.Contains(files, filepath.Join(dir, "a")) {
+		t.Errorf("Expected the oldest file to be dropped")
+	}
+}
+
+func TestStartScreen(t *testing.T) {
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first.txt"), filepath.Join(dir, "second.txt")
+	os.WriteFile(second, []byte("second\n"), 0644)
+
+	e := newTestEditor(20, 80)
+	e.config = DefaultConfig()
+	e.output = newOutput(io.Discard)
+	e.start = startScreen{files: []string{first, second}}
+
+	drawn := func() string {
+		var abuf appendBuffer
+		e.DrawRows(&abuf)
+		return string(abuf.b)
+	}
+	if screen := drawn(); !strings.Contains(screen, COLORS_INVERT+"  "+first) || !strings.Contains(screen, "  "+second) {
+		t.Fatalf("Expected the recent files listed with the first selected, got %q", screen)
+	}
+
+	pressKeys(e, "\x1b[B", "\r")
+	if e.filename != second || e.Text() != "second\n" {
+		t.Errorf("Expected the second recent file to be opened, got %q", e.filename)
+	}
+}
+
+func TestStartScreenDismissed(t *testing.T) {
+	e := newTestEditor(20, 80)
+	e.config = DefaultConfig()
+	e.output = newOutput(io.Discard)
+
+	var abuf appendBuffer
+	e.DrawRows(&abuf)
+	if !strings.Contains(string(abuf.b), "No recent files") {
+		t.Errorf("Expected the start screen to mention the empty recent files list")
+	}
+
+	pressKeys(e, "x")
+	if e.Text() != "x" || !e.start.dismissed {
+		t.Errorf("Expected typing to dismiss the start screen and insert text, got %q", e.Text())
+	}
+}
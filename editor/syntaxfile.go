@@ -0,0 +1,140 @@
+package editor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SYNTAX_FILE_EXTENSION is the extension of the files LoadSyntaxDir reads
+const SYNTAX_FILE_EXTENSION = ".json"
+
+// syntaxFlagNames are the names of the highlighting flags in syntax files
+var syntaxFlagNames = map[string]int{
+	"numbers":  HL_HIGHLIGHT_NUMBERS,
+	"strings":  HL_HIGHLIGHT_STRINGS,
+	"versions": HL_HIGHLIGHT_VERSIONS,
+	"make":     HL_HIGHLIGHT_MAKE,
+	"headers":  HL_HIGHLIGHT_HEADERS,
+	"keys":     HL_HIGHLIGHT_KEYS,
+}
+
+// syntaxFile is a syntax definition as written in a syntax file, for example
+//
+//	{
+//	  "filetype": "lua",
+//	  "filematch": [".lua"],
+//	  "keywords": [["if", "then", "end"], ["nil", "true", "false"]],
+//	  "comment": "--",
+//	  "multilineComment": ["--[[", "]]"],
+//	  "highlight": ["numbers", "strings"]
+//	}
+//
+// The second list of keywords is highlighted like types.
+type syntaxFile struct {
+	Filetype          string     `json:"filetype"`
+	Filematch         []string   `json:"filematch"`
+	Keywords          [][]string `json:"keywords"`
+	Comment           string     `json:"comment"`
+	MultilineComment  []string   `json:"multilineComment"`
+	Highlight         []string   `json:"highlight"`
+	IndentTabs        bool       `json:"indentTabs"`
+	StringQuotes      string     `json:"stringQuotes"`
+	CommentAfterSpace bool       `json:"commentAfterSpace"`
+}
+
+// parseSyntaxFile returns the syntax defined by the JSON in data
+func parseSyntaxFile(data []byte) (editorSyntax, error) {
+	var f syntaxFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return editorSyntax{}, err
+	}
+	if f.Filetype == "" || len(f.Filematch) == 0 {
+		return editorSyntax{}, errors.New("filetype and filematch are required")
+	}
+	if len(f.Keywords) > 2 {
+		return editorSyntax{}, errors.New("keywords can have at most two lists")
+	}
+	if len(f.MultilineComment) != 0 && len(f.MultilineComment) != 2 {
+		return editorSyntax{}, errors.New("multilineComment needs a start and an end marker")
+	}
+
+	s := editorSyntax{
+		filetype:               f.Filetype,
+		filematch:              f.Filematch,
+		keywords:               f.Keywords,
+		singlelineCommentStart: f.Comment,
+		indentTabs:             f.IndentTabs,
+		stringQuotes:           f.StringQuotes,
+		commentAfterSpace:      f.CommentAfterSpace,
+	}
+	if len(f.MultilineComment) == 2 {
+		s.multilineCommentStart, s.multilineCommentEnd = f.MultilineComment[0], f.MultilineComment[1]
+	}
+	for _, name := range f.Highlight {
+		flag, ok := syntaxFlagNames[name]
+		if !ok {
+			return editorSyntax{}, fmt.Errorf("unknown highlight %q", name)
+		}
+		s.flags |= flag
+	}
+	return s, nil
+}
+
+// readSyntaxFile returns the syntax defined in the file at path
+func readSyntaxFile(path string) (editorSyntax, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return editorSyntax{}, err
+	}
+	return parseSyntaxFile(data)
+}
+
+// DefaultSyntaxDir returns where syntax files are looked for, the syntax directory next
+// to the config file, or "" if there is none
+func DefaultSyntaxDir() string {
+	path := DefaultConfigPath()
+	if path == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(path), "syntax")
+}
+
+// LoadSyntaxDir reads the syntax definitions in the SYNTAX_FILE_EXTENSION files in dir,
+// in the order of their names. Files are matched with them before HLDB_ENTRIES, so a
+// file can add a language or replace a built-in one. A missing directory loads
+// nothing, invalid files are skipped and reported in the error.
+func (e *Editor) LoadSyntaxDir(dir string) error {
+	e.syntaxes = nil
+	if dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != SYNTAX_FILE_EXTENSION {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		s, err := readSyntaxFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+		e.syntaxes = append(e.syntaxes, s)
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
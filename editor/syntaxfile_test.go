@@ -0,0 +1,53 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSyntaxDir(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "lua.json"), []byte(`{
+		"filetype": "lua",
+		"filematch": [".lua"],
+		"keywords": [["local", "end"], ["nil"]],
+		"comment": "--",
+		"multilineComment": ["--[[", "]]"],
+		"highlight": ["numbers", "strings"]
+	}`), 0644)
+	// A file can take the place of a built-in syntax
+	os.WriteFile(filepath.Join(dir, "golang.json"), []byte(`{"filetype": "mygo", "filematch": [".go"]}`), 0644)
+	os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{"filetype": "x", "filematch": [".x"], "highlight": ["colors"]}`), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a syntax"), 0644)
+
+	e := newTestEditor(10, 80, "local x = nil -- 42")
+	err := e.LoadSyntaxDir(dir)
+	if err == nil || !strings.Contains(err.Error(), `broken.json: unknown highlight "colors"`) {
+		t.Errorf("Expected the broken file to be reported, got %v", err)
+	}
+	if len(e.syntaxes) != 2 {
+		t.Fatalf("Expected 2 loaded syntaxes, got %d", len(e.syntaxes))
+	}
+
+	e.filename = "init.lua"
+	e.SelectSyntaxHighlight()
+	if e.syntax == nil || e.syntax.filetype != "lua" {
+		t.Fatalf("Expected the loaded lua syntax, got %v", e.syntax)
+	}
+	hl := e.row[0].hl
+	if hl[0] != HL_KEYWORD1 || hl[10] != HL_KEYWORD2 || hl[14] != HL_COMMENT {
+		t.Errorf("Unexpected highlighting %v", hl)
+	}
+	if s := e.detectSyntax("main.go"); s == nil || s.filetype != "mygo" {
+		t.Errorf("Expected the loaded syntax to replace the built-in one, got %v", s)
+	}
+	if s := e.detectSyntax("main.c"); s == nil || s.filetype != "c" {
+		t.Errorf("Expected the built-in syntaxes to be kept, got %v", s)
+	}
+
+	if err := e.LoadSyntaxDir(filepath.Join(dir, "missing")); err != nil || e.syntaxes != nil {
+		t.Errorf("Expected a missing directory to load nothing, got %v", err)
+	}
+}
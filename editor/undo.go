@@ -0,0 +1,142 @@
+package editor
+
+import "slices"
+
+// Kinds of recorded row operations
+const (
+	UNDO_INSERT_ROW = iota
+	UNDO_DELETE_ROW
+	UNDO_CHANGE_ROW
+)
+
+// undoOp is a recorded change of the rows that can be reversed
+type undoOp struct {
+	kind  int
+	at    int    // index of the inserted, deleted or changed row
+	chars []byte // content of a deleted row, or of a changed row before the change
+}
+
+// undoGroup holds the operations of one command, which are undone together
+type undoGroup struct {
+	ops            []undoOp
+	cx, cy         int // cursor position before the command
+	afterX, afterY int // cursor position after the command
+}
+
+// undoHistory records the changes made to a buffer
+type undoHistory struct {
+	groups    []undoGroup
+	redo      []undoGroup // undone groups, which revert the undo when they are reverted
+	pending   undoGroup   // operations of the command that is running
+	suspended bool        // set while changes must not be recorded, like when loading a file
+}
+
+// recordUndo adds an operation to the group of the running command
+func (e *Editor) recordUndo(op undoOp) {
+	if e.undo.suspended {
+		return
+	}
+	if len(e.undo.pending.ops) == 0 {
+		e.undo.pending.cx, e.undo.pending.cy = e.cx, e.cy
+	}
+	e.undo.pending.ops = append(e.undo.pending.ops, op)
+}
+
+// saveRow records the content of a row of the buffer before it is changed. Changes
+// following each other on the same row only need the content before the first one.
+func (e *Editor) saveRow(row *editorRow) {
+	if e.undo.suspended {
+		return
+	}
+	at := e.rowIndex(row)
+	if at < 0 {
+		return // Not a row of the buffer, like the rows of a modal screen
+	}
+	if ops := e.undo.pending.ops; len(ops) > 0 && ops[len(ops)-1].kind == UNDO_CHANGE_ROW && ops[len(ops)-1].at == at {
+		return
+	}
+	e.recordUndo(undoOp{kind: UNDO_CHANGE_ROW, at: at, chars: slices.Clone(row.chars)})
+}
+
+// commitUndo closes the group of the running command, so that the next
+// operations are undone separately
+func (e *Editor) commitUndo() {
+	if len(e.undo.pending.ops) > 0 {
+		e.undo.pending.afterX, e.undo.pending.afterY = e.cx, e.cy
+		e.undo.groups = append(e.undo.groups, e.undo.pending)
+		e.undo.redo = nil // A new change can't be followed by the undone ones
+	}
+	e.undo.pending = undoGroup{}
+}
+
+// resetUndo forgets all recorded changes, like after loading another file
+func (e *Editor) resetUndo() {
+	e.undo = undoHistory{}
+}
+
+// revertLastGroup reverses the operations of the last command in reverse order and
+// puts the cursor back where it was before. It reports whether there was anything to undo.
+func (e *Editor) revertLastGroup() bool {
+	e.commitUndo()
+	if len(e.undo.groups) == 0 {
+		return false
+	}
+	group := e.undo.groups[len(e.undo.groups)-1]
+	e.undo.groups = e.undo.groups[:len(e.undo.groups)-1]
+	e.undo.redo = append(e.undo.redo, e.revertGroup(group))
+	return true
+}
+
+// reapplyLastGroup makes the change that was undone last again and puts the cursor
+// where it was after the change. It reports whether there was anything to redo.
+func (e *Editor) reapplyLastGroup() bool {
+	e.commitUndo()
+	if len(e.undo.redo) == 0 {
+		return false
+	}
+	group := e.undo.redo[len(e.undo.redo)-1]
+	e.undo.redo = e.undo.redo[:len(e.undo.redo)-1]
+	e.undo.groups = append(e.undo.groups, e.revertGroup(group))
+	return true
+}
+
+// revertGroup reverses the operations of group in reverse order and puts the cursor
+// back where it was before them. The reversing operations are recorded themselves and
+// returned as the group that reverts group's revert.
+func (e *Editor) revertGroup(group undoGroup) undoGroup {
+	for i := len(group.ops) - 1; i >= 0; i-- {
+		op := group.ops[i]
+		switch op.kind {
+		case UNDO_INSERT_ROW:
+			e.DeleteRow(op.at)
+		case UNDO_DELETE_ROW:
+			e.InsertRow(op.at, op.chars, len(op.chars))
+		case UNDO_CHANGE_ROW:
+			row := &e.row[op.at]
+			row.replaceBytes(e, 0, len(row.chars), op.chars)
+		}
+	}
+
+	inverse := e.undo.pending
+	e.undo.pending = undoGroup{}
+	inverse.cx, inverse.cy = group.afterX, group.afterY
+	inverse.afterX, inverse.afterY = group.cx, group.cy
+
+	e.cx, e.cy = group.cx, group.cy
+	e.clampSelection()
+	return inverse
+}
+
+// Undo reverts the changes of the last command
+func (e *Editor) Undo() {
+	if !e.revertLastGroup() {
+		e.SetStatusMessage("Nothing to undo")
+	}
+}
+
+// Redo makes the changes that were undone last again
+func (e *Editor) Redo() {
+	if !e.reapplyLastGroup() {
+		e.SetStatusMessage("Nothing to redo")
+	}
+}
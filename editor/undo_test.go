@@ -0,0 +1,97 @@
+package editor
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestUndoDeleteRow(t *testing.T) {
+	lines := []string{"x = 1", "/* start", "middle", "end */", "y = 2"}
+	e := newTestEditor(10, 80)
+	e.Load(strings.NewReader(strings.Join(lines, "\n")), "main.go")
+	if e.revertLastGroup() {
+		t.Fatalf("Expected nothing to undo after loading")
+	}
+
+	e.cy = 1
+	e.DeleteRow(1)
+	if e.row[1].hl[0] == HL_MLCOMMENT {
+		t.Errorf("Expected the row after the deleted comment start to be highlighted again")
+	}
+
+	if !e.revertLastGroup() {
+		t.Fatalf("Expected the deletion to be undone")
+	}
+	if got := e.Lines(); !slices.Equal(got, lines) {
+		t.Errorf("Expected the deleted line to be restored, got %q", got)
+	}
+	for i, want := range []int{HL_NORMAL, HL_MLCOMMENT, HL_MLCOMMENT, HL_MLCOMMENT, HL_NORMAL} {
+		if got := e.row[i].hl[0]; got != want {
+			t.Errorf("Row %d: expected highlighting %d after undo, got %d", i, want, got)
+		}
+	}
+	if e.cy != 1 {
+		t.Errorf("Expected the cursor back on row 1, got %d", e.cy)
+	}
+}
+
+func TestUndoGroupsLineOperations(t *testing.T) {
+	e := newTestEditor(10, 80, "a", "a", "b", "b")
+	e.cy = 3
+
+	// Every removed line is recorded, and undone as one step
+	e.RemoveDuplicateLines(true)
+	e.commitUndo()
+	e.InsertRow(0, []byte("top"), 3)
+	e.commitUndo()
+
+	e.revertLastGroup()
+	if got := e.Lines(); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("Expected only the inserted line to be undone, got %q", got)
+	}
+	e.revertLastGroup()
+	if got := e.Lines(); !slices.Equal(got, []string{"a", "a", "b", "b"}) {
+		t.Errorf("Expected the removed lines to be restored, got %q", got)
+	}
+	if e.cy != 3 {
+		t.Errorf("Expected the cursor back on row 3, got %d", e.cy)
+	}
+}
+
+func TestUndoRedoKeys(t *testing.T) {
+	e := newTestEditor(10, 80, "hello")
+	e.output = newOutput(io.Discard)
+	e.config = DefaultConfig()
+	e.cx = 5
+
+	// Typing, a new line and deleting are undone one keypress at a time
+	pressKeys(e, "!", "\r", "x", "\x7f", "\x1a", "\x1a")
+	if got := e.Lines(); !slices.Equal(got, []string{"hello!", ""}) {
+		t.Fatalf("Expected the typed x and its deletion to be undone, got %q", got)
+	}
+	if e.cy != 1 || e.cx != 0 {
+		t.Errorf("Expected the cursor where x was typed, got (%d,%d)", e.cy, e.cx)
+	}
+	pressKeys(e, "\x1a", "\x1a")
+	if got := e.Lines(); !slices.Equal(got, []string{"hello"}) || e.cx != 5 {
+		t.Fatalf("Expected the original line with the cursor at its end, got %q at %d", got, e.cx)
+	}
+	pressKeys(e, "\x1a")
+	if e.statusMessage != "Nothing to undo" {
+		t.Errorf("Expected nothing left to undo, got %q", e.statusMessage)
+	}
+
+	// Redo makes the changes again and leaves the cursor after them
+	pressKeys(e, "\x19", "\x19")
+	if got := e.Lines(); !slices.Equal(got, []string{"hello!", ""}) || e.cy != 1 || e.cx != 0 {
+		t.Errorf("Expected the changes to be made again, got %q at (%d,%d)", got, e.cy, e.cx)
+	}
+
+	// A new change can't be followed by the undone changes
+	pressKeys(e, "y", "\x19")
+	if got := e.Lines(); !slices.Equal(got, []string{"hello!", "y"}) || e.statusMessage != "Nothing to redo" {
+		t.Errorf("Expected nothing to redo after a new change, got %q", got)
+	}
+}
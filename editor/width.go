@@ -0,0 +1,70 @@
+package editor
+
+import (
+	"strings"
+	"unicode"
+)
+
+// runeWidth returns the number of terminal columns a rune occupies: 0 for combining
+// marks, 2 for wide East Asian characters and emoji, and 1 otherwise
+func runeWidth(r rune) int {
+	switch {
+	case unicode.Is(unicode.Mn, r), unicode.Is(unicode.Me, r):
+		return 0
+	case r >= 0x1100 && r <= 0x115f, // Hangul Jamo
+		r >= 0x2e80 && r <= 0xa4cf && r != 0x303f, // CJK ... Yi
+		r >= 0xac00 && r <= 0xd7a3,                // Hangul syllables
+		r >= 0xf900 && r <= 0xfaff,                // CJK compatibility ideographs
+		r >= 0xfe30 && r <= 0xfe4f,                // CJK compatibility forms
+		r >= 0xff00 && r <= 0xff60,                // Fullwidth forms
+		r >= 0xffe0 && r <= 0xffe6,
+		r >= 0x1f300 && r <= 0x1f64f, // Emoji
+		r >= 0x1f900 && r <= 0x1f9ff,
+		r >= 0x20000 && r <= 0x3fffd:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// displayWidth returns the number of terminal columns s occupies
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// truncateToWidth cuts s at a rune boundary so that it occupies at most width columns
+func truncateToWidth(s string, width int) string {
+	used := 0
+	for i, r := range s {
+		used += runeWidth(r)
+		if used > width {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// expandTabs replaces tabs in s with spaces up to the next multiple of tabStop
+// display columns, so that text after a tab lines up despite wide characters
+func expandTabs(s string, tabStop int) string {
+	if !strings.ContainsRune(s, '\t') {
+		return s
+	}
+	var b strings.Builder
+	col := 0
+	for _, r := range s {
+		if r == '\t' {
+			spaces := tabStop - col%tabStop
+			b.WriteString(strings.Repeat(" ", spaces))
+			col += spaces
+			continue
+		}
+		b.WriteRune(r)
+		col += runeWidth(r)
+	}
+	return b.String()
+}
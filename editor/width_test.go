@@ -0,0 +1,37 @@
+package editor
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateToWidth(t *testing.T) {
+	tests := []struct {
+		input    string
+		width    int
+		expected string
+	}{
+		{"hello", 3, "hel"},
+		{"hello", 10, "hello"},
+		{"héllo", 2, "hé"},
+		{"日本語", 5, "日本"},
+		{"日本語", 1, ""},
+		{"e\u0301x", 1, "e\u0301"}, // combining accent takes no column
+	}
+	for _, tt := range tests {
+		actual := truncateToWidth(tt.input, tt.width)
+		if actual != tt.expected || !utf8.ValidString(actual) {
+			t.Errorf("truncateToWidth(%q, %d) = %q, expected %q", tt.input, tt.width, actual, tt.expected)
+		}
+	}
+}
+
+func TestDrawMessageBarMultiByte(t *testing.T) {
+	e := newTestEditor(5, 4)
+	e.SetStatusMessage("äöüß and more")
+	var abuf appendBuffer
+	e.DrawMessageBar(&abuf)
+	if got := string(abuf.b); got != CLEAR_LINE+"äöüß" {
+		t.Errorf("Expected message cut after 4 columns, got %q", got)
+	}
+}
@@ -0,0 +1,311 @@
+package editor
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+)
+
+// WINDOW_SEPARATOR is drawn in the column between windows side by side
+const WINDOW_SEPARATOR = "│"
+
+// Smallest window sizes a window can be split into, the rows including its status bar
+const (
+	MIN_WINDOW_ROWS = 3
+	MIN_WINDOW_COLS = 10
+)
+
+// window is a view of a buffer in an area of the screen. The active window's view is
+// the editor's cursor and offsets, its slot is stale while it is active.
+type window struct {
+	buffer               int // index of the shown buffer
+	cx, cy               int
+	rowOffset, colOffset int
+	top, left            int // screen position of the top-left corner
+	rows, cols           int // size, the rows including the window's status bar
+}
+
+// layout divides an area of the screen: a leaf shows a window, other nodes split
+// their area between two layouts, stacked or side by side
+type layout struct {
+	win           *window
+	sideBySide    bool
+	first, second *layout
+}
+
+// windowLayout is how the screen is split into windows
+type windowLayout struct {
+	root       *layout
+	windows    []*window // the windows in layout order, left and upper ones first
+	current    int       // index of the active window
+	rows, cols int       // size of the area of the windows, above the message bar
+}
+
+// leaves returns the windows of the layout in order
+func (l *layout) leaves(windows []*window) []*window {
+	if l.win != nil {
+		return append(windows, l.win)
+	}
+	return l.second.leaves(l.first.leaves(windows))
+}
+
+// find returns the leaf that shows w, or nil
+func (l *layout) find(w *window) *layout {
+	if l.win != nil {
+		if l.win == w {
+			return l
+		}
+		return nil
+	}
+	if found := l.first.find(w); found != nil {
+		return found
+	}
+	return l.second.find(w)
+}
+
+// without returns the layout with the leaf of w removed, its sibling taking its place
+func (l *layout) without(w *window) *layout {
+	if l.win != nil {
+		if l.win == w {
+			return nil
+		}
+		return l
+	}
+	first, second := l.first.without(w), l.second.without(w)
+	if first == nil {
+		return second
+	}
+	if second == nil {
+		return first
+	}
+	l.first, l.second = first, second
+	return l
+}
+
+// arrange sets the screen areas of the windows in the layout to share the given area.
+// Windows side by side are separated by a column for WINDOW_SEPARATOR.
+func (l *layout) arrange(top, left, rows, cols int) {
+	if l.win != nil {
+		l.win.top, l.win.left, l.win.rows, l.win.cols = top, left, rows, cols
+		return
+	}
+	if l.sideBySide {
+		firstCols := (cols - 1) / 2
+		l.first.arrange(top, left, rows, firstCols)
+		l.second.arrange(top, left+firstCols+1, rows, cols-firstCols-1)
+		return
+	}
+	firstRows := rows / 2
+	l.first.arrange(top, left, firstRows, cols)
+	l.second.arrange(top+firstRows, left, rows-firstRows, cols)
+}
+
+// isSplit reports whether the screen is split into several windows
+func (e *Editor) isSplit() bool {
+	return len(e.split.windows) > 1
+}
+
+// activeWindow returns the window that has the cursor
+func (e *Editor) activeWindow() *window {
+	return e.split.windows[e.split.current]
+}
+
+// storeWindow saves the view of the active window into its slot
+func (e *Editor) storeWindow() {
+	w := e.activeWindow()
+	w.buffer = e.currentBuffer
+	w.cx, w.cy = e.cx, e.cy
+	w.rowOffset, w.colOffset = e.rowOffset, e.colOffset
+}
+
+// showWindow shows the view of w in its area of the screen, switching to its buffer.
+// Its cursor is kept inside the buffer, which may have been changed in another window.
+func (e *Editor) showWindow(w *window) {
+	if w.buffer != e.currentBuffer && w.buffer < len(e.buffers) {
+		e.storeBuffer()
+		e.loadBuffer(w.buffer)
+	}
+	e.cy = min(w.cy, e.totalRows)
+	e.cx = w.cx
+	if e.cy < e.totalRows {
+		e.cx = min(e.cx, len(e.row[e.cy].chars))
+	}
+	e.rowOffset, e.colOffset = w.rowOffset, w.colOffset
+	e.fitWindow(w)
+}
+
+// fitWindow sizes the text area to the area of w on the screen
+func (e *Editor) fitWindow(w *window) {
+	e.screenRows = max(w.rows-1, 0) // Adjust for the window's status bar
+	e.screenCols = w.cols
+	e.screenTop, e.screenLeft = w.top, w.left
+}
+
+// arrangeWindows shares the area above the message bar between the windows
+func (e *Editor) arrangeWindows(rows, cols int) {
+	e.split.rows, e.split.cols = rows, cols
+	e.split.root.arrange(0, 0, rows, cols)
+}
+
+// SplitWindow splits the active window in two, stacked or side by side, that show
+// the same buffer. The new window, below or to the right, becomes active.
+func (e *Editor) SplitWindow(sideBySide bool) {
+	if !e.isSplit() {
+		// The screen becomes the area of a single window, which is split
+		w := &window{}
+		e.split = windowLayout{root: &layout{win: w}, windows: []*window{w}}
+		e.arrangeWindows(e.screenRows+1, e.screenCols)
+	}
+	e.storeWindow()
+	active := e.activeWindow()
+	if (sideBySide && active.cols < 2*MIN_WINDOW_COLS+1) || (!sideBySide && active.rows < 2*MIN_WINDOW_ROWS) {
+		if !e.isSplit() {
+			e.split = windowLayout{}
+		}
+		e.ShowError("Not enough room to split the window")
+		return
+	}
+
+	added := *active
+	leaf := e.split.root.find(active)
+	*leaf = layout{sideBySide: sideBySide, first: &layout{win: active}, second: &layout{win: &added}}
+	e.split.windows = e.split.root.leaves(nil)
+	e.split.current = slices.Index(e.split.windows, &added)
+	e.arrangeWindows(e.split.rows, e.split.cols)
+	e.showWindow(&added)
+	e.invalidateFrame()
+}
+
+// NextWindow moves the cursor to the next window, or to the previous one for a
+// negative step
+func (e *Editor) NextWindow(step int) {
+	if !e.isSplit() {
+		e.SetStatusMessage("There is only one window")
+		return
+	}
+	e.storeWindow()
+	n := len(e.split.windows)
+	e.split.current = ((e.split.current+step)%n + n) % n
+	e.showWindow(e.activeWindow())
+}
+
+// CloseWindow closes the active window, giving its area to the window next to it.
+// The buffer stays open.
+func (e *Editor) CloseWindow() {
+	if !e.isSplit() {
+		e.SetStatusMessage("There is only one window")
+		return
+	}
+	e.storeWindow()
+	e.split.root = e.split.root.without(e.activeWindow())
+	e.split.windows = e.split.root.leaves(nil)
+	e.split.current = min(e.split.current, len(e.split.windows)-1)
+	e.arrangeWindows(e.split.rows, e.split.cols)
+	e.showWindow(e.activeWindow())
+	if !e.isSplit() {
+		e.split = windowLayout{}
+	}
+	e.invalidateFrame()
+}
+
+// WindowCommand reads the key after Ctrl-W and runs the window command bound to it
+func (e *Editor) WindowCommand() {
+	key := e.PromptKey("Window: (s)plit, (v)ertical split, (w) next, (p)revious, (c)lose")
+	e.SetStatusMessage("")
+	switch key {
+	case 's', withControlKey('s'):
+		e.SplitWindow(false)
+	case 'v', withControlKey('v'):
+		e.SplitWindow(true)
+	case 'w', withControlKey('w'), ARROW_DOWN, ARROW_RIGHT:
+		e.NextWindow(1)
+	case 'p', ARROW_UP, ARROW_LEFT:
+		e.NextWindow(-1)
+	case 'c', 'q':
+		e.CloseWindow()
+	}
+}
+
+// bufferClosed lets the other windows show the current buffer in place of the closed
+// one, and keeps their buffer indices pointing at their buffers
+func (e *Editor) bufferClosed(closed int) {
+	for i, w := range e.split.windows {
+		switch {
+		case i == e.split.current:
+		case w.buffer == closed:
+			*w = window{buffer: e.currentBuffer, top: w.top, left: w.left, rows: w.rows, cols: w.cols}
+		case w.buffer > closed:
+			w.buffer--
+		}
+	}
+}
+
+// useWholeScreen gives the whole screen to a modal screen while windows are split.
+// It returns the function that gives it back to the active window.
+func (e *Editor) useWholeScreen() func() {
+	if !e.isSplit() {
+		return func() {}
+	}
+	e.screenRows = max(e.split.rows-1, 0)
+	e.screenCols = e.split.cols
+	e.screenTop, e.screenLeft = 0, 0
+	e.invalidateFrame()
+	return func() {
+		if e.isSplit() {
+			e.fitWindow(e.activeWindow())
+		}
+		e.invalidateFrame()
+	}
+}
+
+// drawWindows draws the rows and status bar of every window into the lines of the
+// screen above the message bar. Windows to the right are drawn after moving to their
+// column, as clearing the rest of a line in a window erases everything to its right.
+func (e *Editor) drawWindows() [][]byte {
+	e.storeWindow()
+	active := e.activeWindow()
+	saved, cursors, frame := e.selection, e.cursors, e.frame
+	goalRx, hasGoalRx := e.goalRx, e.hasGoalRx
+
+	drawn := make(map[*window][][]byte, len(e.split.windows))
+	draw := func(w *window) {
+		e.Scroll()
+		var abuf appendBuffer
+		e.DrawRows(&abuf)
+		e.DrawStatusBar(&abuf)
+		drawn[w] = bytes.SplitAfter(abuf.b, []byte("\r\n"))
+	}
+
+	// The selection and additional cursors belong to the active window, which is drawn
+	// last so that its buffer is shown afterwards
+	e.selection, e.cursors = selection{}, nil
+	for _, w := range e.split.windows {
+		if w != active {
+			e.showWindow(w)
+			draw(w)
+		}
+	}
+	e.showWindow(active)
+	e.selection, e.cursors, e.frame = saved, cursors, frame
+	e.goalRx, e.hasGoalRx = goalRx, hasGoalRx
+	draw(active)
+
+	lines := make([][]byte, e.split.rows)
+	for _, w := range e.split.windows {
+		for i, line := range drawn[w] {
+			y := w.top + i
+			if y >= len(lines) || i >= w.rows {
+				break
+			}
+			if w.left > 0 {
+				lines[y] = fmt.Appendf(lines[y], CURSOR_COLUMN_FORMAT, w.left)
+				lines[y] = append(lines[y], WINDOW_SEPARATOR...)
+			}
+			lines[y] = append(lines[y], bytes.TrimSuffix(line, []byte("\r\n"))...)
+		}
+	}
+	for y := range lines {
+		lines[y] = append(lines[y], "\r\n"...)
+	}
+	return lines
+}
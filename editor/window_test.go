@@ -0,0 +1,94 @@
+package editor
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitWindowStacked(t *testing.T) {
+	e := newTestEditor(10, 40, numberedLines(30)...)
+	var out bytes.Buffer
+	e.output = newOutput(&out)
+	e.cy = 20
+
+	e.SplitWindow(false)
+	top, bottom := e.split.windows[0], e.split.windows[1]
+	if top.rows != 5 || bottom.top != 5 || bottom.rows != 6 || e.activeWindow() != bottom {
+		t.Fatalf("Expected the 11 rows above the message bar split 5/6 with the lower window active, got %+v %+v", *top, *bottom)
+	}
+	if e.screenRows != 5 || e.cy != 20 {
+		t.Errorf("Expected the new window to show the same place in 5 rows, got %d rows at %d", e.screenRows, e.cy)
+	}
+
+	// Both windows show the buffer with their own cursor and status bar
+	e.InsertChar('x')
+	e.NextWindow(1)
+	e.cy = 0
+	e.RefreshScreen()
+	if got := strings.Count(out.String(), "30 lines"); got != 2 {
+		t.Errorf("Expected a status bar for each window, got %d", got)
+	}
+	if !strings.Contains(out.String(), "xline 21") {
+		t.Errorf("Expected the change to be shown in both windows")
+	}
+	e.NextWindow(1)
+	if e.cy != 20 || e.cx != 1 {
+		t.Errorf("Expected the lower window's cursor to be kept, got (%d,%d)", e.cy, e.cx)
+	}
+}
+
+func TestSplitWindowSideBySide(t *testing.T) {
+	e := newTestEditor(10, 40, "left", "text")
+	var out bytes.Buffer
+	e.output = newOutput(&out)
+
+	e.SplitWindow(true)
+	left, right := e.split.windows[0], e.split.windows[1]
+	if left.cols != 19 || right.left != 20 || right.cols != 20 {
+		t.Fatalf("Expected 40 columns split 19/20 around a separator, got %+v %+v", *left, *right)
+	}
+	e.RefreshScreen()
+	if !strings.Contains(out.String(), "\x1b[20G"+WINDOW_SEPARATOR+"left") {
+		t.Errorf("Expected the right window after the separator, got %q", out.String())
+	}
+
+	e.CloseWindow()
+	if e.isSplit() || e.screenRows != 10 || e.screenCols != 40 || e.screenLeft != 0 {
+		t.Errorf("Expected the remaining window to get the whole screen, got %dx%d", e.screenCols, e.screenRows)
+	}
+}
+
+func TestWindowsShowDifferentBuffers(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.txt")
+	second := filepath.Join(dir, "second.txt")
+	os.WriteFile(first, []byte("first\n"), 0644)
+	os.WriteFile(second, []byte("second\n"), 0644)
+
+	e := newTestEditor(10, 80)
+	e.output = newOutput(io.Discard)
+	e.config = DefaultConfig()
+	e.Open(first)
+	e.SplitWindow(false)
+	e.OpenBuffer(second)
+	e.RefreshScreen()
+	if e.filename != second || e.screenRows != 5 {
+		t.Fatalf("Expected drawing the other window to keep the active one shown, got %q", e.filename)
+	}
+
+	e.NextWindow(1)
+	if e.filename != first {
+		t.Errorf("Expected the upper window to show %q, got %q", first, e.filename)
+	}
+
+	// Windows showing a closed buffer show the current one instead
+	e.CloseBuffer()
+	e.NextWindow(1)
+	if e.filename != second || e.bufferCount() != 1 {
+		t.Errorf("Expected both windows to show %q, got %q", second, e.filename)
+	}
+}
@@ -1,16 +1,107 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/hnnsb/kigo/editor"
 )
 
+// options are the settings given on the command line
+type options struct {
+	files     []string
+	line      int    // 1-based line to start at, 0 to keep the default, -1 for the last line
+	search    string // text to search for after opening
+	debugKeys bool   // show unrecognized key sequences in the message bar
+}
+
+// parseArgs parses "[+N] [-c search] [-d] [filename...]". A bare "+" starts at the last line.
+func parseArgs(args []string) (options, error) {
+	var opts options
+	flags := true
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case flags && arg == "--":
+			flags = false
+		case flags && arg == "+":
+			opts.line = -1
+		case flags && strings.HasPrefix(arg, "+"):
+			line, err := strconv.Atoi(arg[1:])
+			if err != nil || line < 1 {
+				return opts, fmt.Errorf("invalid line number %q", arg)
+			}
+			opts.line = line
+		case flags && arg == "-c":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("flag -c needs a search text")
+			}
+			i++
+			opts.search = args[i]
+		case flags && arg == "-d":
+			opts.debugKeys = true
+		case flags && strings.HasPrefix(arg, "-") && arg != "-":
+			return opts, fmt.Errorf("unknown flag %q", arg)
+		default:
+			opts.files = append(opts.files, arg)
+		}
+	}
+	return opts, nil
+}
+
+// openFiles opens every file in its own buffer and shows the first one. Files that
+// don't exist yet are opened empty, like a single file, and files that can't be
+// opened are skipped with a warning.
+func openFiles(e *editor.Editor, files []string) {
+	var failed []string
+	opened := 0
+	for _, filename := range files {
+		var err error
+		if opened == 0 {
+			err = e.Open(filename)
+		} else {
+			err = e.OpenBuffer(filename)
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			failed = append(failed, filename)
+			continue
+		}
+		opened++
+	}
+	e.SwitchBuffer(0)
+	if len(failed) > 0 {
+		e.ShowWarning("could not open %s", strings.Join(failed, ", "))
+	}
+}
+
+// isDirectory reports whether path names an existing directory
+func isDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
 func main() {
+	opts, err := parseArgs(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\nUsage: kigo [+N] [-c search] [-d] [filename...]\n", err)
+		os.Exit(2)
+	}
+
+	piped := !editor.StdinIsTerminal()
+	config := editor.DefaultConfig()
+	configErr := editor.LoadConfigFile(editor.DefaultConfigPath(), &config)
+	syntaxDir := editor.DefaultSyntaxDir()
+	config.RecentFilesPath = editor.DefaultRecentFilesPath()
+	config.DebugKeys = opts.debugKeys
 	editor := editor.NewEditor()
+	editor.SetConfig(config)
+	syntaxErr := editor.LoadSyntaxDir(syntaxDir)
 
-	args := os.Args[1:]
-	err := editor.EnableRawMode()
+	err = editor.EnableRawMode()
 	if err != nil {
 		editor.Die("enabling raw mode: %s", err.Error())
 	}
@@ -22,12 +113,37 @@ func main() {
 	}
 
 	editor.SetStatusMessage("HELP: Ctrl-S = save | Ctrl-Q = quit | Ctrl-F = find")
+	if configErr != nil {
+		editor.ShowWarning("config: %v", configErr)
+	}
+	if syntaxErr != nil {
+		editor.ShowWarning("syntax files: %v", syntaxErr)
+	}
 
-	if len(args) >= 1 {
-		err = editor.Open(args[0])
+	if len(opts.files) == 1 && isDirectory(opts.files[0]) {
+		editor.ExploreDirectory(opts.files[0])
+	} else if len(opts.files) == 1 {
+		err = editor.Open(opts.files[0])
 		if err != nil {
 			editor.ShowError("%v", err)
 		}
+	} else if len(opts.files) > 1 {
+		openFiles(&editor, opts.files)
+	} else if piped {
+		err = editor.OpenStdin()
+		if err != nil {
+			editor.ShowError("%v", err)
+		}
+	}
+
+	switch {
+	case opts.line > 0:
+		editor.GotoLine(opts.line)
+	case opts.line < 0:
+		editor.GotoPercent(100)
+	}
+	if opts.search != "" {
+		editor.Search(opts.search)
 	}
 
 	for {
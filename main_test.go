@@ -0,0 +1,39 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected options
+	}{
+		{[]string{"file.go"}, options{files: []string{"file.go"}}},
+		{[]string{"+12", "file.go"}, options{files: []string{"file.go"}, line: 12}},
+		{[]string{"+", "-c", "func main", "file.go"}, options{files: []string{"file.go"}, line: -1, search: "func main"}},
+		{[]string{"-"}, options{files: []string{"-"}}},
+		{[]string{"--", "-c"}, options{files: []string{"-c"}}},
+		{[]string{"-d", "file.go"}, options{files: []string{"file.go"}, debugKeys: true}},
+	}
+	for _, tt := range tests {
+		actual, err := parseArgs(tt.args)
+		if err != nil {
+			t.Errorf("parseArgs(%q) returned %v", tt.args, err)
+			continue
+		}
+		if actual.line != tt.expected.line || actual.search != tt.expected.search ||
+			actual.debugKeys != tt.expected.debugKeys || !slices.Equal(actual.files, tt.expected.files) {
+			t.Errorf("parseArgs(%q) = %+v, expected %+v", tt.args, actual, tt.expected)
+		}
+	}
+}
+
+func TestParseArgsErrors(t *testing.T) {
+	for _, args := range [][]string{{"-x"}, {"+abc"}, {"+0"}, {"-c"}} {
+		if _, err := parseArgs(args); err == nil {
+			t.Errorf("parseArgs(%q) should fail", args)
+		}
+	}
+}